@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// acknowledge records in the store that the current prayer was acknowledged,
+// which stops the reminders repeated until then. The store is shared with
+// the daemon so that `adhan ack` from another terminal stops its reminders.
+func acknowledge() error {
+	return updateStore(func(tx *bolt.Tx) error {
+		// A pending snooze is for the prayer acknowledged.
+		if err := tx.Bucket(stateBucket).Delete(snoozeKey); err != nil {
+			return err
+		}
+		return tx.Bucket(stateBucket).Put(ackKey, []byte(clock.Now().Format(time.RFC3339)))
+	})
+}
+
+// acknowledged reports whether the prayer due at t was acknowledged, that is
+// whether an acknowledgement was recorded since.
+func acknowledged(t time.Time) bool {
+	var at time.Time
+	err := viewStore(func(tx *bolt.Tx) error {
+		var err error
+		at, err = time.Parse(time.RFC3339, string(tx.Bucket(stateBucket).Get(ackKey)))
+		return err
+	})
+	return err == nil && !at.Before(t.Truncate(time.Second))
+}
+
+// ack acknowledges the current prayer and tells the user.
+func ack() error {
+	if err := acknowledge(); err != nil {
+		return fmt.Errorf("failed to acknowledge: %w", err)
+	}
+	fmt.Println("Acknowledged, reminders stopped until the next prayer")
+	return nil
+}
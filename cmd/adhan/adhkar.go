@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// AdhkarConfig configures the reminder of the adhkar said after each prayer.
+type AdhkarConfig struct {
+	Enabled bool `toml:"enabled"`
+	// After is how many minutes after the prayer time to remind, 10 by
+	// default. prayers.<name>.adhkar overrides it for a single prayer.
+	After int `toml:"after"`
+	// Message replaces the reminder text, {prayer} is replaced with the name
+	// of the prayer.
+	Message string `toml:"message"`
+}
+
+// adhkarAfter returns how long after prayer to remind of the adhkar, if at
+// all.
+func (c Config) adhkarAfter(prayer string) (time.Duration, bool) {
+	if prayer == "Sunrise" {
+		return 0, false
+	}
+	if p := c.prayerConfig(prayer); p.Adhkar != nil {
+		return time.Duration(*p.Adhkar) * time.Minute, *p.Adhkar > 0
+	}
+	if !c.Adhkar.Enabled {
+		return 0, false
+	}
+	if c.Adhkar.After <= 0 {
+		return 10 * time.Minute, true
+	}
+	return time.Duration(c.Adhkar.After) * time.Minute, true
+}
+
+// adhkarReminders reminds of the adhkar a while after each prayer.
+func (s *scheduler) adhkarReminders(prayers []adhan.Prayer) []adhan.Reminder {
+	var reminders []adhan.Reminder
+	for _, p := range prayers {
+		after, ok := s.cfg.adhkarAfter(p.Name)
+		if !ok {
+			continue
+		}
+
+		name := s.cfg.localName(p)
+		message := s.cfg.tr("Remember the adhkar after %s: Astaghfirullah three times, Ayat al-Kursi, then SubhanAllah, Alhamdulillah and Allahu Akbar 33 times each.", name)
+		if s.cfg.Adhkar.Message != "" {
+			message = strings.ReplaceAll(s.cfg.Adhkar.Message, "{prayer}", name)
+		}
+		reminders = append(reminders, adhan.Reminder{
+			Title:   s.cfg.tr("Adhkar"),
+			Message: message,
+			Time:    p.Time.Add(after),
+		})
+	}
+	return reminders
+}
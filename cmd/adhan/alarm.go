@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// alarmLimit is how long the Fajr alarm rings at most when nobody dismisses
+// it.
+const alarmLimit = time.Hour
+
+// AlarmConfig configures the Fajr alarm, which rings before Fajr louder and
+// louder until dismissed with adhan ack or from its notification. It rings
+// regardless of quiet hours.
+type AlarmConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Before is how many minutes before Fajr to ring, 15 by default.
+	Before int `toml:"before"`
+	// File is the sound rung, the adhan of Fajr by default.
+	File string `toml:"file"`
+}
+
+func (a AlarmConfig) before() time.Duration {
+	if a.Before <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(a.Before) * time.Minute
+}
+
+// alarmFile returns the sound of the Fajr alarm, if any.
+func (c Config) alarmFile() string {
+	if c.Alarm.File != "" {
+		return c.Alarm.File
+	}
+	if p := c.prayerConfig("Fajr"); p.File != "" {
+		return p.File
+	}
+	return c.Audio.File
+}
+
+// scheduleAlarm sets the alarm to ring before next if it is Fajr, unless it
+// is set for it already.
+func (s *scheduler) scheduleAlarm(ctx context.Context, next adhan.Prayer) {
+	if !s.cfg.Alarm.Enabled || next.Name != "Fajr" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.alarmFor.Equal(next.Time) {
+		return
+	}
+	if s.alarm != nil {
+		s.alarm.Stop()
+	}
+	s.alarmFor = next.Time
+	at := next.Time.Add(-s.cfg.Alarm.before())
+	slog.Debug("Scheduled Fajr alarm", "time", at)
+	// Started within the time before Fajr, the alarm rings right away.
+	s.alarm = clock.AfterFunc(at.Sub(clock.Now()), func() {
+		s.ringAlarm(ctx, next)
+	})
+}
+
+// ringAlarm plays the alarm sound over and over, louder each time, until it
+// is dismissed or rang for alarmLimit.
+func (s *scheduler) ringAlarm(ctx context.Context, fajr adhan.Prayer) {
+	ctx, cancel := context.WithCancel(ctx)
+	limit := clock.AfterFunc(alarmLimit, cancel)
+	defer limit.Stop()
+
+	s.mu.Lock()
+	s.ringing = cancel
+	s.mu.Unlock()
+	defer s.dismissAlarm()
+
+	slog.Info("Ringing Fajr alarm", "fajr", fajr.Time)
+	notify(s.notifier, Notification{
+		Title:   s.cfg.tr("Fajr alarm"),
+		Message: s.cfg.tr("Wake up, Fajr is at %s.", s.cfg.formatTime(fajr.Time)),
+		Urgency: UrgencyCritical,
+		Actions: []Action{{Key: "ack", Label: s.cfg.tr("I'm up")}},
+		OnAction: func(string) {
+			s.dismissAlarm()
+		},
+	})
+
+	file := s.cfg.alarmFile()
+	if file == "" {
+		slog.Warn("No sound for the Fajr alarm, set alarm.file")
+		return
+	}
+	for volume := 20; ; volume = min(volume+20, 100) {
+		cmd, err := volumeCommand(file, volume)
+		if err != nil {
+			slog.Error("Failed to ring Fajr alarm", "err", err)
+			return
+		}
+		done := make(chan struct{})
+		started := time.Now()
+		if err := s.player.start(func() { close(done) }, cmd); err != nil {
+			slog.Error("Failed to ring Fajr alarm", "err", err)
+			return
+		}
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			s.player.stop()
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		// A player exiting at once can't play the file, rather than
+		// ringing silently in a loop.
+		if time.Since(started) < time.Second {
+			slog.Error("Failed to ring Fajr alarm, the sound could not be played", "file", file)
+			return
+		}
+	}
+}
+
+// alarmRinging reports whether the Fajr alarm is ringing.
+func (s *scheduler) alarmRinging() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ringing != nil
+}
+
+// dismissAlarm silences the Fajr alarm and reports whether it was ringing.
+func (s *scheduler) dismissAlarm() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ringing == nil {
+		return false
+	}
+	s.ringing()
+	s.ringing = nil
+	s.player.stop()
+	return true
+}
+
+// cancelAlarm unsets the Fajr alarm, and silences it if it is ringing.
+func (s *scheduler) cancelAlarm() {
+	s.dismissAlarm()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.alarm != nil {
+		s.alarm.Stop()
+		s.alarm = nil
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// mosqueJPEG is the image shown in notifications unless configured
+// otherwise.
+//
+//go:embed assets/mosque.jpeg
+var mosqueJPEG []byte
+
+// notificationIcon returns the path of the image shown in notifications: the
+// configured one, or the mosque written to the cache directory for the
+// notification servers to read. It is empty if neither is available.
+func notificationIcon(cfg NotificationConfig) string {
+	if cfg.Icon != "" {
+		return cfg.Icon
+	}
+	path, err := writeAsset("mosque.jpeg", mosqueJPEG)
+	if err != nil {
+		slog.Warn("Failed to write notification icon", "err", err)
+		return ""
+	}
+	return path
+}
+
+// writeAsset writes an embedded asset to ~/.cache/adhan/assets, unless it is
+// there already, and returns its path.
+func writeAsset(name string, data []byte) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "adhan", "assets", name)
+	if raw, err := os.ReadFile(path); err == nil && bytes.Equal(raw, data) {
+		return path, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, data, 0o644)
+}
+
+// resolvePath returns path relative to the directory dir of the config file,
+// rather than to the working directory, unless it is absolute.
+func resolvePath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// resolveSound resolves a notification sound like resolvePath, when it is a
+// path rather than the name of a system sound.
+func resolveSound(dir, sound string) string {
+	if strings.ContainsAny(sound, `/\`) {
+		return resolvePath(dir, sound)
+	}
+	return sound
+}
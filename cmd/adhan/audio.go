@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"sync"
+)
+
+var (
+	errNoPlayer = errors.New("no audio player available")
+	errNoSpeech = errors.New("no speech synthesizer available")
+)
+
+// audioPlayer plays the adhan through an external player process, so that
+// it can be stopped at any time by killing that process.
+type audioPlayer struct {
+	mu  sync.Mutex
+	cmd *exec.Cmd
+	// done is called once the commands are over, whether they finished or
+	// were stopped.
+	done func()
+}
+
+// start runs the player commands one after the other in the background,
+// stopping whatever was playing before. done, if not nil, is called once
+// they are over.
+func (p *audioPlayer) start(done func(), cmds ...*exec.Cmd) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopLocked()
+	p.done = done
+	if err := p.startLocked(cmds); err != nil {
+		p.finishLocked()
+		return err
+	}
+	return nil
+}
+
+func (p *audioPlayer) startLocked(cmds []*exec.Cmd) error {
+	cmd := cmds[0]
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p.cmd = cmd
+
+	go func() {
+		cmd.Wait()
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		// Stopped, or replaced by something else.
+		if p.cmd != cmd {
+			return
+		}
+		p.cmd = nil
+		if len(cmds) > 1 {
+			err := p.startLocked(cmds[1:])
+			if err == nil {
+				return
+			}
+			slog.Error("Failed to play adhan", "err", err)
+		}
+		p.finishLocked()
+	}()
+
+	return nil
+}
+
+// stop silences the adhan and reports whether anything was playing.
+func (p *audioPlayer) stop() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.stopLocked()
+}
+
+func (p *audioPlayer) stopLocked() bool {
+	if p.cmd == nil {
+		return false
+	}
+
+	p.cmd.Process.Kill()
+	p.cmd = nil
+	p.finishLocked()
+	return true
+}
+
+func (p *audioPlayer) finishLocked() {
+	if p.done != nil {
+		go p.done()
+		p.done = nil
+	}
+}
+
+// lookSpeech returns a command saying text with the first of the candidate
+// synthesizers that is installed, in the given voice when it is set and the
+// synthesizer has a flag for it.
+func lookSpeech(text, voice string, candidates []speechCandidate) (*exec.Cmd, error) {
+	for _, c := range candidates {
+		bin, err := exec.LookPath(c.args[0])
+		if err != nil {
+			continue
+		}
+		args := c.args[1:len(c.args):len(c.args)]
+		if voice != "" && c.voice != "" {
+			args = append(args, c.voice, voice)
+		}
+		return exec.Command(bin, append(args, text)...), nil
+	}
+	return nil, errNoSpeech
+}
+
+// speechCandidate is a speech synthesizer command, and its flag selecting the
+// voice.
+type speechCandidate struct {
+	args  []string
+	voice string
+}
+
+// playerCommand returns a command playing path at full volume.
+func playerCommand(path string) (*exec.Cmd, error) {
+	return volumeCommand(path, 100)
+}
+
+// playerCandidate is an audio player command, and how to lower its volume.
+type playerCandidate struct {
+	args []string
+	// volume returns the flags playing at percent of the full volume, nil if
+	// the player has none.
+	volume func(percent int) []string
+}
+
+// lookPlayer returns a command for the first of the candidate players that is
+// installed, playing at percent of the full volume where the player allows.
+func lookPlayer(path string, percent int, candidates []playerCandidate) (*exec.Cmd, error) {
+	for _, c := range candidates {
+		bin, err := exec.LookPath(c.args[0])
+		if err != nil {
+			continue
+		}
+		args := c.args[1:len(c.args):len(c.args)]
+		if percent < 100 && c.volume != nil {
+			args = append(args, c.volume(percent)...)
+		}
+		return exec.Command(bin, append(args, path)...), nil
+	}
+	return nil, errNoPlayer
+}
+
+// Volume flags shared by the players found on several platforms.
+var (
+	mpvVolume = func(percent int) []string {
+		return []string{"--volume=" + strconv.Itoa(percent)}
+	}
+	ffplayVolume = func(percent int) []string {
+		return []string{"-volume", strconv.Itoa(percent)}
+	}
+)
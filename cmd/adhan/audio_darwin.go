@@ -0,0 +1,20 @@
+package main
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+func volumeCommand(path string, percent int) (*exec.Cmd, error) {
+	if percent < 100 {
+		return exec.Command("afplay", "-v", strconv.FormatFloat(float64(percent)/100, 'f', 2, 64), path), nil
+	}
+	return exec.Command("afplay", path), nil
+}
+
+func speechCommand(text, voice string) (*exec.Cmd, error) {
+	if voice != "" {
+		return exec.Command("say", "-v", voice, text), nil
+	}
+	return exec.Command("say", text), nil
+}
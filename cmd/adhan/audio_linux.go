@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+func volumeCommand(path string, percent int) (*exec.Cmd, error) {
+	return lookPlayer(path, percent, []playerCandidate{
+		{args: []string{"mpv", "--no-video", "--really-quiet"}, volume: mpvVolume},
+		{args: []string{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"}, volume: ffplayVolume},
+		{args: []string{"mpg123", "-q"}, volume: func(percent int) []string {
+			return []string{"-f", strconv.Itoa(32768 * percent / 100)}
+		}},
+		{args: []string{"ogg123", "-q"}},
+		{args: []string{"paplay"}, volume: func(percent int) []string {
+			return []string{"--volume=" + strconv.Itoa(65536*percent/100)}
+		}},
+	})
+}
+
+func speechCommand(text, voice string) (*exec.Cmd, error) {
+	return lookSpeech(text, voice, []speechCandidate{
+		{args: []string{"espeak-ng"}, voice: "-v"},
+		{args: []string{"espeak"}, voice: "-v"},
+		{args: []string{"spd-say", "--wait"}, voice: "-y"},
+	})
+}
@@ -0,0 +1,19 @@
+//go:build !darwin && !linux && !windows
+
+package main
+
+import "os/exec"
+
+func volumeCommand(path string, percent int) (*exec.Cmd, error) {
+	return lookPlayer(path, percent, []playerCandidate{
+		{args: []string{"mpv", "--no-video", "--really-quiet"}, volume: mpvVolume},
+		{args: []string{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"}, volume: ffplayVolume},
+	})
+}
+
+func speechCommand(text, voice string) (*exec.Cmd, error) {
+	return lookSpeech(text, voice, []speechCandidate{
+		{args: []string{"espeak-ng"}, voice: "-v"},
+		{args: []string{"espeak"}, voice: "-v"},
+	})
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+func volumeCommand(path string, percent int) (*exec.Cmd, error) {
+	script := `$p = New-Object -ComObject WMPlayer.OCX; `
+	if percent < 100 {
+		script += `$p.settings.volume = ` + strconv.Itoa(percent) + `; `
+	}
+	script += `$p.URL = '` + strings.ReplaceAll(path, "'", "''") + `'; ` +
+		`$p.controls.play(); Start-Sleep 1; while ($p.playState -eq 3) { Start-Sleep 1 }`
+	return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script), nil
+}
+
+// speechCommand speaks through SAPI, by way of System.Speech.
+func speechCommand(text, voice string) (*exec.Cmd, error) {
+	quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+
+	script := `Add-Type -AssemblyName System.Speech; $s = New-Object System.Speech.Synthesis.SpeechSynthesizer; `
+	if voice != "" {
+		script += `$s.SelectVoice(` + quote(voice) + `); `
+	}
+	script += `$s.Speak(` + quote(text) + `)`
+	return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script), nil
+}
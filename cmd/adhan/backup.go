@@ -0,0 +1,180 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"iustusae/adhan/internal/control"
+)
+
+// Files of a backup.
+const (
+	backupConfig  = "config.toml"
+	backupProfile = "profile"
+	backupStore   = "adhan.db"
+)
+
+// backupFiles writes a zip archive of the config file, the profile in use
+// and the store, with the prayer log and the stored calendars, to path.
+func backupFiles(path, cfgPath string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(f)
+
+	err = func() error {
+		var saved []string
+		files := map[string]string{backupConfig: cfgPath}
+		if p, err := profilePath(); err == nil {
+			files[backupProfile] = p
+		}
+		for _, name := range []string{backupConfig, backupProfile} {
+			raw, err := os.ReadFile(files[name])
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			w, err := createEntry(zw, name)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(raw); err != nil {
+				return err
+			}
+			saved = append(saved, name)
+		}
+
+		// A read transaction gives a consistent copy of the store, even
+		// while the daemon writes to it.
+		err := viewStore(func(tx *bolt.Tx) error {
+			w, err := createEntry(zw, backupStore)
+			if err != nil {
+				return err
+			}
+			_, err = tx.WriteTo(w)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		saved = append(saved, backupStore)
+
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		fmt.Printf("Backed up %s to %s\n", strings.Join(saved, ", "), path)
+		return nil
+	}()
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("failed to back up: %w", err)
+	}
+	return f.Close()
+}
+
+// createEntry adds a compressed file dated now to zw.
+func createEntry(zw *zip.Writer, name string) (io.Writer, error) {
+	return zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+}
+
+// restoreFiles replaces the config file, the profile in use and the store
+// with those of the backup at path, and has the running instance reload
+// them.
+func restoreFiles(ctx context.Context, path, cfgPath string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer zr.Close()
+
+	dbPath, err := storePath()
+	if err != nil {
+		return err
+	}
+	files := map[string]string{backupConfig: cfgPath, backupStore: dbPath}
+	if p, err := profilePath(); err == nil {
+		files[backupProfile] = p
+	}
+
+	var restored []string
+	for _, zf := range zr.File {
+		dest, ok := files[zf.Name]
+		if !ok {
+			continue
+		}
+		if err := restoreFile(zf, dest); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", zf.Name, err)
+		}
+		restored = append(restored, zf.Name)
+	}
+	if len(restored) == 0 {
+		return fmt.Errorf("%s is not a backup of adhan", path)
+	}
+	// No profile was in use when backing up.
+	if p := files[backupProfile]; p != "" && !slices.Contains(restored, backupProfile) {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	fmt.Printf("Restored %s from %s\n", strings.Join(restored, ", "), path)
+
+	out, err := control.Send(ctx, "reload")
+	switch {
+	case errors.Is(err, control.ErrNotRunning):
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "The running instance keeps the previous settings until restarted:", err)
+	case out != "":
+		fmt.Println(out)
+	}
+	return nil
+}
+
+// restoreFile writes the file of a backup to dest, replacing it at once
+// once written in full.
+func restoreFile(zf *zip.File, dest string) error {
+	r, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp := dest + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && zf.Name == backupStore {
+		// Rather than replacing the store with a broken one.
+		var db *bolt.DB
+		if db, err = bolt.Open(tmp, 0o644, &bolt.Options{ReadOnly: true, Timeout: storeTimeout}); err == nil {
+			db.Close()
+		}
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"iustusae/adhan/internal/ical"
+)
+
+const (
+	// busyRefresh is how long the busy times of the calendar are cached.
+	busyRefresh = 15 * time.Minute
+	// busyCheck is how often to check whether the user is still busy, when
+	// it is unknown until when.
+	busyCheck = time.Minute
+)
+
+var busyClient = &http.Client{Timeout: 10 * time.Second}
+
+// BusyConfig configures muting the adhan while the user is busy, as in a
+// meeting: prayers are notified silently, then again once no longer busy.
+type BusyConfig struct {
+	// Focus mutes while a Focus, such as Do Not Disturb, is on, on macOS.
+	Focus bool `toml:"focus"`
+	// Calendar is the address of an iCalendar feed of the busy times, as the
+	// secret address of a Google Calendar, or a CalDAV calendar or free/busy
+	// URL, with the user name and password in it if needed.
+	Calendar string `toml:"calendar"`
+}
+
+// busyCalendar caches the busy times of a calendar feed.
+type busyCalendar struct {
+	url string
+	loc *time.Location
+
+	mu      sync.Mutex
+	fetched time.Time
+	periods []ical.Period
+}
+
+func newBusyCalendar(url string, loc *time.Location) *busyCalendar {
+	if url == "" {
+		return nil
+	}
+	// webcal:// addresses are served over HTTPS.
+	if rest, ok := strings.CutPrefix(url, "webcal://"); ok {
+		url = "https://" + rest
+	}
+	return &busyCalendar{url: url, loc: loc}
+}
+
+// busyUntil returns when the busy time t falls in ends, merged with those
+// following it without a break, if it falls in one.
+func (b *busyCalendar) busyUntil(t time.Time) (time.Time, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.fetched.IsZero() || t.Sub(b.fetched) > busyRefresh || t.Before(b.fetched) {
+		periods, err := b.fetch(t)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		b.periods, b.fetched = periods, t
+	}
+
+	end := t
+	for _, p := range b.periods {
+		if !p.Start.After(end) && p.End.After(end) {
+			end = p.End
+		}
+	}
+	return end, end.After(t), nil
+}
+
+// fetch returns the busy times of the calendar from the day before t until
+// the one after.
+func (b *busyCalendar) fetch(t time.Time) ([]ical.Period, error) {
+	resp, err := busyClient.Get(b.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return ical.Busy(resp.Body, t.AddDate(0, 0, -1), t.AddDate(0, 0, 2), b.loc)
+}
+
+// busyUntil reports whether the user is busy at t, and until when, zero if
+// that is unknown, as for a Focus.
+func (s *scheduler) busyUntil(t time.Time) (time.Time, bool) {
+	if s.cfg.Busy.Focus {
+		on, err := focusOn()
+		if err != nil {
+			slog.Warn("Failed to check whether a Focus is on", "err", err)
+		} else if on {
+			return time.Time{}, true
+		}
+	}
+	if s.busy == nil {
+		return time.Time{}, false
+	}
+	until, busy, err := s.busy.busyUntil(t)
+	if err != nil {
+		slog.Warn("Failed to fetch busy times", "err", err)
+		return time.Time{}, false
+	}
+	return until, busy
+}
+
+// busyWait returns how long to wait before checking again whether the user
+// is busy, being busy until then.
+func busyWait(until time.Time) time.Duration {
+	if d := until.Sub(clock.Now()); !until.IsZero() && d > 0 {
+		return d
+	}
+	return busyCheck
+}
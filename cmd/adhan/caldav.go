@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"iustusae/adhan/internal/ical"
+)
+
+// caldavEventPrefix starts the UIDs of the events adhan creates, which are
+// the only ones it updates or removes.
+const caldavEventPrefix = "adhan-"
+
+var caldavClient = &http.Client{Timeout: 30 * time.Second}
+
+// CalDAVSyncConfig configures syncing to a calendar of a CalDAV server, as
+// those of Nextcloud, Fastmail or iCloud.
+type CalDAVSyncConfig struct {
+	// URL is that of the calendar, e.g.
+	// https://cloud.example.com/remote.php/dav/calendars/me/prayer-times/.
+	URL      string `toml:"url"`
+	Username string `toml:"username"`
+	// Password is better an app password, as required by iCloud and
+	// Fastmail.
+	Password string `toml:"password"`
+}
+
+// caldavCalendar calls a CalDAV server on a calendar.
+type caldavCalendar struct {
+	url      *url.URL
+	username string
+	password string
+}
+
+// caldavError is an unexpected response of a CalDAV server.
+type caldavError struct {
+	Status string
+}
+
+func (e *caldavError) Error() string {
+	return "CalDAV: " + e.Status
+}
+
+// do sends a request for the resource at href, relative to the calendar, and
+// returns the body of the response.
+func (c caldavCalendar) do(ctx context.Context, method, href string, header http.Header, body []byte) ([]byte, error) {
+	ref, err := url.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.url.ResolveReference(ref).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("User-Agent", "adhan")
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := caldavClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, &caldavError{Status: resp.Status}
+	}
+	return raw, nil
+}
+
+// caldavEvent is an event of the calendar, at href.
+type caldavEvent struct {
+	ical.Event
+	href string
+	etag string
+}
+
+// caldavMultistatus is the response of a REPORT request.
+type caldavMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				ETag string `xml:"DAV: getetag"`
+				Data string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+const caldavQuery = `<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <d:getetag/>
+    <c:calendar-data/>
+  </d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VEVENT">
+        <c:time-range start="%s" end="%s"/>
+      </c:comp-filter>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`
+
+// events returns the events of the calendar between from and to.
+func (c caldavCalendar) events(ctx context.Context, from, to time.Time) ([]caldavEvent, error) {
+	const layout = "20060102T150405Z"
+	query := fmt.Sprintf(caldavQuery, from.UTC().Format(layout), to.UTC().Format(layout))
+	raw, err := c.do(ctx, "REPORT", "", http.Header{
+		"Depth":        {"1"},
+		"Content-Type": {"application/xml; charset=utf-8"},
+	}, []byte(query))
+	if err != nil {
+		return nil, err
+	}
+
+	var ms caldavMultistatus
+	if err := xml.Unmarshal(raw, &ms); err != nil {
+		return nil, fmt.Errorf("failed to read the events: %w", err)
+	}
+	var events []caldavEvent
+	for _, r := range ms.Responses {
+		for _, ps := range r.Propstat {
+			if ps.Prop.Data == "" {
+				continue
+			}
+			parsed, err := ical.Events(strings.NewReader(ps.Prop.Data))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", r.Href, err)
+			}
+			// Recurring events come with their exceptions, the first
+			// event is the one that counts here.
+			if len(parsed) > 0 {
+				events = append(events, caldavEvent{Event: parsed[0], href: r.Href, etag: ps.Prop.ETag})
+			}
+		}
+	}
+	return events, nil
+}
+
+// put writes event to the calendar, creating it unless etag is set.
+func (c caldavCalendar) put(ctx context.Context, href, etag string, event ical.Event) error {
+	var body bytes.Buffer
+	if err := ical.Write(&body, "-//adhan//adhan//EN", event); err != nil {
+		return err
+	}
+	header := http.Header{"Content-Type": {"text/calendar; charset=utf-8"}}
+	if etag != "" {
+		header.Set("If-Match", etag)
+	} else {
+		header.Set("If-None-Match", "*")
+	}
+	_, err := c.do(ctx, http.MethodPut, href, header, body.Bytes())
+	return err
+}
+
+// syncCalDAV syncs the prayer times of the coming days to a calendar of a
+// CalDAV server.
+func syncCalDAV(ctx context.Context, cfg Config) error {
+	dav := cfg.Sync.CalDAV
+	if dav.URL == "" {
+		return errors.New("set sync.caldav.url to the address of the calendar")
+	}
+	u, err := url.Parse(dav.URL)
+	if err != nil {
+		return fmt.Errorf("invalid sync.caldav.url: %w", err)
+	}
+	// Events are added under the calendar, which its trailing slash makes
+	// the base of.
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	c := caldavCalendar{url: u, username: dav.Username, password: dav.Password}
+
+	loc, err := cfg.location()
+	if err != nil {
+		return err
+	}
+	now := clock.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	events, err := syncEvents(ctx, cfg, from)
+	if err != nil {
+		return err
+	}
+	stats, err := c.sync(ctx, events, from, from.AddDate(0, 0, cfg.Sync.days()))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Synced %d days of prayer times to %s: %s\n", cfg.Sync.days(), u.Redacted(), stats)
+	return nil
+}
+
+// sync makes events the events adhan added to the calendar between from and
+// to: it adds those missing, updates those that changed and removes the
+// others.
+func (c caldavCalendar) sync(ctx context.Context, events []calendarEvent, from, to time.Time) (syncStats, error) {
+	var stats syncStats
+	existing, err := c.events(ctx, from, to)
+	if err != nil {
+		return stats, fmt.Errorf("failed to list the events: %w", err)
+	}
+	synced := make(map[string]caldavEvent, len(existing))
+	for _, e := range existing {
+		if strings.HasPrefix(e.UID, caldavEventPrefix) {
+			synced[e.UID] = e
+		}
+	}
+
+	for _, e := range events {
+		want := ical.Event{UID: caldavEventPrefix + e.ID, Summary: e.Summary, Start: e.Start, End: e.End}
+		have, ok := synced[want.UID]
+		delete(synced, want.UID)
+		switch {
+		case !ok:
+			err = c.put(ctx, want.UID+".ics", "", want)
+			stats.added++
+		case have.Summary != want.Summary || !have.Start.Equal(want.Start) || !have.End.Equal(want.End):
+			err = c.put(ctx, have.href, have.etag, want)
+			stats.updated++
+		}
+		if err != nil {
+			return stats, fmt.Errorf("failed to sync %s on %s: %w", e.Summary, e.Start.Format(time.DateOnly), err)
+		}
+	}
+	for _, e := range synced {
+		var header http.Header
+		if e.etag != "" {
+			header = http.Header{"If-Match": {e.etag}}
+		}
+		if _, err := c.do(ctx, http.MethodDelete, e.href, header, nil); err != nil {
+			return stats, fmt.Errorf("failed to remove an event: %w", err)
+		}
+		stats.removed++
+	}
+	return stats, nil
+}
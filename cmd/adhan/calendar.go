@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// calendarCache is the stored form of a month of timings.
+type calendarCache struct {
+	Key  string      `json:"key"`
+	Days []adhan.Day `json:"days"`
+}
+
+// timingsCache keeps the months of timings loaded in memory, shared by the
+// scheduler and the commands run alongside it, so that a month is read or
+// fetched only once even when they need it at the same time.
+type timingsCache struct {
+	mu     sync.Mutex
+	months map[string]*cachedMonth
+}
+
+// cachedMonth is a month of timings, loaded by whoever holds load.
+type cachedMonth struct {
+	load chan struct{}
+	days []adhan.Day
+}
+
+// timings is the cache used by loadCalendar.
+var timings = &timingsCache{months: make(map[string]*cachedMonth)}
+
+// calendar returns the month from memory, or else loads it with load, once
+// per key: concurrent callers wait for the first one.
+func (c *timingsCache) calendar(ctx context.Context, key string, load func() ([]adhan.Day, error)) ([]adhan.Day, error) {
+	c.mu.Lock()
+	m, ok := c.months[key]
+	if !ok {
+		m = &cachedMonth{load: make(chan struct{}, 1)}
+		c.months[key] = m
+	}
+	c.mu.Unlock()
+
+	select {
+	case m.load <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-m.load }()
+
+	if m.days != nil {
+		return m.days, nil
+	}
+	days, err := load()
+	if err != nil {
+		return nil, err
+	}
+	m.days = days
+	return days, nil
+}
+
+// monthKey is how months are keyed in the store, e.g. "2024-03".
+func monthKey(year int, month time.Month) []byte {
+	return []byte(fmt.Sprintf("%04d-%02d", year, month))
+}
+
+// loadCalendar returns the timings of every day of the month, one entry per
+// day. They come from the store when they were fetched with the same
+// settings, and are fetched and stored otherwise, so the API is only hit
+// once a month.
+func loadCalendar(ctx context.Context, cfg Config, year int, month time.Month) ([]adhan.Day, error) {
+	key := fmt.Sprintf("%s %04d-%02d", cfg.cacheKey(), year, month)
+	return timings.calendar(ctx, key, func() ([]adhan.Day, error) {
+		return readCalendar(ctx, cfg, year, month)
+	})
+}
+
+// readCalendar returns the month from the store, or fetches and stores it.
+func readCalendar(ctx context.Context, cfg Config, year int, month time.Month) ([]adhan.Day, error) {
+	var cache calendarCache
+	err := viewStore(func(tx *bolt.Tx) error {
+		_, err := getJSON(tx, calendarsBucket, monthKey(year, month), &cache)
+		return err
+	})
+	if err == nil && cache.Key == cfg.cacheKey() && len(cache.Days) > 0 {
+		return cache.Days, nil
+	}
+
+	days, err := fetchCalendar(ctx, cfg, year, month)
+	if err != nil {
+		return nil, err
+	}
+	// Fresh timings are better than stale ones, even if they can't be
+	// stored this time.
+	if err := storeCalendar(cfg, year, month, days); err != nil {
+		slog.Warn("Failed to store calendar", "month", string(monthKey(year, month)), "err", err)
+	}
+	return days, nil
+}
+
+// storeCalendar stores the month of timings fetched with the settings of
+// cfg.
+func storeCalendar(cfg Config, year int, month time.Month, days []adhan.Day) error {
+	return updateStore(func(tx *bolt.Tx) error {
+		return putJSON(tx, calendarsBucket, monthKey(year, month), calendarCache{Key: cfg.cacheKey(), Days: days})
+	})
+}
+
+// calendarStored reports whether the month is stored with the settings of
+// cfg.
+func calendarStored(cfg Config, year int, month time.Month) bool {
+	var cache calendarCache
+	err := viewStore(func(tx *bolt.Tx) error {
+		_, err := getJSON(tx, calendarsBucket, monthKey(year, month), &cache)
+		return err
+	})
+	return err == nil && cache.Key == cfg.cacheKey() && len(cache.Days) > 0
+}
+
+// loadStaleDay returns the stored timings closest to day when the calendar
+// can't be fetched: those of the same month even if they were fetched with
+// other settings, or else those of the same day in the most recent month
+// stored. The date is dropped in the latter case since it doesn't match.
+func loadStaleDay(day time.Time) (adhan.Day, bool) {
+	var (
+		stale adhan.Day
+		ok    bool
+	)
+	viewStore(func(tx *bolt.Tx) error {
+		var cache calendarCache
+		found, err := getJSON(tx, calendarsBucket, monthKey(day.Year(), day.Month()), &cache)
+		if err == nil && found && day.Day() <= len(cache.Days) {
+			stale, ok = cache.Days[day.Day()-1], true
+			return nil
+		}
+
+		c := tx.Bucket(calendarsBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var cache calendarCache
+			if json.Unmarshal(v, &cache) != nil || len(cache.Days) == 0 {
+				continue
+			}
+			i := min(day.Day(), len(cache.Days))
+			stale, ok = adhan.Day{Timings: cache.Days[i-1].Timings}, true
+			return nil
+		}
+		return nil
+	})
+	return stale, ok
+}
+
+// storedCalendar returns the month from the store, whatever the settings it
+// was fetched with.
+func storedCalendar(year int, month time.Month) ([]adhan.Day, error) {
+	var cache calendarCache
+	err := viewStore(func(tx *bolt.Tx) error {
+		found, err := getJSON(tx, calendarsBucket, monthKey(year, month), &cache)
+		if err == nil && !found {
+			err = fmt.Errorf("%s isn't stored", monthKey(year, month))
+		}
+		return err
+	})
+	return cache.Days, err
+}
+
+// fetchUncached returns the timings of day under settings other than the
+// configured ones, which are fetched every time since the cache only holds
+// the configured settings. They are calculated locally when the API can't be
+// reached and the coordinates are known.
+func fetchUncached(ctx context.Context, cfg Config, day time.Time) (adhan.Day, error) {
+	days, err := fetchCalendar(ctx, cfg, day.Year(), day.Month())
+	if err == nil && day.Day() > len(days) {
+		err = fmt.Errorf("calendar has no timings for day %d", day.Day())
+	}
+	if err == nil {
+		return days[day.Day()-1], nil
+	}
+	if ctx.Err() != nil {
+		return adhan.Day{}, err
+	}
+	located, ok := cfg.geocoded()
+	if !ok {
+		return adhan.Day{}, err
+	}
+	return calculateDay(ctx, located, day)
+}
+
+func fetchCalendar(ctx context.Context, cfg Config, year int, month time.Month) ([]adhan.Day, error) {
+	query, err := cfg.query()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.provider().Calendar(ctx, query, year, month)
+}
+
+// prefetchCalendars fetches and stores the months of year not stored yet with
+// the configured settings, so that adhan works offline all year.
+func prefetchCalendars(ctx context.Context, cfg Config, year int) error {
+	fetched := 0
+	for month := time.January; month <= time.December; month++ {
+		if calendarStored(cfg, year, month) {
+			continue
+		}
+		days, err := fetchCalendar(ctx, cfg, year, month)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s: %w", monthKey(year, month), err)
+		}
+		if err := storeCalendar(cfg, year, month, days); err != nil {
+			return err
+		}
+		fmt.Printf("Fetched %s\n", monthKey(year, month))
+		fetched++
+	}
+	fmt.Printf("All of %d is stored, %d months fetched\n", year, fetched)
+	return nil
+}
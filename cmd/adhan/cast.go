@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+const (
+	// castTimeout bounds how long casting waits for the device.
+	castTimeout = 30 * time.Second
+	// castServeTime is how long a recording cast is taken to play for at
+	// most: adhan cast serves it that long when not stopped before, and
+	// silencing the adhan stops the device until then.
+	castServeTime = 10 * time.Minute
+)
+
+var castClient = &http.Client{Timeout: castTimeout}
+
+// CastConfig configures casting the adhan to a device on the network, so
+// that it is heard through the house.
+type CastConfig struct {
+	// Device is "chromecast", "sonos" or "dlna". Casting is disabled when it
+	// is empty.
+	Device string `toml:"device"`
+	// Address is the host name or IP address of the Chromecast or Sonos
+	// speaker, or the URL of the description of the DLNA renderer, e.g.
+	// http://192.168.1.20:49152/description.xml.
+	Address string `toml:"address"`
+	// File is the recording cast, a path or an http(s) URL, the adhan file
+	// by default.
+	File string `toml:"file"`
+	// Port is the port the recording is served on to the device, any free
+	// one by default.
+	Port int `toml:"port"`
+	// Prayers limits casting to some prayers, all but Sunrise by default.
+	Prayers []string `toml:"prayers"`
+}
+
+// validate checks the device.
+func (c CastConfig) validate() error {
+	switch c.Device {
+	case "":
+		return nil
+	case "chromecast", "sonos", "dlna":
+		if c.Address == "" {
+			return fmt.Errorf("cast.address is required to cast to %s", c.Device)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid cast.device %q, expected chromecast, sonos or dlna", c.Device)
+	}
+}
+
+// casts reports whether the adhan is cast for prayer.
+func (c CastConfig) casts(prayer string) bool {
+	if c.Device == "" {
+		return false
+	}
+	if len(c.Prayers) > 0 {
+		return containsPrayer(c.Prayers, prayer)
+	}
+	return prayer != "Sunrise"
+}
+
+// castFile returns the recording to cast for prayer: that of the prayer, or
+// the one configured for casting, or the adhan file.
+func (c Config) castFile(prayer string) string {
+	if p := c.prayerConfig(prayer); p.File != "" {
+		return p.File
+	}
+	if c.Cast.File != "" {
+		return c.Cast.File
+	}
+	return c.Audio.File
+}
+
+// castAdhan casts the adhan for prayer in the background, if configured.
+func (s *scheduler) castAdhan(prayer adhan.Prayer) {
+	if !s.cfg.Cast.casts(prayer.Name) {
+		return
+	}
+	file := s.cfg.castFile(prayer.Name)
+	if file == "" {
+		slog.Error("Failed to cast adhan", "err", errors.New("set cast.file or audio.file to the recording to cast"))
+		return
+	}
+	go func() {
+		slog.Info("Casting adhan", "device", s.cfg.Cast.Device, "address", s.cfg.Cast.Address, "prayer", prayer.Name)
+		if err := castMedia(context.Background(), s.cfg.Cast, file, s.cfg.localName(prayer)); err != nil {
+			slog.Error("Failed to cast adhan", "device", s.cfg.Cast.Device, "err", err)
+			return
+		}
+		s.mu.Lock()
+		s.castUntil = time.Now().Add(castServeTime)
+		s.mu.Unlock()
+	}()
+}
+
+// stopCast stops the adhan cast, if it may still be playing, and reports
+// whether it was.
+func (s *scheduler) stopCast() bool {
+	s.mu.Lock()
+	casting := time.Now().Before(s.castUntil)
+	s.castUntil = time.Time{}
+	s.mu.Unlock()
+	if !casting {
+		return false
+	}
+
+	if err := stopCastMedia(context.Background(), s.cfg.Cast); err != nil {
+		slog.Error("Failed to stop the cast", "device", s.cfg.Cast.Device, "err", err)
+	}
+	return true
+}
+
+// castMedia has the device configured in cfg play file, titled title.
+func castMedia(ctx context.Context, cfg CastConfig, file, title string) error {
+	ctx, cancel := context.WithTimeout(ctx, castTimeout)
+	defer cancel()
+
+	switch cfg.Device {
+	case "chromecast":
+		host := withPort(cfg.Address, "8009")
+		media, err := castURL(host, cfg.Port, file)
+		if err != nil {
+			return err
+		}
+		return castChromecast(ctx, host, media, title)
+	case "sonos":
+		host := withPort(cfg.Address, "1400")
+		media, err := castURL(host, cfg.Port, file)
+		if err != nil {
+			return err
+		}
+		return castUPnP(ctx, "http://"+host+"/MediaRenderer/AVTransport/Control", media, title)
+	case "dlna":
+		u, err := url.Parse(cfg.Address)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid cast.address %q, expected the URL of the description of the renderer", cfg.Address)
+		}
+		control, err := upnpControlURL(ctx, cfg.Address)
+		if err != nil {
+			return err
+		}
+		media, err := castURL(withPort(u.Host, "80"), cfg.Port, file)
+		if err != nil {
+			return err
+		}
+		return castUPnP(ctx, control, media, title)
+	default:
+		return fmt.Errorf("invalid cast.device %q, expected chromecast, sonos or dlna", cfg.Device)
+	}
+}
+
+// stopCastMedia has the device configured in cfg stop playing.
+func stopCastMedia(ctx context.Context, cfg CastConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, castTimeout)
+	defer cancel()
+
+	switch cfg.Device {
+	case "chromecast":
+		return stopChromecast(ctx, withPort(cfg.Address, "8009"))
+	case "sonos":
+		return upnpAction(ctx, "http://"+withPort(cfg.Address, "1400")+"/MediaRenderer/AVTransport/Control", "Stop", "<InstanceID>0</InstanceID>")
+	case "dlna":
+		control, err := upnpControlURL(ctx, cfg.Address)
+		if err != nil {
+			return err
+		}
+		return upnpAction(ctx, control, "Stop", "<InstanceID>0</InstanceID>")
+	default:
+		return fmt.Errorf("invalid cast.device %q, expected chromecast, sonos or dlna", cfg.Device)
+	}
+}
+
+// withPort adds port to host unless it has one already.
+func withPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// mediaType returns the MIME type of the recording at path.
+func mediaType(path string) string {
+	if t := mime.TypeByExtension(strings.ToLower(filepath.Ext(path))); t != "" {
+		return t
+	}
+	return "audio/mpeg"
+}
+
+// castServer serves the recordings cast to devices, which fetch them over
+// HTTP. It is started on the first cast and serves only the files cast,
+// until closeCastServer.
+var castServer struct {
+	mu       sync.Mutex
+	server   *http.Server
+	listener net.Listener
+	files    map[string]string
+}
+
+// castURL returns the URL the device at host fetches file from. URLs are
+// cast as they are, local files are served on port.
+func castURL(host string, port int, file string) (string, error) {
+	if strings.HasPrefix(file, "http://") || strings.HasPrefix(file, "https://") {
+		return file, nil
+	}
+
+	// The address of this machine the device can reach is the one that
+	// routes to it.
+	conn, err := net.Dial("udp", host)
+	if err != nil {
+		return "", fmt.Errorf("failed to find a route to %s: %w", host, err)
+	}
+	local := conn.LocalAddr().(*net.UDPAddr).IP
+	conn.Close()
+
+	castServer.mu.Lock()
+	defer castServer.mu.Unlock()
+
+	if castServer.listener == nil {
+		l, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+		if err != nil {
+			return "", fmt.Errorf("failed to serve the recording: %w", err)
+		}
+		castServer.server = &http.Server{Handler: http.HandlerFunc(serveCastFile)}
+		castServer.listener = l
+		castServer.files = make(map[string]string)
+		go castServer.server.Serve(l)
+	}
+
+	sum := sha256.Sum256([]byte(file))
+	name := hex.EncodeToString(sum[:8]) + strings.ToLower(filepath.Ext(file))
+	castServer.files[name] = file
+	addr := net.JoinHostPort(local.String(), strconv.Itoa(castServer.listener.Addr().(*net.TCPAddr).Port))
+	return "http://" + addr + "/" + name, nil
+}
+
+// closeCastServer stops serving the recordings cast, if it was.
+func closeCastServer() {
+	castServer.mu.Lock()
+	defer castServer.mu.Unlock()
+
+	if castServer.server == nil {
+		return
+	}
+	castServer.server.Close()
+	castServer.server = nil
+	castServer.listener = nil
+	castServer.files = nil
+}
+
+// serveCastFile serves a recording cast, with ranges as some devices ask
+// for.
+func serveCastFile(w http.ResponseWriter, r *http.Request) {
+	castServer.mu.Lock()
+	file, ok := castServer.files[strings.TrimPrefix(r.URL.Path, "/")]
+	castServer.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	slog.Debug("Serving cast recording", "file", file, "client", r.RemoteAddr)
+	w.Header().Set("Content-Type", mediaType(file))
+	http.ServeFile(w, r, file)
+}
+
+// castNow casts file, or the adhan file, to the configured device, then
+// serves the recording until it has played or Ctrl-C is pressed.
+func castNow(ctx context.Context, cfg Config, args []string) error {
+	if cfg.Cast.Device == "" {
+		return errors.New("set cast.device to chromecast, sonos or dlna, and cast.address to where it is")
+	}
+	file := cfg.castFile("")
+	if len(args) > 0 {
+		file = args[0]
+	}
+	if file == "" {
+		return errors.New("set cast.file or audio.file to the recording to cast, or give it")
+	}
+	defer closeCastServer()
+	if err := castMedia(ctx, cfg.Cast, file, "Adhan"); err != nil {
+		return fmt.Errorf("failed to cast to %s: %w", cfg.Cast.Address, err)
+	}
+
+	castServer.mu.Lock()
+	serving := castServer.listener != nil
+	castServer.mu.Unlock()
+	if !serving {
+		fmt.Printf("Casting %s to %s\n", file, cfg.Cast.Address)
+		return nil
+	}
+	fmt.Printf("Casting %s to %s, press Ctrl-C once it is over\n", file, cfg.Cast.Address)
+	select {
+	case <-ctx.Done():
+	case <-time.After(castServeTime):
+	}
+	return nil
+}
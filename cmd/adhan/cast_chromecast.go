@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// Namespaces of the Cast protocol.
+const (
+	castConnection = "urn:x-cast:com.google.cast.tp.connection"
+	castHeartbeat  = "urn:x-cast:com.google.cast.tp.heartbeat"
+	castReceiver   = "urn:x-cast:com.google.cast.receiver"
+	castMediaNS    = "urn:x-cast:com.google.cast.media"
+)
+
+// castMediaApp is the Default Media Receiver, which plays the media it is
+// given a URL of.
+const castMediaApp = "CC1AD845"
+
+// castMessage is a message of the Cast protocol, with a JSON payload.
+type castMessage struct {
+	source      string
+	destination string
+	namespace   string
+	payload     []byte
+}
+
+// castConn is a connection to a Chromecast.
+type castConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// castChromecast has the Chromecast at host play media, titled title. The
+// Default Media Receiver goes on playing once disconnected.
+func castChromecast(ctx context.Context, host, media, title string) error {
+	c, err := dialChromecast(ctx, host)
+	if err != nil {
+		return err
+	}
+	defer c.conn.Close()
+
+	if err := c.send("receiver-0", castReceiver, map[string]any{"type": "LAUNCH", "appId": castMediaApp, "requestId": 1}); err != nil {
+		return err
+	}
+	var status struct {
+		Type   string `json:"type"`
+		Status struct {
+			Applications []struct {
+				AppID       string `json:"appId"`
+				TransportID string `json:"transportId"`
+			} `json:"applications"`
+		} `json:"status"`
+		Reason string `json:"reason"`
+	}
+	var transport string
+	for transport == "" {
+		if err := c.receive(castReceiver, &status); err != nil {
+			return err
+		}
+		if status.Type == "LAUNCH_ERROR" {
+			return fmt.Errorf("failed to start the media receiver: %s", status.Reason)
+		}
+		for _, app := range status.Status.Applications {
+			if app.AppID == castMediaApp {
+				transport = app.TransportID
+			}
+		}
+	}
+
+	if err := c.send(transport, castConnection, map[string]any{"type": "CONNECT"}); err != nil {
+		return err
+	}
+	load := map[string]any{
+		"type":      "LOAD",
+		"requestId": 2,
+		"autoplay":  true,
+		"media": map[string]any{
+			"contentId":   media,
+			"contentType": mediaType(media),
+			"streamType":  "BUFFERED",
+			"metadata":    map[string]any{"metadataType": 0, "title": title},
+		},
+	}
+	if err := c.send(transport, castMediaNS, load); err != nil {
+		return err
+	}
+	for {
+		var reply struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		}
+		if err := c.receive(castMediaNS, &reply); err != nil {
+			return err
+		}
+		switch reply.Type {
+		case "MEDIA_STATUS":
+			return nil
+		case "LOAD_FAILED", "LOAD_CANCELLED", "INVALID_REQUEST":
+			return fmt.Errorf("the Chromecast failed to load the recording: %s %s", reply.Type, reply.Reason)
+		}
+	}
+}
+
+// stopChromecast has the Chromecast at host stop the Default Media Receiver,
+// if it is running.
+func stopChromecast(ctx context.Context, host string) error {
+	c, err := dialChromecast(ctx, host)
+	if err != nil {
+		return err
+	}
+	defer c.conn.Close()
+
+	if err := c.send("receiver-0", castReceiver, map[string]any{"type": "GET_STATUS", "requestId": 1}); err != nil {
+		return err
+	}
+	var status struct {
+		Status struct {
+			Applications []struct {
+				AppID     string `json:"appId"`
+				SessionID string `json:"sessionId"`
+			} `json:"applications"`
+		} `json:"status"`
+	}
+	if err := c.receive(castReceiver, &status); err != nil {
+		return err
+	}
+	for _, app := range status.Status.Applications {
+		if app.AppID != castMediaApp {
+			continue
+		}
+		if err := c.send("receiver-0", castReceiver, map[string]any{"type": "STOP", "sessionId": app.SessionID, "requestId": 2}); err != nil {
+			return err
+		}
+		// The status that follows tells the receiver has stopped.
+		return c.receive(castReceiver, &status)
+	}
+	return nil
+}
+
+// dialChromecast connects to the receiver of the Chromecast at host, until
+// ctx is done.
+func dialChromecast(ctx context.Context, host string) (*castConn, error) {
+	// Chromecasts present certificates of their own, which no authority
+	// signs.
+	dialer := &tls.Dialer{Config: &tls.Config{InsecureSkipVerify: true}}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c := &castConn{conn: conn, r: bufio.NewReader(conn)}
+	if err := c.send("receiver-0", castConnection, map[string]any{"type": "CONNECT"}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// send sends payload, encoded as JSON, to destination in namespace.
+func (c *castConn) send(destination, namespace string, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	msg := castMessage{source: "sender-0", destination: destination, namespace: namespace, payload: raw}.encode()
+	frame := binary.BigEndian.AppendUint32(nil, uint32(len(msg)))
+	_, err = c.conn.Write(append(frame, msg...))
+	return err
+}
+
+// receive decodes the payload of the next message in namespace into v,
+// answering the pings that come before it.
+func (c *castConn) receive(namespace string, v any) error {
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(c.r, size[:]); err != nil {
+			return err
+		}
+		n := binary.BigEndian.Uint32(size[:])
+		if n > 1<<20 {
+			return errors.New("message from the Chromecast too large")
+		}
+		raw := make([]byte, n)
+		if _, err := io.ReadFull(c.r, raw); err != nil {
+			return err
+		}
+		msg, err := decodeCastMessage(raw)
+		if err != nil {
+			return err
+		}
+
+		switch msg.namespace {
+		case castHeartbeat:
+			if err := c.send(msg.source, castHeartbeat, map[string]any{"type": "PONG"}); err != nil {
+				return err
+			}
+		case namespace:
+			return json.Unmarshal(msg.payload, v)
+		}
+	}
+}
+
+// encode encodes m as a CastMessage protocol buffer: the protocol version
+// and payload type, both 0, then the strings.
+func (m castMessage) encode() []byte {
+	b := []byte{1<<3 | 0, 0}
+	for i, s := range [][]byte{[]byte(m.source), []byte(m.destination), []byte(m.namespace)} {
+		b = append(b, byte(i+2)<<3|2)
+		b = binary.AppendUvarint(b, uint64(len(s)))
+		b = append(b, s...)
+	}
+	b = append(b, 5<<3|0, 0, 6<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(m.payload)))
+	return append(b, m.payload...)
+}
+
+// decodeCastMessage decodes a CastMessage protocol buffer, skipping the
+// fields adhan has no use for.
+func decodeCastMessage(raw []byte) (castMessage, error) {
+	var m castMessage
+	for len(raw) > 0 {
+		key, n := binary.Uvarint(raw)
+		if n <= 0 {
+			return m, errors.New("invalid message from the Chromecast")
+		}
+		raw = raw[n:]
+		switch key & 7 {
+		case 0:
+			if _, n = binary.Uvarint(raw); n <= 0 {
+				return m, errors.New("invalid message from the Chromecast")
+			}
+			raw = raw[n:]
+		case 2:
+			size, n := binary.Uvarint(raw)
+			if n <= 0 || uint64(len(raw)-n) < size {
+				return m, errors.New("invalid message from the Chromecast")
+			}
+			value := raw[n : n+int(size)]
+			raw = raw[n+int(size):]
+			switch key >> 3 {
+			case 2:
+				m.source = string(value)
+			case 3:
+				m.destination = string(value)
+			case 4:
+				m.namespace = string(value)
+			case 6:
+				m.payload = value
+			}
+		default:
+			return m, errors.New("invalid message from the Chromecast")
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// avTransport is the UPnP service playing media, on Sonos speakers and DLNA
+// renderers alike.
+const avTransport = "urn:schemas-upnp-org:service:AVTransport:1"
+
+// upnpDescription is the description of a UPnP device, with the services of
+// its embedded devices.
+type upnpDescription struct {
+	URLBase string     `xml:"URLBase"`
+	Device  upnpDevice `xml:"device"`
+}
+
+type upnpDevice struct {
+	Services []struct {
+		Type       string `xml:"serviceType"`
+		ControlURL string `xml:"controlURL"`
+	} `xml:"serviceList>service"`
+	Devices []upnpDevice `xml:"deviceList>device"`
+}
+
+// controlURL returns the control URL of the AVTransport service of d or its
+// embedded devices, empty if there is none.
+func (d upnpDevice) controlURL() string {
+	for _, s := range d.Services {
+		if strings.HasPrefix(s.Type, "urn:schemas-upnp-org:service:AVTransport:") {
+			return s.ControlURL
+		}
+	}
+	for _, sub := range d.Devices {
+		if u := sub.controlURL(); u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+// upnpControlURL reads the description of the renderer at location and
+// returns the URL to control its AVTransport service at.
+func upnpControlURL(ctx context.Context, location string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "adhan")
+	resp, err := castClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to read the description of the renderer: %s", resp.Status)
+	}
+
+	var desc upnpDescription
+	if err := xml.NewDecoder(resp.Body).Decode(&desc); err != nil {
+		return "", fmt.Errorf("failed to read the description of the renderer: %w", err)
+	}
+	control := desc.Device.controlURL()
+	if control == "" {
+		return "", errors.New("the renderer has no AVTransport service to play media with")
+	}
+
+	base, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	if desc.URLBase != "" {
+		if base, err = url.Parse(desc.URLBase); err != nil {
+			return "", err
+		}
+	}
+	ref, err := url.Parse(control)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// castUPnP has the AVTransport service at control play media, titled title.
+func castUPnP(ctx context.Context, control, media, title string) error {
+	// Renderers show the title from the DIDL-Lite metadata, and some won't
+	// play without the protocol it gives.
+	metadata := fmt.Sprintf(`<DIDL-Lite xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/">`+
+		`<item id="adhan" parentID="0" restricted="1"><dc:title>%s</dc:title><upnp:class>object.item.audioItem.musicTrack</upnp:class>`+
+		`<res protocolInfo="http-get:*:%s:*">%s</res></item></DIDL-Lite>`,
+		html.EscapeString(title), mediaType(media), html.EscapeString(media))
+
+	err := upnpAction(ctx, control, "SetAVTransportURI", fmt.Sprintf(
+		"<InstanceID>0</InstanceID><CurrentURI>%s</CurrentURI><CurrentURIMetaData>%s</CurrentURIMetaData>",
+		html.EscapeString(media), html.EscapeString(metadata)))
+	if err != nil {
+		return err
+	}
+	return upnpAction(ctx, control, "Play", "<InstanceID>0</InstanceID><Speed>1</Speed>")
+}
+
+// upnpAction calls action of the AVTransport service at control with args,
+// its arguments as XML.
+func upnpAction(ctx context.Context, control, action, args string) error {
+	body := `<?xml version="1.0" encoding="utf-8"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">` +
+		`<s:Body><u:` + action + ` xmlns:u="` + avTransport + `">` + args + `</u:` + action + `></s:Body></s:Envelope>`
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, control, bytes.NewReader([]byte(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", `"`+avTransport+"#"+action+`"`)
+	req.Header.Set("User-Agent", "adhan")
+
+	resp, err := castClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s: %s", action, resp.Status, upnpFault(raw))
+	}
+	return nil
+}
+
+// upnpFault returns the error description of a SOAP fault, or its code.
+func upnpFault(raw []byte) string {
+	var fault struct {
+		Code        string `xml:"Body>Fault>detail>UPnPError>errorCode"`
+		Description string `xml:"Body>Fault>detail>UPnPError>errorDescription"`
+	}
+	if err := xml.Unmarshal(raw, &fault); err != nil || fault.Code == "" {
+		return "unexpected response"
+	}
+	if fault.Description != "" {
+		return fault.Description
+	}
+	return "UPnP error " + fault.Code
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// ChangesConfig configures the notification of notable shifts in the
+// timetable from one day to the next, as when daylight saving time begins or
+// ends, to help adjust alarms.
+type ChangesConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Threshold is how many minutes a prayer time has to move by to be
+	// notified, 10 by default.
+	Threshold int `toml:"threshold"`
+}
+
+func (c ChangesConfig) threshold() time.Duration {
+	if c.Threshold <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(c.Threshold) * time.Minute
+}
+
+// timetableChanges describes the prayer times of date that moved by at least
+// the threshold on the clock since the day before, e.g. "Fajr is 12 minutes
+// earlier than the day before".
+func timetableChanges(ctx context.Context, cfg Config, date time.Time) ([]string, error) {
+	day, err := getDay(ctx, cfg, date)
+	if err != nil {
+		return nil, err
+	}
+	prayers, err := day.Prayers(date)
+	if err != nil {
+		return nil, err
+	}
+	eve := date.AddDate(0, 0, -1)
+	before, err := getDay(ctx, cfg, eve)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := before.Prayers(eve)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []string
+	for i, p := range prayers {
+		// What matters to alarms is the time on the clock, which jumps when
+		// daylight saving time begins or ends.
+		shift := clockTime(p.Time) - clockTime(previous[i].Time)
+		minutes := int(shift.Abs().Minutes())
+		switch {
+		case shift.Abs() < cfg.Changes.threshold():
+		case shift < 0:
+			changes = append(changes, cfg.tr("%s is %d minutes earlier than the day before", cfg.localName(p), minutes))
+		default:
+			changes = append(changes, cfg.tr("%s is %d minutes later than the day before", cfg.localName(p), minutes))
+		}
+	}
+	return changes, nil
+}
+
+// clockTime returns how far into its day t is on the clock.
+func clockTime(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
+
+// checkChanges notifies of the changes to the timetable of the day of next,
+// once per day: on the evening before, as the next prayer is the following
+// Fajr, or once the daemon starts.
+func (s *scheduler) checkChanges(ctx context.Context, next adhan.Prayer) {
+	if !s.cfg.Changes.Enabled {
+		return
+	}
+	date := next.Time.Format(time.DateOnly)
+	if date == s.checked {
+		return
+	}
+	s.checked = date
+
+	changes, err := timetableChanges(ctx, s.cfg, next.Time)
+	if err != nil {
+		slog.Error("Failed to compare prayer times with the day before", "err", err)
+		return
+	}
+	if len(changes) == 0 {
+		return
+	}
+	slog.Info("Prayer times changed", "date", date, "changes", changes)
+	showNotification(s.notifier, s.cfg.tr("Prayer times changed"), strings.Join(changes, "\n"))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// splitCity splits "City, Country" in its parts, the country being
+// defaultCountry when it is left out.
+func splitCity(s, defaultCountry string) (city, country string) {
+	i := strings.LastIndex(s, ",")
+	if i < 0 {
+		return strings.TrimSpace(s), defaultCountry
+	}
+	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+}
+
+// printCities prints the timings of date at the configured location, here,
+// next to those of the other cities configured, one column per city. Each
+// city's timings are in its own local time.
+func printCities(ctx context.Context, cfg Config, here adhan.Day, date time.Time) error {
+	name := cfg.City
+	if name == "" {
+		name = "Here"
+	}
+	header := []string{"Prayer", name}
+	days := make([]adhan.Day, len(cfg.Cities))
+
+	var wg sync.WaitGroup
+	for i, city := range cfg.Cities {
+		header = append(header, city)
+
+		other := cfg
+		other.City, other.Country = splitCity(city, cfg.Country)
+		other.Latitude, other.Longitude = 0, 0
+		other.Timezone = ""
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			d, err := fetchUncached(ctx, other, date)
+			if err != nil {
+				slog.Error("Failed to fetch prayer times", "city", other.City, "err", err)
+			}
+			days[i] = d
+		}(i)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	printDate(here.Date)
+	data := [][]string{{cfg.nameOf("Fajr")}, {cfg.nameOf("Sunrise")}, {cfg.nameOf("Dhuhr")}, {cfg.nameOf("Asr")}, {cfg.nameOf("Maghrib")}, {cfg.nameOf("Isha")}}
+	for _, day := range append([]adhan.Day{here}, days...) {
+		for i, timing := range cfg.formatTimings(day.Timings) {
+			data[i] = append(data[i], timing)
+		}
+	}
+	printTable(header, data)
+	return nil
+}
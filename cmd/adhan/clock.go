@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// clock is where adhan gets the time from, simulated with --at and --speed.
+var clock adhan.Clock = adhan.SystemClock{}
+
+// simulated reports whether the clock is simulated, in which case the
+// prayer log is left alone.
+func simulated() bool {
+	_, ok := clock.(*adhan.SimulatedClock)
+	return ok
+}
+
+// atLayouts are the layouts accepted by --at, the date defaulting to today.
+var atLayouts = []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02", "15:04"}
+
+// parseAt parses the time given with --at in loc.
+func parseAt(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range atLayouts {
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err != nil {
+			continue
+		}
+		if layout == "15:04" {
+			now := time.Now().In(loc)
+			t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected e.g. \"2024-03-10 18:29\" or \"18:29\"", s)
+}
+
+// parseDate parses a day given on the command line: a date such as
+// 2024-12-25, today, tomorrow, yesterday, or a number of days from today such
+// as +3d or -1d. The time returned is noon of that day in loc.
+func parseDate(s string, loc *time.Location) (time.Time, error) {
+	now := clock.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, loc)
+
+	switch strings.ToLower(s) {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	if rest, ok := strings.CutSuffix(s, "d"); ok && (strings.HasPrefix(rest, "+") || strings.HasPrefix(rest, "-")) {
+		days, err := strconv.Atoi(rest)
+		if err == nil {
+			return today.AddDate(0, 0, days), nil
+		}
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected e.g. 2024-12-25, tomorrow or +3d", s)
+	}
+	// Adding 12 hours to midnight would be off by one on DST changes.
+	return time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, loc), nil
+}
+
+// parseSpeed parses the speed given with --speed, e.g. "60x" or "60".
+func parseSpeed(s string) (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(s), "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("invalid speed %q, expected e.g. 60x", s)
+	}
+	return speed, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+func TestParseDate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	// The evening before clocks go forward.
+	defer func(c adhan.Clock) { clock = c }(clock)
+	clock = adhan.NewSimulatedClock(time.Date(2024, 3, 30, 23, 30, 0, 0, berlin), 1)
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"today", "2024-03-30"},
+		{"Today", "2024-03-30"},
+		{"tomorrow", "2024-03-31"},
+		{"yesterday", "2024-03-29"},
+		{"+0d", "2024-03-30"},
+		{"+3d", "2024-04-02"},
+		{"-1d", "2024-03-29"},
+		{"-31d", "2024-02-28"},
+		{"2024-03-31", "2024-03-31"},
+		{"2024-02-29", "2024-02-29"},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.in, berlin)
+		if err != nil {
+			t.Errorf("parseDate(%q) failed: %v", tt.in, err)
+			continue
+		}
+		want, _ := time.ParseInLocation("2006-01-02 15:04", tt.want+" 12:00", berlin)
+		if !got.Equal(want) {
+			t.Errorf("parseDate(%q) = %s, want %s", tt.in, got, want)
+		}
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	for _, in := range []string{"", "3d", "+d", "+3", "+3w", "2024-02-30", "25/12/2024", "next week"} {
+		if got, err := parseDate(in, time.UTC); err == nil {
+			t.Errorf("parseDate(%q) = %s, want an error", in, got)
+		}
+	}
+}
@@ -0,0 +1,670 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+
+	"iustusae/adhan/internal/control"
+)
+
+func newRootCommand() *cobra.Command {
+	var (
+		opts options
+		cfg  Config
+		loc  *time.Location
+		// stop stops Ctrl-C from cancelling the command's context.
+		stop = func() {}
+	)
+
+	// newReloader loads the config again the same way as cmd did.
+	newReloader := func(cmd *cobra.Command) (*reloader, error) {
+		path, err := opts.configPath()
+		if err != nil {
+			return nil, err
+		}
+		return &reloader{path: path, load: func() (Config, error) {
+			return opts.load(cmd.Context(), cmd.Flags())
+		}}, nil
+	}
+
+	root := &cobra.Command{
+		Use:          "adhan",
+		Short:        "Prayer times and adhan notifications",
+		Long:         "Without a command, adhan notifies at prayer times in the background and reads commands from the terminal.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if cfg, err = opts.load(cmd.Context(), cmd.Flags()); err != nil {
+				return err
+			}
+			if loc, err = cfg.location(); err != nil {
+				return fmt.Errorf("invalid timezone: %w", err)
+			}
+			if clock, err = opts.clock(loc); err != nil {
+				return err
+			}
+
+			// The interactive prompt reads Ctrl-C as input, everywhere else
+			// it stops adhan like SIGTERM does.
+			if cmd != cmd.Root() {
+				ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+				cmd.SetContext(ctx)
+				stop = cancel
+			}
+			return nil
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			stop()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			runInteractive(cmd.Context(), cfg, loc)
+		},
+	}
+	opts.register(root.PersistentFlags())
+	root.MarkFlagsMutuallyExclusive("verbose", "quiet")
+
+	var watch bool
+	remaining := &cobra.Command{
+		Use:   "remaining",
+		Short: "Show the time left until the next prayer",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printRemaining(cmd.Context(), cfg, loc, watch)
+		},
+	}
+	remaining.Flags().BoolVarP(&watch, "watch", "w", false, "refresh the countdown every second until interrupted")
+
+	var nextJSON bool
+	next := &cobra.Command{
+		Use:   "next",
+		Short: "Show the next prayer and its time",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printNext(cmd.Context(), cfg, loc, nextJSON)
+		},
+	}
+	next.Flags().BoolVar(&nextJSON, "json", false, "print the next prayer as JSON")
+
+	var nowJSON bool
+	now := &cobra.Command{
+		Use:   "now",
+		Short: "Show the prayer time it is now, when it ends and how long is left",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printNow(cmd.Context(), cfg, loc, nowJSON)
+		},
+	}
+	now.Flags().BoolVar(&nowJSON, "json", false, "print the current period as JSON")
+
+	var allJSON bool
+	var allDate string
+	all := &cobra.Command{
+		Use:   "all",
+		Short: "Show today's timings, or those of another day",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var date time.Time
+			if allDate != "" {
+				var err error
+				if date, err = parseDate(allDate, loc); err != nil {
+					return err
+				}
+			}
+			return printAll(cmd.Context(), cfg, date, allJSON)
+		},
+	}
+	all.Flags().BoolVar(&allJSON, "json", false, "print the timings as JSON")
+	all.Flags().StringVarP(&allDate, "date", "d", "", "day to show, e.g. 2024-12-25, tomorrow or +3d (default today)")
+
+	var year, month int
+	calendar := &cobra.Command{
+		Use:     "calendar",
+		Aliases: []string{"month"},
+		Short:   "Show the timings of a whole month, today highlighted",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			year, month, err := monthOrCurrent(loc, year, month)
+			if err != nil {
+				return err
+			}
+			return printCalendar(cmd.Context(), cfg, loc, year, month)
+		},
+	}
+	calendar.Flags().IntVar(&year, "year", 0, "year of the calendar (default current year)")
+	calendar.Flags().IntVar(&month, "month", 0, "month of the calendar, 1-12 (default current month)")
+
+	var methods []string
+	compare := &cobra.Command{
+		Use:   "compare",
+		Short: "Compare today's timings under several calculation methods",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printCompare(cmd.Context(), cfg, loc, methods)
+		},
+	}
+	compare.Flags().StringSliceVar(&methods, "methods", defaultCompareMethods, "methods to compare, by number or preset name")
+
+	service := &cobra.Command{
+		Use:   "service",
+		Short: "Manage the Windows service",
+	}
+	service.AddCommand(
+		&cobra.Command{
+			Use:   "install",
+			Short: "Register adhan as a Windows service started at boot",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				path, err := opts.configPath()
+				if err != nil {
+					return err
+				}
+				return installService(path)
+			},
+		},
+		&cobra.Command{
+			Use:   "uninstall",
+			Short: "Stop and remove the Windows service",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return uninstallService()
+			},
+		},
+		&cobra.Command{
+			Use:    "run",
+			Short:  "Run as a Windows service, used by the service control manager",
+			Args:   cobra.NoArgs,
+			Hidden: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				r, err := newReloader(cmd)
+				if err != nil {
+					return err
+				}
+				return runService(cmd.Context(), cfg, loc, r)
+			},
+		},
+	)
+
+	var systemd, launchd bool
+	install := &cobra.Command{
+		Use:   "install",
+		Short: "Start the daemon automatically at login",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := opts.configPath()
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case systemd:
+				return installSystemd(path)
+			case launchd:
+				return installLaunchd(path)
+			default:
+				return errNoInstallTarget
+			}
+		},
+	}
+	install.Flags().BoolVar(&systemd, "systemd", false, "install and enable a systemd user unit")
+	install.Flags().BoolVar(&launchd, "launchd", false, "install and load a launchd agent")
+	install.MarkFlagsMutuallyExclusive("systemd", "launchd")
+
+	var exportFormat, exportOutput string
+	export := &cobra.Command{
+		Use:   "export",
+		Short: "Export the timetable of a month as CSV or Markdown",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			year, month, err := monthOrCurrent(loc, year, month)
+			if err != nil {
+				return err
+			}
+
+			if exportOutput == "" || exportOutput == "-" {
+				return exportCalendar(cmd.Context(), os.Stdout, cfg, year, month, exportFormat)
+			}
+
+			f, err := os.Create(exportOutput)
+			if err != nil {
+				return err
+			}
+			if err := exportCalendar(cmd.Context(), f, cfg, year, month, exportFormat); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		},
+	}
+	export.Flags().StringVarP(&exportFormat, "format", "f", "csv", "output format, csv or md")
+	export.Flags().StringVarP(&exportOutput, "output", "o", "", "file to write to (default stdout)")
+	export.Flags().IntVar(&year, "year", 0, "year of the timetable (default current year)")
+	export.Flags().IntVar(&month, "month", 0, "month of the timetable, 1-12 (default current month)")
+
+	prefetch := &cobra.Command{
+		Use:   "prefetch",
+		Short: "Fetch and store the timings of a whole year, to work offline",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			year, _, err := monthOrCurrent(loc, year, 0)
+			if err != nil {
+				return err
+			}
+			return prefetchCalendars(cmd.Context(), cfg, year)
+		},
+	}
+	prefetch.Flags().IntVar(&year, "year", 0, "year to fetch (default current year)")
+
+	backup := &cobra.Command{
+		Use:   "backup [file]",
+		Short: "Back up the config, the prayer log and the stored calendars to a zip file",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfgPath, err := opts.configPath()
+			if err != nil {
+				return err
+			}
+			path := "adhan-backup-" + clock.Now().In(loc).Format(time.DateOnly) + ".zip"
+			if len(args) > 0 {
+				path = args[0]
+			}
+			return backupFiles(path, cfgPath)
+		},
+	}
+
+	restore := &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Restore the config, the prayer log and the stored calendars from a backup",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfgPath, err := opts.configPath()
+			if err != nil {
+				return err
+			}
+			return restoreFiles(cmd.Context(), args[0], cfgPath)
+		},
+	}
+
+	var statusFormat string
+	status := &cobra.Command{
+		Use:   "status",
+		Short: "Print the next prayer on a single line for status bars",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return printStatus(cmd.Context(), cfg, loc, statusFormat)
+		},
+	}
+	status.Flags().StringVarP(&statusFormat, "format", "f", "plain", "output format, plain, polybar, waybar, xbar or tmux")
+
+	prompt := &cobra.Command{
+		Use:   "prompt",
+		Short: "Print the next prayer as a single token for shell prompts, from the cache only",
+		Args:  cobra.NoArgs,
+		// Skip the root's setup, which may resolve the location over the
+		// network.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			path, err := opts.configPath()
+			if err != nil {
+				return
+			}
+			cfg, err := loadConfig(path)
+			if err != nil {
+				return
+			}
+			applyEnv(&cfg)
+			if opts.useProfile(cmd.Flags(), &cfg) != nil {
+				return
+			}
+			opts.apply(cmd.Flags(), &cfg)
+
+			loc, err := cfg.location()
+			if err != nil {
+				return
+			}
+			printPrompt(cfg, loc)
+		},
+	}
+
+	var play bool
+	doctor := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the config, network, notifications and audio",
+		Args:  cobra.NoArgs,
+		// Config errors are reported like the other problems found.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			cmd.SetContext(ctx)
+			stop = cancel
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(cmd.Context(), &opts, cmd.Flags(), play)
+		},
+	}
+	doctor.Flags().BoolVar(&play, "play", false, "play the adhan, to check the audio")
+
+	var host string
+	var port int
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the timings as a JSON API over HTTP",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(cmd.Context(), cfg, loc, net.JoinHostPort(host, strconv.Itoa(port)))
+		},
+	}
+	serveCmd.Flags().StringVar(&host, "host", "", "address to listen on (default all interfaces)")
+	serveCmd.Flags().IntVarP(&port, "port", "p", 8080, "port to listen on")
+
+	root.AddCommand(
+		&cobra.Command{
+			Use:       "prayed [prayer]",
+			Short:     "Mark a prayer of today as prayed, the current one by default",
+			Args:      cobra.MaximumNArgs(1),
+			ValidArgs: fardPrayers,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				var name string
+				if len(args) > 0 {
+					name = args[0]
+				}
+				return markPrayed(cmd.Context(), cfg, loc, name)
+			},
+		},
+		&cobra.Command{
+			Use:   "stats",
+			Short: "Show how many prayers were prayed and streaks of complete days",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return printStats(loc)
+			},
+		},
+		&cobra.Command{
+			Use:   "ack",
+			Short: "Stop the daemon reminding of the current prayer",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				// Let the running instance silence the adhan too.
+				if _, err := control.Send(cmd.Context(), "ack"); !errors.Is(err, control.ErrNotRunning) {
+					return err
+				}
+				return ack()
+			},
+		},
+		&cobra.Command{
+			Use:   "stop-audio",
+			Short: "Silence the adhan played by the running instance",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				out, err := control.Send(cmd.Context(), "stop")
+				if out != "" {
+					fmt.Println(out)
+				}
+				return err
+			},
+		},
+		&cobra.Command{
+			Use:   "cast [file]",
+			Short: "Cast the adhan, or file, to the configured device now",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return castNow(cmd.Context(), cfg, args)
+			},
+		},
+		&cobra.Command{
+			Use:   "lights",
+			Short: "Flash the configured lights, or change their colour, now",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return testLights(cmd.Context(), cfg.Lights)
+			},
+		},
+		newQadaCommand(),
+		newProfileCommand(&cfg),
+		newSyncCommand(&cfg),
+		newCitiesCommand(),
+		&cobra.Command{
+			Use:   "search <city>",
+			Short: "Find a city allowing for typos, and save the one picked to the config",
+			Args:  cobra.MinimumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				path, err := opts.configPath()
+				if err != nil {
+					return err
+				}
+				return searchCity(cfg, path, strings.Join(args, " "))
+			},
+		},
+		doctor,
+		serveCmd,
+		status,
+		prompt,
+		install,
+		export,
+		prefetch,
+		backup,
+		restore,
+		service,
+		next,
+		now,
+		&cobra.Command{
+			Use:   "makruh",
+			Short: "Show today's times in which voluntary prayers are disliked",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return printMakruh(cmd.Context(), cfg, loc)
+			},
+		},
+		&cobra.Command{
+			Use:     "verse",
+			Aliases: []string{"dua"},
+			Short:   "Show the verse or dua of the day",
+			Args:    cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return printVerse(cfg, loc)
+			},
+		},
+		all,
+		remaining,
+		&cobra.Command{
+			Use:   "date",
+			Short: "Show today's Gregorian and Hijri dates",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return printToday(cmd.Context(), cfg)
+			},
+		},
+		calendar,
+		&cobra.Command{
+			Use:   "week",
+			Short: "Show the timings of the seven days starting today",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return printWeek(cmd.Context(), cfg, loc)
+			},
+		},
+		&cobra.Command{
+			Use:   "tui",
+			Short: "Show a live dashboard of today's timings in the terminal",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runTUI(cmd.Context(), cfg, loc)
+			},
+		},
+		compare,
+		&cobra.Command{
+			Use:   "methods",
+			Short: "List the available calculation methods",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return printMethods(cmd.Context(), cfg)
+			},
+		},
+		&cobra.Command{
+			Use:   "daemon",
+			Short: "Notify at prayer times without reading commands from the terminal",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				r, err := newReloader(cmd)
+				if err != nil {
+					return err
+				}
+				err = runDaemon(cmd.Context(), cfg, loc, r)
+				if errors.Is(err, control.ErrAlreadyRunning) {
+					// Not a failure, for service managers not to start it
+					// again and again.
+					slog.Warn("Not starting the daemon", "reason", err)
+					return nil
+				}
+				return err
+			},
+		},
+		&cobra.Command{
+			Use:   "tray",
+			Short: "Show the next prayer in the system tray and notify at prayer times",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runTray(cmd.Context(), cfg, loc)
+			},
+		},
+		&cobra.Command{
+			Use:   "gui",
+			Short: "Open a window with the timetable and settings, and notify at prayer times",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				r, err := newReloader(cmd)
+				if err != nil {
+					return err
+				}
+				return runGUI(cmd.Context(), cfg, loc, r)
+			},
+		},
+		&cobra.Command{
+			Use:   "config",
+			Short: "Show the config file location and the effective settings",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				path, err := opts.configPath()
+				if err != nil {
+					return err
+				}
+				fmt.Printf("# %s\n", path)
+				return toml.NewEncoder(os.Stdout).Encode(cfg)
+			},
+		},
+	)
+
+	return root
+}
+
+// newProfileCommand returns the profile command, cfg being set by the time it
+// runs.
+func newProfileCommand(cfg *Config) *cobra.Command {
+	profile := &cobra.Command{
+		Use:   "profile",
+		Short: "Switch between the configured locations",
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the profiles, marking the one in use",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			printProfiles(*cfg)
+		},
+	}
+	profile.AddCommand(
+		list,
+		&cobra.Command{
+			Use:   "use <name>",
+			Short: "Use a profile from now on, the running instance rescheduling at once",
+			Args:  cobra.ExactArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				names := make([]string, 0, len(cfg.Profiles))
+				for name := range cfg.Profiles {
+					names = append(names, name)
+				}
+				return names, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return switchProfile(cmd.Context(), *cfg, args[0])
+			},
+		},
+		&cobra.Command{
+			Use:   "reset",
+			Short: "Stop using a profile, going back to the location of the config",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return switchProfile(cmd.Context(), *cfg, "")
+			},
+		},
+	)
+	profile.Run = list.Run
+	return profile
+}
+
+func newCitiesCommand() *cobra.Command {
+	cities := &cobra.Command{
+		Use:   "cities",
+		Short: "Manage the offline city database",
+	}
+	cities.AddCommand(&cobra.Command{
+		Use:   "update",
+		Short: "Download the GeoNames database of every city of over 15000 inhabitants",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateCityDB(cmd.Context())
+		},
+	})
+	return cities
+}
+
+func newQadaCommand() *cobra.Command {
+	qada := &cobra.Command{
+		Use:   "qada",
+		Short: "Track missed prayers to make up",
+	}
+
+	var all bool
+	clearMissed := &cobra.Command{
+		Use:       "clear [prayer]",
+		Short:     "Mark the oldest missed occurrence of a prayer as made up",
+		Args:      cobra.RangeArgs(0, 1),
+		ValidArgs: fardPrayers,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if all {
+				return clearQada("", true)
+			}
+			if len(args) == 0 {
+				return errors.New("name the prayer made up, or pass --all")
+			}
+			return clearQada(args[0], false)
+		},
+	}
+	clearMissed.Flags().BoolVar(&all, "all", false, "clear every missed prayer")
+
+	qada.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List the missed prayers still to make up",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return printQada()
+			},
+		},
+		clearMissed,
+	)
+	return qada
+}
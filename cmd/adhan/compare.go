@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// defaultCompareMethods are the methods compared when none are given, those
+// most mosques follow.
+var defaultCompareMethods = []string{"mwl", "isna", "egypt", "karachi", "ummalqura"}
+
+// printCompare prints today's timings under each of the methods side by side,
+// one column per method, to find the one matching the local mosque.
+func printCompare(ctx context.Context, cfg Config, loc *time.Location, names []string) error {
+	methods := make([]MethodID, len(names))
+	for i, name := range names {
+		id, err := parseMethod(name)
+		if err != nil {
+			return err
+		}
+		methods[i] = id
+	}
+
+	now := clock.Now().In(loc)
+	days := make([]adhan.Day, len(methods))
+	var wg sync.WaitGroup
+	for i, method := range methods {
+		wg.Add(1)
+		go func(i int, method MethodID) {
+			defer wg.Done()
+			days[i] = compareDay(ctx, cfg, method, now)
+		}(i, method)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	header := append([]string{"Prayer"}, names...)
+	data := [][]string{{cfg.nameOf("Fajr")}, {cfg.nameOf("Sunrise")}, {cfg.nameOf("Dhuhr")}, {cfg.nameOf("Asr")}, {cfg.nameOf("Maghrib")}, {cfg.nameOf("Isha")}}
+	for _, day := range days {
+		for i, timing := range cfg.formatTimings(day.Timings) {
+			data[i] = append(data[i], timing)
+		}
+	}
+	printTable(header, data)
+	return nil
+}
+
+// compareDay returns the timings of day under method, empty if they can be
+// neither fetched nor calculated.
+func compareDay(ctx context.Context, cfg Config, method MethodID, day time.Time) adhan.Day {
+	if method == cfg.Method {
+		d, err := fetchDay(ctx, cfg, day)
+		if err != nil {
+			slog.Error("Failed to fetch prayer times", "method", method, "err", err)
+		}
+		return d
+	}
+
+	cfg.Method = method
+	d, err := fetchUncached(ctx, cfg, day)
+	if err != nil {
+		slog.Error("Failed to fetch prayer times", "method", method, "err", err)
+	}
+	return d
+}
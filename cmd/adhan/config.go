@@ -0,0 +1,396 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"iustusae/adhan/internal/i18n"
+	"iustusae/adhan/pkg/adhan"
+)
+
+type NotificationConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Sound is a macOS sound name, a freedesktop sound theme name or sound
+	// file on Linux, or a toast sound on Windows. Each platform has its own
+	// default, see defaultSound.
+	Sound string `toml:"sound"`
+	// Icon is the image shown in notifications, the mosque by default.
+	Icon string `toml:"icon"`
+	// Desktop sends notifications to the desktop, which headless machines
+	// may not have. Push notifications are sent regardless.
+	Desktop  bool           `toml:"desktop"`
+	Ntfy     NtfyConfig     `toml:"ntfy"`
+	Pushover PushoverConfig `toml:"pushover"`
+	Discord  ChatConfig     `toml:"discord"`
+	Slack    ChatConfig     `toml:"slack"`
+	// Repeat is how many minutes to wait before notifying of a prayer again
+	// until it is acknowledged, 0 to notify only once.
+	Repeat int `toml:"repeat"`
+}
+
+type AudioConfig struct {
+	Enabled bool   `toml:"enabled"`
+	File    string `toml:"file"`
+	// PauseMedia pauses the music and videos playing during the adhan, and
+	// ResumeMedia resumes them afterwards.
+	PauseMedia  bool `toml:"pause_media"`
+	ResumeMedia bool `toml:"resume_media"`
+}
+
+// SpeechConfig configures announcing prayers with text-to-speech, before the
+// adhan when both are enabled.
+type SpeechConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Message is what is said, {prayer} is replaced with the prayer name.
+	Message string `toml:"message"`
+	// Voice is the name of the voice to use, the system default if empty.
+	Voice string `toml:"voice"`
+}
+
+// CustomMethod holds the twilight parameters used with the custom method
+// (99), for local conventions that match none of the presets. Maghrib takes
+// either an angle or a number of minutes after sunset, and Isha either an
+// angle or a number of minutes after Maghrib.
+type CustomMethod struct {
+	FajrAngle      float64 `toml:"fajr_angle"`
+	MaghribAngle   float64 `toml:"maghrib_angle"`
+	MaghribMinutes int     `toml:"maghrib_minutes"`
+	IshaAngle      float64 `toml:"isha_angle"`
+	IshaMinutes    int     `toml:"isha_minutes"`
+}
+
+// settings formats the parameters as the methodSettings query parameter of
+// the API, e.g. "18.5,null,90 min".
+func (m CustomMethod) settings() string {
+	param := func(angle float64, minutes int) string {
+		switch {
+		case minutes > 0:
+			return fmt.Sprintf("%d min", minutes)
+		case angle > 0:
+			return strconv.FormatFloat(angle, 'f', -1, 64)
+		default:
+			return "null"
+		}
+	}
+
+	return strings.Join([]string{
+		param(m.FajrAngle, 0),
+		param(m.MaghribAngle, m.MaghribMinutes),
+		param(m.IshaAngle, m.IshaMinutes),
+	}, ",")
+}
+
+type Config struct {
+	APIURL         string             `toml:"api_url"`
+	Mirrors        []string           `toml:"mirrors"`
+	MuslimSalatKey string             `toml:"muslimsalat_key"`
+	City           string             `toml:"city"`
+	Country        string             `toml:"country"`
+	Cities         []string           `toml:"cities"`
+	Method         MethodID           `toml:"method"`
+	School         string             `toml:"school"`
+	CustomMethod   CustomMethod       `toml:"custom_method"`
+	Timezone       string             `toml:"timezone"`
+	TimeFormat     string             `toml:"time_format"`
+	Latitude       float64            `toml:"latitude"`
+	Longitude      float64            `toml:"longitude"`
+	AutoLocate     bool               `toml:"auto_locate"`
+	Notifications  NotificationConfig `toml:"notifications"`
+	Audio          AudioConfig        `toml:"audio"`
+	Speech         SpeechConfig       `toml:"speech"`
+	Ramadan        RamadanConfig      `toml:"ramadan"`
+	Jumuah         JumuahConfig       `toml:"jumuah"`
+	Events         EventsConfig       `toml:"events"`
+	Quiet          QuietConfig        `toml:"quiet"`
+	Webhooks       []WebhookConfig    `toml:"webhooks"`
+	MQTT           MQTTConfig         `toml:"mqtt"`
+	GPIO           GPIOConfig         `toml:"gpio"`
+	Cast           CastConfig         `toml:"cast"`
+	Lights         LightsConfig       `toml:"lights"`
+	Travel         TravelConfig       `toml:"travel"`
+	Makruh         MakruhConfig       `toml:"makruh"`
+	Tahajjud       TahajjudConfig     `toml:"tahajjud"`
+	Duha           DuhaConfig         `toml:"duha"`
+	Fasting        FastingConfig      `toml:"fasting"`
+	Adhkar         AdhkarConfig       `toml:"adhkar"`
+	Verse          VerseConfig        `toml:"verse"`
+	Changes        ChangesConfig      `toml:"changes"`
+	Alarm          AlarmConfig        `toml:"alarm"`
+	Busy           BusyConfig         `toml:"busy"`
+	Break          BreakConfig        `toml:"prayer_break"`
+	Sync           SyncConfig         `toml:"sync"`
+	// Headless turns off what needs a desktop, see headless.
+	Headless bool `toml:"headless"`
+	// Hooks are shell commands run at prayer times, keyed on_<prayer>,
+	// on_prayer or on_reminder.
+	Hooks map[string]string `toml:"hooks"`
+	// Prayers holds per-prayer settings, keyed by prayer name.
+	Prayers map[string]PrayerConfig `toml:"prayers"`
+	Log     LogConfig               `toml:"log"`
+	// Profiles are named locations switched to with adhan profile use.
+	Profiles map[string]Profile `toml:"profiles"`
+	// Profile is the name of the profile in use, see useProfile.
+	Profile string `toml:"-"`
+	// Language is the ISO 639-1 code of the language to show text in, that
+	// of the environment if empty.
+	Language string `toml:"language"`
+	// PrayerNames shows prayer names in Arabic, or transliterated, rather
+	// than in Language.
+	PrayerNames string `toml:"prayer_names"`
+	translator  *i18n.Translator
+}
+
+func defaultConfig() Config {
+	return Config{
+		APIURL:  adhan.DefaultBaseURL,
+		City:    "Boynton Beach",
+		Country: "United States",
+		Method:  methodPresets["mwl"],
+		School:  "shafi",
+		Notifications: NotificationConfig{
+			Enabled: true,
+			Sound:   defaultSound,
+			Desktop: true,
+			Repeat:  10,
+		},
+		Speech: SpeechConfig{
+			Message: "It is now time for {prayer} prayer",
+		},
+		Ramadan: RamadanConfig{
+			Enabled:       true,
+			SuhoorWarning: 30,
+		},
+		Jumuah: JumuahConfig{
+			Enabled:  true,
+			Reminder: 45,
+		},
+		MQTT: MQTTConfig{
+			Discovery: true,
+		},
+		Events: EventsConfig{
+			Enabled:    true,
+			RemindDays: 1,
+		},
+		Travel: TravelConfig{
+			Interval: 30,
+		},
+	}
+}
+
+// configPath returns the location of the config file, usually
+// ~/.config/adhan/config.toml.
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "adhan", "config.toml"), nil
+}
+
+// headless turns off what needs a desktop, for servers and containers:
+// desktop notifications, the adhan and anything else played, pausing media,
+// Focus and the prayer break. Logs, push notifications, webhooks, hooks, MQTT
+// and the HTTP API are left as configured.
+func (c *Config) headless() {
+	c.Notifications.Desktop = false
+	c.Audio.Enabled = false
+	c.Audio.PauseMedia = false
+	c.Speech.Enabled = false
+	c.Alarm.Enabled = false
+	c.Busy.Focus = false
+	c.Break.Enabled = false
+}
+
+// location returns the time zone prayer times should be compared in. It falls
+// back to the local zone when no timezone is configured.
+func (c Config) location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(c.Timezone)
+}
+
+// hasCoordinates reports whether coordinates were configured. They take
+// precedence over the city, and are needed to calculate prayer times locally.
+func (c Config) hasCoordinates() bool {
+	return c.Latitude != 0 || c.Longitude != 0
+}
+
+// cacheKey identifies the settings cached timings were fetched with, so that
+// they are refetched when the configuration changes.
+func (c Config) cacheKey() string {
+	key := fmt.Sprintf("%s|%s|%g|%g|%d|%s|%s", c.City, c.Country, c.Latitude, c.Longitude, c.Method, c.School, c.Timezone)
+	if c.Method == methodPresets["custom"] {
+		key += "|" + c.CustomMethod.settings()
+	}
+	return key
+}
+
+// asrSchool returns the aladhan id of the juristic school used for Asr: 0 for
+// Shafi'i (also Maliki and Hanbali) and 1 for Hanafi.
+func (c Config) asrSchool() (int, error) {
+	switch strings.ToLower(c.School) {
+	case "", "shafi", "standard":
+		return 0, nil
+	case "hanafi":
+		return 1, nil
+	default:
+		return 0, fmt.Errorf("unknown school %q, expected shafi or hanafi", c.School)
+	}
+}
+
+func (c Config) client() *adhan.Client {
+	return adhan.NewClient(c.APIURL)
+}
+
+// provider returns the providers to fetch timings from, in order: the API,
+// its mirrors, then muslimsalat.com when a key is configured.
+func (c Config) provider() adhan.PrayerTimesProvider {
+	providers := adhan.Fallback{c.client()}
+	for _, mirror := range c.Mirrors {
+		providers = append(providers, adhan.NewClient(mirror))
+	}
+	if c.MuslimSalatKey != "" {
+		providers = append(providers, adhan.MuslimSalat{Key: c.MuslimSalatKey})
+	}
+	return providers
+}
+
+// query returns the API query matching the configured location and method.
+func (c Config) query() (adhan.Query, error) {
+	school, err := c.asrSchool()
+	if err != nil {
+		return adhan.Query{}, err
+	}
+
+	q := adhan.Query{
+		City:      c.City,
+		Country:   c.Country,
+		Latitude:  c.Latitude,
+		Longitude: c.Longitude,
+		Method:    int(c.Method),
+		School:    school,
+		Timezone:  c.Timezone,
+	}
+	if c.Method == methodPresets["custom"] {
+		q.MethodSettings = c.CustomMethod.settings()
+	}
+
+	return q, nil
+}
+
+// configKey is a top-level key of the config file with its value, a string,
+// float64 or bool.
+type configKey struct {
+	name  string
+	value any
+}
+
+// setConfigKeys sets top-level keys of the config file at path, leaving the
+// rest of it, comments included, as it is. Keys not in the file yet are added
+// before its first table.
+func setConfigKeys(path string, keys ...configKey) error {
+	raw, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	var lines []string
+	if len(raw) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n")
+	}
+	tables := len(lines)
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "[") {
+			tables = i
+			break
+		}
+	}
+
+	var added []string
+	for _, key := range keys {
+		var value string
+		switch v := key.value.(type) {
+		case string:
+			value = strconv.Quote(v)
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			value = strconv.FormatBool(v)
+		default:
+			return fmt.Errorf("unsupported value %v for %s", v, key.name)
+		}
+		line := key.name + " = " + value
+
+		found := false
+		for i := 0; i < tables; i++ {
+			if name, _, ok := strings.Cut(lines[i], "="); ok && strings.TrimSpace(name) == key.name {
+				lines[i], found = line, true
+			}
+		}
+		if !found {
+			added = append(added, line)
+		}
+	}
+	// Added after the last key, before the blank lines leading to the tables.
+	at := tables
+	for at > 0 && strings.TrimSpace(lines[at-1]) == "" {
+		at--
+	}
+	if at == tables && tables < len(lines) && len(added) > 0 {
+		added = append(added, "")
+	}
+	lines = append(lines[:at], append(added, lines[at:]...)...)
+
+	text := strings.Join(lines, "\n") + "\n"
+	if _, err := toml.Decode(text, &Config{}); err != nil {
+		return fmt.Errorf("failed to update config file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Written in place, so that its permissions are kept and a symlink to it
+	// is followed.
+	return os.WriteFile(path, []byte(text), 0o644)
+}
+
+// loadConfig reads the config file at path on top of the defaults. A missing
+// file is not an error, the defaults are returned as is.
+func loadConfig(path string) (Config, error) {
+	cfg := defaultConfig()
+
+	if _, err := toml.DecodeFile(path, &cfg); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return defaultConfig(), nil
+		}
+		return cfg, err
+	}
+
+	// Files are relative to the config, wherever adhan is run from.
+	dir := filepath.Dir(path)
+	cfg.Notifications.Icon = resolvePath(dir, cfg.Notifications.Icon)
+	cfg.Notifications.Sound = resolveSound(dir, cfg.Notifications.Sound)
+	cfg.Audio.File = resolvePath(dir, cfg.Audio.File)
+	cfg.Alarm.File = resolvePath(dir, cfg.Alarm.File)
+	cfg.Verse.File = resolvePath(dir, cfg.Verse.File)
+	if !strings.HasPrefix(cfg.Cast.File, "http://") && !strings.HasPrefix(cfg.Cast.File, "https://") {
+		cfg.Cast.File = resolvePath(dir, cfg.Cast.File)
+	}
+	if cfg.Log.File != "default" {
+		cfg.Log.File = resolvePath(dir, cfg.Log.File)
+	}
+	for name, p := range cfg.Prayers {
+		p.File = resolvePath(dir, p.File)
+		p.Sound = resolveSound(dir, p.Sound)
+		cfg.Prayers[name] = p
+	}
+	return cfg, nil
+}
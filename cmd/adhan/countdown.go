@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// formatCountdown renders d as "1h 23m", or "1h 23m 05s" when seconds are
+// wanted.
+func formatCountdown(d time.Duration, seconds bool) string {
+	if seconds {
+		d = d.Round(time.Second)
+	} else {
+		// Round up so that "in 0m" is never shown before the prayer.
+		d = (d + time.Minute - 1).Truncate(time.Minute)
+	}
+
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	s := int(d.Seconds()) % 60
+
+	switch {
+	case seconds && h > 0:
+		return fmt.Sprintf("%dh %02dm %02ds", h, m, s)
+	case seconds:
+		return fmt.Sprintf("%dm %02ds", m, s)
+	case h > 0:
+		return fmt.Sprintf("%dh %02dm", h, m)
+	default:
+		return fmt.Sprintf("%dm", m)
+	}
+}
+
+// watchCountdown prints a countdown to the next prayer every second until it
+// is interrupted with Ctrl-C or ctx is done.
+func watchCountdown(ctx context.Context, cfg Config, today adhan.Day, loc *time.Location) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		now := clock.Now().In(loc)
+		next, err := nextPrayer(ctx, cfg, today, now)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\r\033[K%s in %s", next.Name, formatCountdown(next.Time.Sub(now), true))
+
+		select {
+		case <-ticker.C:
+		case <-interrupt:
+			fmt.Println()
+			return nil
+		case <-ctx.Done():
+			fmt.Println()
+			return nil
+		}
+	}
+}
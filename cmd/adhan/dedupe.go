@@ -0,0 +1,94 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// forgetAfter is how long an event is remembered once notified of.
+const forgetAfter = 48 * time.Hour
+
+// notified remembers the prayers and reminders notified of, so that each one
+// is notified of at most once even when a scheduler comes across it again, as
+// when the clock is moved back, the daemon starts another one on reload, or
+// is restarted.
+var notified = &eventSet{seen: make(map[string]time.Time)}
+
+// eventSet is a set of events, each forgotten forgetAfter after it happened.
+// It is kept in the store, and in memory only while simulating, or if the
+// store can't be used.
+type eventSet struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// first records the event identified by key, which happened at t, and
+// reports whether it was not recorded already.
+func (e *eventSet) first(key string, t time.Time) bool {
+	if !simulated() {
+		first, err := firstStored(key, t)
+		if err == nil {
+			return first
+		}
+		slog.Warn("Failed to remember notifications in the store, remembering them in memory", "err", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for k, at := range e.seen {
+		if t.Sub(at) > forgetAfter {
+			delete(e.seen, k)
+		}
+	}
+	if _, ok := e.seen[key]; ok {
+		return false
+	}
+	e.seen[key] = t
+	return true
+}
+
+// firstStored records the event identified by key, which happened at t, in
+// the store, and reports whether it was not recorded already.
+func firstStored(key string, t time.Time) (bool, error) {
+	first := false
+	err := updateStore(func(tx *bolt.Tx) error {
+		b := tx.Bucket(notifiedBucket)
+		var forgotten [][]byte
+		b.ForEach(func(k, v []byte) error {
+			at, err := time.Parse(time.RFC3339, string(v))
+			if err != nil || t.Sub(at) > forgetAfter {
+				forgotten = append(forgotten, k)
+			}
+			return nil
+		})
+		for _, k := range forgotten {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		if b.Get([]byte(key)) != nil {
+			return nil
+		}
+		first = true
+		return b.Put([]byte(key), []byte(t.Format(time.RFC3339)))
+	})
+	return first, err
+}
+
+// firstPrayer records that prayer is notified of, on time or as missed, and
+// reports whether it is the first time on its date.
+func firstPrayer(prayer adhan.Prayer) bool {
+	return notified.first(prayer.Name+" "+prayer.Time.Format(time.DateOnly), prayer.Time)
+}
+
+// firstReminder records that r is notified of, and reports whether it is the
+// first time.
+func firstReminder(r adhan.Reminder) bool {
+	return notified.first(r.Title+" "+r.Time.Format(time.RFC3339), r.Time)
+}
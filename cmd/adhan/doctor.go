@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"iustusae/adhan/internal/control"
+)
+
+// doctorTimeout bounds how long doctor waits for the API.
+const doctorTimeout = 15 * time.Second
+
+var errChecksFailed = errors.New("some checks failed")
+
+// doctor prints the result of each check, with a hint on how to fix what is
+// wrong.
+type doctor struct {
+	failed bool
+}
+
+func (d *doctor) ok(format string, args ...any) {
+	fmt.Printf("[ok]   %s\n", fmt.Sprintf(format, args...))
+}
+
+func (d *doctor) warn(hint, format string, args ...any) {
+	fmt.Printf("[warn] %s\n", fmt.Sprintf(format, args...))
+	if hint != "" {
+		fmt.Printf("       %s\n", hint)
+	}
+}
+
+func (d *doctor) fail(hint, format string, args ...any) {
+	d.failed = true
+	fmt.Printf("[fail] %s\n", fmt.Sprintf(format, args...))
+	if hint != "" {
+		fmt.Printf("       %s\n", hint)
+	}
+}
+
+// runDoctor checks the config, the time zone, the API, notifications, audio
+// and the daemon. It plays the adhan when play is set, and sends a test
+// notification.
+func runDoctor(ctx context.Context, opts *options, flags *pflag.FlagSet, play bool) error {
+	var d doctor
+
+	path, err := opts.configPath()
+	if err != nil {
+		d.fail("", "Failed to locate config file: %v", err)
+		return errChecksFailed
+	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		d.warn("Create it to set your location and method, see the README.", "No config file at %s, using the defaults", path)
+	}
+	cfg, err := opts.load(ctx, flags)
+	if err != nil {
+		d.fail("Fix the config file, or the flags given.", "Invalid config: %v", err)
+		return errChecksFailed
+	}
+	d.ok("Config is valid: %s", path)
+
+	loc, err := cfg.location()
+	if err != nil {
+		d.fail("Use an IANA name such as Europe/London.", "Invalid timezone %q: %v", cfg.Timezone, err)
+		return errChecksFailed
+	}
+	if cfg.Timezone == "" {
+		d.warn("Set timezone when the location is not where this machine is.", "No timezone configured, using this machine's: %s", loc)
+	} else {
+		d.ok("Time zone: %s, currently %s", loc, time.Now().In(loc).Format("15:04 MST"))
+	}
+
+	d.checkAPI(ctx, cfg, loc)
+	d.checkNotifications(cfg)
+	d.checkAudio(ctx, cfg, play)
+
+	if out, err := control.Send(ctx, "status"); err != nil {
+		d.warn("Start it with adhan daemon, or at login with adhan install.", "adhan is not running, nothing will notify at prayer times")
+	} else {
+		d.ok("%s", out)
+	}
+
+	if d.failed {
+		return errChecksFailed
+	}
+	return nil
+}
+
+// checkAPI fetches the current month, bypassing the cache, and compares the
+// time zone of the location with the configured one.
+func (d *doctor) checkAPI(ctx context.Context, cfg Config, loc *time.Location) {
+	ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+
+	now := time.Now().In(loc)
+	days, err := fetchCalendar(ctx, cfg, now.Year(), now.Month())
+	switch {
+	case err != nil && cfg.hasCoordinates():
+		d.warn("Prayer times are calculated locally meanwhile, check the network or api_url.", "Failed to reach the API: %v", err)
+		return
+	case err != nil:
+		d.fail("Check the network or api_url, or set latitude and longitude to calculate prayer times offline.", "Failed to reach the API: %v", err)
+		return
+	case len(days) == 0:
+		d.fail("Check the location and method.", "The API returned no timings")
+		return
+	}
+	d.ok("API reachable, Fajr today is at %s", days[now.Day()-1].Timings.Fajr)
+
+	if zone := days[0].Meta.Timezone; zone != "" && cfg.Timezone != "" && zone != cfg.Timezone {
+		d.warn("Set timezone to the location's, or check the city and coordinates.", "The location is in %s, but timezone is %s", zone, cfg.Timezone)
+	}
+}
+
+func (d *doctor) checkNotifications(cfg Config) {
+	if !cfg.Notifications.Enabled {
+		d.warn("Set notifications.enabled to be notified at prayer times.", "Notifications are disabled")
+		return
+	}
+
+	err := newNotifier(cfg.Notifications).Notify(Notification{
+		Title:   "adhan doctor",
+		Message: "This is a test notification.",
+		Urgency: UrgencyNormal,
+	})
+	if err != nil {
+		d.fail("Check that a notification daemon runs, or configure ntfy or Pushover.", "Failed to send a test notification: %v", err)
+		return
+	}
+	d.ok("Sent a test notification")
+}
+
+// checkAudio checks that the adhan recordings exist and that a player is
+// installed, and plays the adhan when play is set.
+func (d *doctor) checkAudio(ctx context.Context, cfg Config, play bool) {
+	files := make(map[string]bool)
+	if cfg.Audio.Enabled && cfg.Audio.File != "" {
+		files[cfg.Audio.File] = true
+	}
+	for _, p := range cfg.Prayers {
+		if p.File != "" {
+			files[p.File] = true
+		}
+	}
+	if len(files) == 0 {
+		if cfg.Audio.Enabled {
+			d.warn("Set audio.file to the recording to play.", "Audio is enabled but no file is configured")
+		} else {
+			d.ok("Audio is disabled")
+		}
+		return
+	}
+
+	var cmd *exec.Cmd
+	for file := range files {
+		if _, err := os.Stat(file); err != nil {
+			d.fail("Check the path of the recording.", "Failed to find the adhan: %v", err)
+			continue
+		}
+		c, err := playerCommand(file)
+		if err != nil {
+			d.fail("Install one of the players listed in the README.", "Failed to play %s: %v", filepath.Base(file), err)
+			continue
+		}
+		d.ok("%s plays %s", filepath.Base(c.Path), file)
+		if file == cfg.Audio.File || cmd == nil {
+			cmd = c
+		}
+	}
+
+	if !play || cmd == nil {
+		return
+	}
+	fmt.Println("Playing the adhan, Ctrl-C to stop")
+	player := &audioPlayer{}
+	done := make(chan struct{})
+	if err := player.start(func() { close(done) }, cmd); err != nil {
+		d.fail("", "Failed to play the adhan: %v", err)
+		return
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		player.stop()
+	}
+}
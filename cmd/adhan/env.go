@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// envPrefix starts the environment variables that override the config file.
+const envPrefix = "ADHAN_"
+
+// pushNotifiers are the services ADHAN_NOTIFIER picks from, besides the
+// desktop.
+var pushNotifiers = []string{"ntfy", "pushover", "discord", "slack"}
+
+// applyEnv overrides cfg with the environment, so that adhan can run without
+// a config file, as in a container. Every key of the config file of a
+// string, number, boolean or list has a variable named after it and the
+// tables it is in, e.g. ADHAN_CITY, ADHAN_MQTT_BROKER or
+// ADHAN_NOTIFICATIONS_NTFY_TOPIC, lists being separated by commas; empty
+// variables are ignored. Besides, ADHAN_NOTIFIER lists the notifiers to use,
+// and ADHAN_WEBHOOKS the addresses to send webhooks to.
+func applyEnv(cfg *Config) error {
+	if err := applyEnvFields(reflect.ValueOf(cfg).Elem(), envPrefix); err != nil {
+		return err
+	}
+
+	if v := os.Getenv(envPrefix + "NOTIFIER"); v != "" {
+		if err := applyNotifierEnv(&cfg.Notifications, v); err != nil {
+			return err
+		}
+	}
+	if v := os.Getenv(envPrefix + "WEBHOOKS"); v != "" {
+		for _, url := range strings.Split(v, ",") {
+			if url = strings.TrimSpace(url); url != "" {
+				cfg.Webhooks = append(cfg.Webhooks, WebhookConfig{URL: url})
+			}
+		}
+	}
+	return nil
+}
+
+// applyEnvFields sets the fields of the struct v from the variables named
+// prefix followed by their key.
+func applyEnvFields(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("toml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		name := prefix + strings.ToUpper(key)
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnvFields(field, name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		if err := setEnvField(field, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setEnvField sets field to value, parsed according to its type. Fields of
+// other types, as tables of tables, can't be set from the environment.
+func setEnvField(field reflect.Value, value string) error {
+	if s, ok := field.Addr().Interface().(interface{ Set(string) error }); ok {
+		return s.Set(value)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	}
+	return nil
+}
+
+// applyNotifierEnv keeps the notifiers listed in value, separated by commas,
+// among desktop and the push services configured, or none of them if value
+// is "none".
+func applyNotifierEnv(cfg *NotificationConfig, value string) error {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name != "none" && name != "desktop" && !slices.Contains(pushNotifiers, name) {
+			return fmt.Errorf("invalid %sNOTIFIER: unknown notifier %q, expected desktop, %s or none", envPrefix, name, strings.Join(pushNotifiers, ", "))
+		}
+		names = append(names, name)
+	}
+
+	cfg.Enabled = !slices.Contains(names, "none")
+	cfg.Desktop = slices.Contains(names, "desktop")
+	if !slices.Contains(names, "ntfy") {
+		cfg.Ntfy.Topic = ""
+	}
+	if !slices.Contains(names, "pushover") {
+		cfg.Pushover.Token = ""
+	}
+	if !slices.Contains(names, "discord") {
+		cfg.Discord.Webhook = ""
+	}
+	if !slices.Contains(names, "slack") {
+		cfg.Slack.Webhook = ""
+	}
+	return nil
+}
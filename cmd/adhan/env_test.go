@@ -0,0 +1,94 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("ADHAN_CITY", "Paris")
+	// Empty variables are ignored, like unset ones.
+	t.Setenv("ADHAN_COUNTRY", "")
+	t.Setenv("ADHAN_LATITUDE", "48.85")
+	t.Setenv("ADHAN_METHOD", "france")
+	t.Setenv("ADHAN_NOTIFICATIONS_REPEAT", "0")
+	t.Setenv("ADHAN_NOTIFICATIONS_NTFY_TOPIC", "adhan")
+	t.Setenv("ADHAN_CAST_PRAYERS", "Fajr, ,Isha")
+	t.Setenv("ADHAN_WEBHOOKS", "http://a.example, ,http://b.example")
+
+	cfg := defaultConfig()
+	cfg.Country = "France"
+	cfg.Timezone = "Europe/Paris"
+	if err := applyEnv(&cfg); err != nil {
+		t.Fatalf("applyEnv failed: %v", err)
+	}
+
+	if cfg.City != "Paris" || cfg.Country != "France" || cfg.Timezone != "Europe/Paris" {
+		t.Errorf("city, country, timezone = %q, %q, %q, want Paris, France, Europe/Paris", cfg.City, cfg.Country, cfg.Timezone)
+	}
+	if cfg.Latitude != 48.85 {
+		t.Errorf("latitude = %v, want 48.85", cfg.Latitude)
+	}
+	if cfg.Method != methodPresets["france"] {
+		t.Errorf("method = %d, want %d", cfg.Method, methodPresets["france"])
+	}
+	if cfg.Notifications.Repeat != 0 {
+		t.Errorf("notifications.repeat = %d, want 0", cfg.Notifications.Repeat)
+	}
+	if cfg.Notifications.Ntfy.Topic != "adhan" {
+		t.Errorf("notifications.ntfy.topic = %q, want adhan", cfg.Notifications.Ntfy.Topic)
+	}
+	if !slices.Equal(cfg.Cast.Prayers, []string{"Fajr", "Isha"}) {
+		t.Errorf("cast.prayers = %q, want [Fajr Isha]", cfg.Cast.Prayers)
+	}
+	if len(cfg.Webhooks) != 2 || cfg.Webhooks[0].URL != "http://a.example" || cfg.Webhooks[1].URL != "http://b.example" {
+		t.Errorf("webhooks = %+v, want http://a.example and http://b.example", cfg.Webhooks)
+	}
+}
+
+func TestApplyEnvInvalid(t *testing.T) {
+	tests := []struct {
+		name, value string
+	}{
+		{"ADHAN_LATITUDE", "north"},
+		{"ADHAN_AUTO_LOCATE", "maybe"},
+		{"ADHAN_NOTIFICATIONS_REPEAT", "10m"},
+		{"ADHAN_METHOD", "9999"},
+		{"ADHAN_NOTIFIER", "desktop,email"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			cfg := defaultConfig()
+			if err := applyEnv(&cfg); err == nil {
+				t.Errorf("applyEnv with %s=%q succeeded, want an error", tt.name, tt.value)
+			}
+		})
+	}
+}
+
+func TestApplyNotifierEnv(t *testing.T) {
+	tests := []struct {
+		value            string
+		enabled, desktop bool
+		ntfy, slack      bool
+	}{
+		{"desktop", true, true, false, false},
+		{"ntfy, slack", true, false, true, true},
+		{"NTFY,,desktop", true, true, true, false},
+		{"none", false, false, false, false},
+	}
+	for _, tt := range tests {
+		cfg := defaultConfig().Notifications
+		cfg.Ntfy.Topic = "adhan"
+		cfg.Slack.Webhook = "https://hooks.slack.example"
+		if err := applyNotifierEnv(&cfg, tt.value); err != nil {
+			t.Errorf("applyNotifierEnv(%q) failed: %v", tt.value, err)
+			continue
+		}
+		if cfg.Enabled != tt.enabled || cfg.Desktop != tt.desktop || (cfg.Ntfy.Topic != "") != tt.ntfy || (cfg.Slack.Webhook != "") != tt.slack {
+			t.Errorf("applyNotifierEnv(%q) = enabled %v, desktop %v, ntfy %v, slack %v, want %v, %v, %v, %v", tt.value,
+				cfg.Enabled, cfg.Desktop, cfg.Ntfy.Topic != "", cfg.Slack.Webhook != "", tt.enabled, tt.desktop, tt.ntfy, tt.slack)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// eventsHorizon is how many days ahead `all` looks for upcoming events.
+const eventsHorizon = 30
+
+// EventsConfig configures the reminders of Islamic events such as Eid.
+type EventsConfig struct {
+	Enabled bool `toml:"enabled"`
+	// RemindDays is how many days ahead of an event to remind of it, at Isha.
+	RemindDays int `toml:"remind_days"`
+}
+
+// upcomingEvent is an event along with the day it falls on.
+type upcomingEvent struct {
+	adhan.Event
+	Date adhan.Date
+	Day  time.Time
+}
+
+// upcomingEvents returns the events of the days starting from from, up to
+// days ahead. The lookahead stops at the first month whose timings can't be
+// loaded.
+func upcomingEvents(ctx context.Context, cfg Config, from time.Time, days int) []upcomingEvent {
+	var (
+		events   []upcomingEvent
+		calendar []adhan.Day
+		loaded   time.Month
+	)
+	for i := 0; i < days; i++ {
+		day := from.AddDate(0, 0, i)
+		if calendar == nil || day.Month() != loaded {
+			var err error
+			if calendar, err = loadCalendar(ctx, cfg, day.Year(), day.Month()); err != nil {
+				slog.Error("Failed to fetch upcoming events", "err", err)
+				break
+			}
+			loaded = day.Month()
+		}
+		if day.Day() > len(calendar) {
+			break
+		}
+
+		date := calendar[day.Day()-1].Date
+		for _, e := range date.Events() {
+			events = append(events, upcomingEvent{Event: e, Date: date, Day: day})
+		}
+	}
+	return events
+}
+
+// printEvents lists the events of the coming days, each only once.
+func printEvents(ctx context.Context, cfg Config, from time.Time) {
+	if !cfg.Events.Enabled {
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, e := range upcomingEvents(ctx, cfg, from, eventsHorizon) {
+		if seen[e.Name] {
+			continue
+		}
+		seen[e.Name] = true
+		fmt.Printf("%s: %s (%s)\n", e.Name, inDays(e.Day, from), e.Date.HijriString())
+	}
+}
+
+// inDays describes how far day is from from, e.g. "tomorrow" or "in 3
+// days".
+func inDays(day, from time.Time) string {
+	y1, m1, d1 := from.Date()
+	y2, m2, d2 := day.Date()
+	n := int(time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC).Sub(time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	switch n {
+	case 0:
+		return "today"
+	case 1:
+		return "tomorrow"
+	default:
+		return fmt.Sprintf("in %d days", n)
+	}
+}
+
+// eventReminders reminds at Isha of the events RemindDays days ahead.
+func (s *scheduler) eventReminders(ctx context.Context, today adhan.Day, prayers []adhan.Prayer) []adhan.Reminder {
+	if !s.cfg.Events.Enabled || s.cfg.Events.RemindDays <= 0 {
+		return nil
+	}
+
+	var reminders []adhan.Reminder
+	for _, p := range prayers {
+		if p.Name != "Isha" {
+			continue
+		}
+
+		at := p.Time.AddDate(0, 0, s.cfg.Events.RemindDays)
+		day, err := getDay(ctx, s.cfg, at)
+		if err != nil {
+			slog.Error("Failed to fetch upcoming events", "err", err)
+			continue
+		}
+		for _, e := range day.Date.Events() {
+			reminders = append(reminders, adhan.Reminder{
+				Title:   e.Name,
+				Message: fmt.Sprintf("%s is %s, %s.", e.Name, inDays(at, p.Time), day.Date.HijriString()),
+				Time:    p.Time,
+			})
+		}
+	}
+	return reminders
+}
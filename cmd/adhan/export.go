@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// monthOrCurrent fills in the current year and month for zero values.
+func monthOrCurrent(loc *time.Location, year, month int) (int, time.Month, error) {
+	now := clock.Now().In(loc)
+	if year == 0 {
+		year = now.Year()
+	}
+	if month == 0 {
+		month = int(now.Month())
+	}
+	if month < 1 || month > 12 {
+		return 0, 0, fmt.Errorf("invalid month %d", month)
+	}
+	return year, time.Month(month), nil
+}
+
+// calendarTable lays out a month of timings with one row per day.
+func calendarTable(cfg Config, days []adhan.Day) (header []string, rows [][]string) {
+	header = []string{"Date", "Hijri", "Fajr", "Sunrise", "Dhuhr", "Asr", "Maghrib", "Isha"}
+	rows = make([][]string, 0, len(days))
+	for i, day := range days {
+		date := day.Date.Gregorian.Date
+		if date == "" {
+			date = strconv.Itoa(i + 1)
+		}
+		rows = append(rows, append([]string{date, day.Date.HijriString()}, cfg.formatTimings(day.Timings)...))
+	}
+	return header, rows
+}
+
+// printCalendar prints the timings of a month, highlighting today.
+func printCalendar(ctx context.Context, cfg Config, loc *time.Location, year int, month time.Month) error {
+	days, err := loadCalendar(ctx, cfg, year, month)
+	if err != nil {
+		return fmt.Errorf("failed to fetch calendar: %w", err)
+	}
+
+	today := -1
+	if now := clock.Now().In(loc); now.Year() == year && now.Month() == month {
+		today = now.Day() - 1
+	}
+	header, rows := calendarTable(cfg, days)
+	printHighlighted(header, rows, today)
+	return nil
+}
+
+// printWeek prints the timings of the seven days starting today, which may
+// span two months.
+func printWeek(ctx context.Context, cfg Config, loc *time.Location) error {
+	header, rows, err := weekTable(ctx, cfg, loc)
+	if err != nil {
+		return err
+	}
+	printHighlighted(header, rows, 0)
+	return nil
+}
+
+// weekTable lays out the timings of the seven days starting today with one
+// row per day.
+func weekTable(ctx context.Context, cfg Config, loc *time.Location) (header []string, rows [][]string, err error) {
+	now := clock.Now().In(loc)
+	header = []string{"Date", "Hijri", "Fajr", "Sunrise", "Dhuhr", "Asr", "Maghrib", "Isha"}
+	rows = make([][]string, 0, 7)
+	for i := 0; i < 7; i++ {
+		date := now.AddDate(0, 0, i)
+		day, err := getDay(ctx, cfg, date)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch prayer times: %w", err)
+		}
+		rows = append(rows, append([]string{date.Format("Mon 02 Jan"), day.Date.HijriString()}, cfg.formatTimings(day.Timings)...))
+	}
+	return header, rows, nil
+}
+
+// printHighlighted prints a table with the row at index highlight in bold,
+// or marked with an asterisk when stdout is not a terminal. No row is
+// highlighted when it is out of range.
+func printHighlighted(header []string, rows [][]string, highlight int) {
+	renderHighlighted(os.Stdout, header, rows, highlight, isTerminal(os.Stdout))
+}
+
+// renderHighlighted writes a table to w with the row at index highlight in
+// bold, or marked with an asterisk when bold is false.
+func renderHighlighted(w io.Writer, header []string, rows [][]string, highlight int, bold bool) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(isolateRTL(header))
+	table.SetAlignment(tablewriter.ALIGN_CENTER)
+
+	for i, row := range rows {
+		row = isolateRTL(row)
+		switch {
+		case i != highlight:
+			table.Append(row)
+		case bold:
+			colors := make([]tablewriter.Colors, len(row))
+			for j := range colors {
+				colors[j] = tablewriter.Colors{tablewriter.Bold}
+			}
+			table.Rich(row, colors)
+		default:
+			row[0] += " *"
+			table.Append(row)
+		}
+	}
+
+	table.Render()
+}
+
+// isTerminal reports whether f is a terminal rather than a file or a pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// exportCalendar writes a month of timings to w as CSV or as a Markdown
+// table.
+func exportCalendar(ctx context.Context, w io.Writer, cfg Config, year int, month time.Month, format string) error {
+	days, err := loadCalendar(ctx, cfg, year, month)
+	if err != nil {
+		return fmt.Errorf("failed to fetch calendar: %w", err)
+	}
+	header, rows := calendarTable(cfg, days)
+
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(header)
+		cw.WriteAll(rows)
+		return cw.Error()
+	case "md", "markdown":
+		fmt.Fprintf(w, "## %s %d\n\n", month, year)
+		fmt.Fprintf(w, "| %s |\n", strings.Join(header, " | "))
+		fmt.Fprintf(w, "|%s\n", strings.Repeat("---|", len(header)))
+		for _, row := range rows {
+			if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | ")); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q, expected csv or md", format)
+	}
+}
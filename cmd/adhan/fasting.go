@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// sunnahFasts maps the events on which fasting is sunnah to their name in
+// FastingConfig.Days.
+var sunnahFasts = map[string]string{
+	"White day":     "white",
+	"Day of Arafah": "arafah",
+	"Ashura":        "ashura",
+}
+
+// fastingDays are the names accepted in FastingConfig.Days.
+var fastingDays = []string{"white", "monday", "thursday", "arafah", "ashura"}
+
+// FastingConfig configures the reminders, the evening before, of the days on
+// which fasting is sunnah.
+type FastingConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Days limits the reminders to some of white (13-15 of each Hijri month),
+	// monday, thursday, arafah and ashura. All of them by default.
+	Days []string `toml:"days"`
+}
+
+// validate checks the days.
+func (f FastingConfig) validate() error {
+	for _, d := range f.Days {
+		if !containsPrayer(fastingDays, d) {
+			return fmt.Errorf("unknown fasting day %q, expected one of %s", d, strings.Join(fastingDays, ", "))
+		}
+	}
+	return nil
+}
+
+// reminds reports whether to remind of the days named name.
+func (f FastingConfig) reminds(name string) bool {
+	return len(f.Days) == 0 || containsPrayer(f.Days, name)
+}
+
+// sunnahFast returns why fasting on day, whose date is date, is sunnah, if it
+// is. It never is in Ramadan, when fasting is obligatory, nor on the days of
+// Eid and Tashreeq, when it is forbidden.
+func (c Config) sunnahFast(date adhan.Date, day time.Time) []string {
+	month := date.Hijri.Month.Number
+	hijriDay, _ := strconv.Atoi(date.Hijri.Day)
+	switch {
+	case month == ramadan,
+		month == 10 && hijriDay == 1,
+		month == 12 && hijriDay >= 10 && hijriDay <= 13:
+		return nil
+	}
+
+	var reasons []string
+	for _, e := range date.Events() {
+		if name, ok := sunnahFasts[e.Name]; ok && c.Fasting.reminds(name) {
+			reasons = append(reasons, c.tr(e.Name))
+		}
+	}
+	switch day.Weekday() {
+	case time.Monday:
+		if c.Fasting.reminds("monday") {
+			reasons = append(reasons, c.tr("Monday"))
+		}
+	case time.Thursday:
+		if c.Fasting.reminds("thursday") {
+			reasons = append(reasons, c.tr("Thursday"))
+		}
+	}
+	return reasons
+}
+
+// fastingReminders reminds at Isha when fasting is sunnah the day after.
+func (s *scheduler) fastingReminders(ctx context.Context, prayers []adhan.Prayer) []adhan.Reminder {
+	if !s.cfg.Fasting.Enabled {
+		return nil
+	}
+
+	var reminders []adhan.Reminder
+	for _, p := range prayers {
+		if p.Name != "Isha" {
+			continue
+		}
+
+		tomorrow := p.Time.AddDate(0, 0, 1)
+		day, err := getDay(ctx, s.cfg, tomorrow)
+		if err != nil {
+			slog.Error("Failed to fetch tomorrow's prayer times", "err", err)
+			continue
+		}
+		reasons := s.cfg.sunnahFast(day.Date, tomorrow)
+		if len(reasons) == 0 {
+			continue
+		}
+		reminders = append(reminders, adhan.Reminder{
+			Title:   s.cfg.tr("Fasting"),
+			Message: s.cfg.tr("Tomorrow is %s, fasting is sunnah. Suhoor ends at %s.", strings.Join(reasons, ", "), s.cfg.formatTiming(day.Timings.Fajr)),
+			Time:    p.Time,
+		})
+	}
+	return reminders
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"iustusae/adhan/internal/i18n"
+	"iustusae/adhan/pkg/adhan"
+)
+
+// options are the command line flags shared by every command.
+type options struct {
+	config     string
+	profile    string
+	city       []string
+	country    string
+	method     MethodID
+	school     string
+	timezone   string
+	timeFormat string
+	latitude   float64
+	longitude  float64
+	autoLocate bool
+	headless   bool
+	verbose    bool
+	quiet      bool
+	logFormat  string
+	logFile    string
+	at         string
+	speed      string
+}
+
+func (o *options) register(fs *pflag.FlagSet) {
+	fs.StringVar(&o.config, "config", "", "path to the config file (default ~/.config/adhan/config.toml)")
+	fs.StringVar(&o.profile, "profile", "", "profile to use instead of the one picked with adhan profile use")
+	fs.StringArrayVar(&o.city, "city", nil, "city to fetch prayer times for, as \"City\" or \"City, Country\"; repeat it to compare cities with adhan all")
+	fs.StringVar(&o.country, "country", "", "country the city is in")
+	fs.Var(&o.method, "method", "calculation method number or preset name, see adhan methods")
+	fs.StringVar(&o.school, "school", "", "juristic school for Asr, shafi or hanafi")
+	fs.StringVar(&o.timezone, "timezone", "", "IANA time zone of the location, e.g. America/New_York")
+	fs.StringVar(&o.timeFormat, "time-format", "", "how to show times: 24h, 12h, locale or a Go layout like 15.04 (default 24h)")
+	fs.Float64Var(&o.latitude, "latitude", 0, "latitude of the location, used instead of the city")
+	fs.Float64Var(&o.longitude, "longitude", 0, "longitude of the location, used instead of the city")
+	fs.BoolVar(&o.autoLocate, "auto-locate", false, "resolve the location from the public IP address")
+	fs.BoolVar(&o.headless, "headless", false, "run without a desktop: no desktop notifications or audio, only logs, push notifications, webhooks, hooks, MQTT and the HTTP API")
+	fs.BoolVarP(&o.verbose, "verbose", "v", false, "log debug messages too")
+	fs.BoolVarP(&o.quiet, "quiet", "q", false, "log errors only")
+	fs.StringVar(&o.logFormat, "log-format", "", "log format, text or json (default text)")
+	fs.StringVar(&o.logFile, "log-file", "", "log to this file instead of stderr, rotated as it grows; adhan.log in the state directory if no path is given")
+	fs.Lookup("log-file").NoOptDefVal = "default"
+	fs.StringVar(&o.at, "at", "", "pretend it is this time, e.g. \"2024-03-10 18:29\", to check what adhan does then")
+	fs.StringVar(&o.speed, "speed", "", "run the clock this many times faster, e.g. 60x, along with --at")
+}
+
+// clock returns the simulated clock asked for with --at and --speed, or the
+// real one.
+func (o *options) clock(loc *time.Location) (adhan.Clock, error) {
+	if o.at == "" && o.speed == "" {
+		return adhan.SystemClock{}, nil
+	}
+
+	start := time.Now().In(loc)
+	if o.at != "" {
+		t, err := parseAt(o.at, loc)
+		if err != nil {
+			return nil, err
+		}
+		start = t
+	}
+	speed := 1.0
+	if o.speed != "" {
+		s, err := parseSpeed(o.speed)
+		if err != nil {
+			return nil, err
+		}
+		speed = s
+	}
+	return adhan.NewSimulatedClock(start, speed), nil
+}
+
+// configPath returns the config file given with --config, or the default one.
+func (o *options) configPath() (string, error) {
+	if o.config != "" {
+		return o.config, nil
+	}
+	return configPath()
+}
+
+// load reads the config file, then applies the environment and the command
+// line on top of it.
+func (o *options) load(ctx context.Context, fs *pflag.FlagSet) (Config, error) {
+	path, err := o.configPath()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to locate config file: %w", err)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to load config file: %w", err)
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return cfg, err
+	}
+	if cfg.Travel.Enabled {
+		cfg.AutoLocate = true
+	}
+	if err := o.useProfile(fs, &cfg); err != nil {
+		return cfg, err
+	}
+	o.apply(fs, &cfg)
+	if cfg.Headless {
+		cfg.headless()
+	}
+
+	// Set up logging first, so that what follows is logged as configured.
+	if err := setupLogging(cfg.Log); err != nil {
+		return cfg, err
+	}
+
+	if _, err := cfg.asrSchool(); err != nil {
+		return cfg, err
+	}
+	if _, err := cfg.timeLayout(); err != nil {
+		return cfg, err
+	}
+	if cfg.translator, err = i18n.New(cfg.Language); err != nil {
+		return cfg, err
+	}
+	if err := cfg.validatePrayerNames(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Jumuah.validate(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Fasting.validate(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Break.validate(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Quiet.validate(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Cast.validate(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Lights.validate(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.validatePrayers(); err != nil {
+		return cfg, err
+	}
+
+	if cfg.AutoLocate {
+		location, err := autoLocate(ctx)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to resolve location: %w", err)
+		}
+		location.apply(&cfg)
+	}
+
+	return cfg, nil
+}
+
+// useProfile applies the profile given with --profile, or else the one in
+// use, to cfg.
+func (o *options) useProfile(fs *pflag.FlagSet, cfg *Config) error {
+	name := o.profile
+	if !fs.Changed("profile") {
+		var err error
+		if name, err = activeProfile(); err != nil {
+			return fmt.Errorf("failed to read profile in use: %w", err)
+		}
+		// Removing a profile from the config mustn't keep adhan from
+		// starting.
+		if _, ok := cfg.Profiles[name]; name != "" && !ok {
+			slog.Warn("Profile in use is no longer configured, ignoring it", "profile", name)
+			return nil
+		}
+	}
+	return useProfile(cfg, name)
+}
+
+// apply overrides cfg with the flags that were explicitly set on the command
+// line, so they always take precedence over the config file.
+func (o *options) apply(fs *pflag.FlagSet, cfg *Config) {
+	if fs.Changed("city") {
+		cfg.City, cfg.Country = splitCity(o.city[0], cfg.Country)
+		if len(o.city) > 1 {
+			cfg.Cities = o.city[1:]
+		}
+	}
+	if fs.Changed("country") {
+		cfg.Country = o.country
+	}
+	if fs.Changed("method") {
+		cfg.Method = o.method
+	}
+	if fs.Changed("school") {
+		cfg.School = o.school
+	}
+	if fs.Changed("timezone") {
+		cfg.Timezone = o.timezone
+	}
+	if fs.Changed("time-format") {
+		cfg.TimeFormat = o.timeFormat
+	}
+	if fs.Changed("latitude") {
+		cfg.Latitude = o.latitude
+	}
+	if fs.Changed("longitude") {
+		cfg.Longitude = o.longitude
+	}
+	if fs.Changed("auto-locate") {
+		cfg.AutoLocate = o.autoLocate
+	}
+	if fs.Changed("headless") {
+		cfg.Headless = o.headless
+	}
+	if o.verbose {
+		cfg.Log.Level = "debug"
+	}
+	if o.quiet {
+		cfg.Log.Level = "error"
+	}
+	if fs.Changed("log-format") {
+		cfg.Log.Format = o.logFormat
+	}
+	if fs.Changed("log-file") {
+		cfg.Log.File = o.logFile
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// focusOn reports whether a Focus, such as Do Not Disturb, is on. macOS
+// records the Focus turned on in ~/Library/DoNotDisturb, which takes Full
+// Disk Access to read.
+func focusOn() (bool, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return false, err
+	}
+	raw, err := os.ReadFile(filepath.Join(home, "Library", "DoNotDisturb", "DB", "Assertions.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var assertions struct {
+		Data []struct {
+			Records []json.RawMessage `json:"storeAssertionRecords"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &assertions); err != nil {
+		return false, err
+	}
+	for _, d := range assertions.Data {
+		if len(d.Records) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
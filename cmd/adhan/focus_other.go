@@ -0,0 +1,8 @@
+//go:build !darwin
+
+package main
+
+// focusOn reports whether a Focus is on, which only macOS has.
+func focusOn() (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	googleCalendarAPI   = "https://www.googleapis.com/calendar/v3"
+	googleCalendarScope = "https://www.googleapis.com/auth/calendar"
+	// googleEventPrefix starts the IDs of the events adhan creates, which
+	// are the only ones it updates or removes.
+	googleEventPrefix = "adhan"
+)
+
+var googleEndpoint = oauth2.Endpoint{
+	AuthURL:   "https://accounts.google.com/o/oauth2/auth",
+	TokenURL:  "https://oauth2.googleapis.com/token",
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
+// googleEventIDs encodes event IDs in base32hex, the characters Google
+// Calendar allows in them.
+var googleEventIDs = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// GoogleSyncConfig configures syncing to Google Calendar.
+type GoogleSyncConfig struct {
+	// ClientID and ClientSecret are those of an OAuth client of the Desktop
+	// app type, created in the Google Cloud console of a project with the
+	// Calendar API enabled.
+	ClientID     string `toml:"client_id"`
+	ClientSecret string `toml:"client_secret"`
+	// Calendar is the name of the calendar synced to, created if needed,
+	// "Prayer Times" by default.
+	Calendar string `toml:"calendar"`
+}
+
+func (g GoogleSyncConfig) calendar() string {
+	if g.Calendar == "" {
+		return "Prayer Times"
+	}
+	return g.Calendar
+}
+
+// googleTokenPath returns the file keeping the token of the Google account
+// signed in to, usually ~/.config/adhan/google-token.json.
+func googleTokenPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "adhan", "google-token.json"), nil
+}
+
+// googleClient returns an HTTP client authorized to manage the calendars of
+// the Google account signed in to, signing in first if needed.
+func googleClient(ctx context.Context, cfg GoogleSyncConfig) (*http.Client, error) {
+	if cfg.ClientID == "" || cfg.ClientSecret == "" {
+		return nil, errors.New("set sync.google.client_id and client_secret to those of an OAuth client of Google Cloud")
+	}
+	conf := &oauth2.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		Endpoint:     googleEndpoint,
+		Scopes:       []string{googleCalendarScope},
+	}
+
+	path, err := googleTokenPath()
+	if err != nil {
+		return nil, err
+	}
+	var token *oauth2.Token
+	if raw, err := os.ReadFile(path); err == nil {
+		err = json.Unmarshal(raw, &token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	} else {
+		if token, err = signInGoogle(ctx, conf); err != nil {
+			return nil, fmt.Errorf("failed to sign in to Google: %w", err)
+		}
+		raw, err := json.Marshal(token)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		// The token gives access to the calendars, only the user may read it.
+		if err := os.WriteFile(path, raw, 0o600); err != nil {
+			return nil, err
+		}
+	}
+	return conf.Client(ctx, token), nil
+}
+
+// signInGoogle has the user sign in through the browser, which Google
+// redirects to a server listening on the loopback interface with the code
+// exchanged for a token.
+func signInGoogle(ctx context.Context, conf *oauth2.Config) (*oauth2.Token, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	defer ln.Close()
+	conf.RedirectURL = "http://" + ln.Addr().String() + "/"
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	state := hex.EncodeToString(b)
+	verifier := oauth2.GenerateVerifier()
+
+	fmt.Printf("Open this address in a browser to let adhan manage its calendar:\n\n%s\n\n",
+		conf.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier)))
+
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("state") != state:
+			http.Error(w, "Unexpected request", http.StatusBadRequest)
+			return
+		case q.Get("error") != "":
+			http.Error(w, "Signing in failed: "+q.Get("error"), http.StatusForbidden)
+			select {
+			case errs <- errors.New(q.Get("error")):
+			default:
+			}
+			return
+		}
+		fmt.Fprintln(w, "adhan is signed in, you can close this page.")
+		// Only the first redirect counts, the browser may send it again.
+		select {
+		case codes <- q.Get("code"):
+		default:
+		}
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	select {
+	case code := <-codes:
+		return conf.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	case err := <-errs:
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// googleError is an error returned by the Google Calendar API.
+type googleError struct {
+	Status  int
+	Message string
+}
+
+func (e *googleError) Error() string {
+	return fmt.Sprintf("Google Calendar: %s (%d)", e.Message, e.Status)
+}
+
+// googleCalendar calls the Google Calendar API.
+type googleCalendar struct {
+	client *http.Client
+}
+
+// do sends a request with body encoded as JSON, if not nil, and decodes the
+// response into out, if not nil.
+func (g googleCalendar) do(ctx context.Context, method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, googleCalendarAPI+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return &googleError{Status: resp.StatusCode, Message: apiErr.Error.Message}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// calendarID returns the ID of the calendar named name, creating it in the
+// time zone tz if there is none.
+func (g googleCalendar) calendarID(ctx context.Context, name, tz string) (string, error) {
+	page := ""
+	for {
+		var list struct {
+			Items []struct {
+				ID      string `json:"id"`
+				Summary string `json:"summary"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := g.do(ctx, http.MethodGet, "/users/me/calendarList?pageToken="+url.QueryEscape(page), nil, &list); err != nil {
+			return "", err
+		}
+		for _, c := range list.Items {
+			if c.Summary == name {
+				return c.ID, nil
+			}
+		}
+		if page = list.NextPageToken; page == "" {
+			break
+		}
+	}
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	err := g.do(ctx, http.MethodPost, "/calendars", map[string]string{"summary": name, "timeZone": tz}, &created)
+	return created.ID, err
+}
+
+// googleTime is the start or end of an event.
+type googleTime struct {
+	DateTime time.Time `json:"dateTime"`
+	TimeZone string    `json:"timeZone,omitempty"`
+}
+
+// googleEvent is an event of Google Calendar, of those fields adhan sets.
+type googleEvent struct {
+	ID      string     `json:"id"`
+	Summary string     `json:"summary"`
+	Start   googleTime `json:"start"`
+	End     googleTime `json:"end"`
+}
+
+// events returns the events of calendar between from and to.
+func (g googleCalendar) events(ctx context.Context, calendar string, from, to time.Time) ([]googleEvent, error) {
+	var events []googleEvent
+	page := ""
+	for {
+		q := url.Values{
+			"timeMin":      {from.Format(time.RFC3339)},
+			"timeMax":      {to.Format(time.RFC3339)},
+			"singleEvents": {"true"},
+			"maxResults":   {"2500"},
+			"pageToken":    {page},
+		}
+		var list struct {
+			Items         []googleEvent `json:"items"`
+			NextPageToken string        `json:"nextPageToken"`
+		}
+		if err := g.do(ctx, http.MethodGet, "/calendars/"+url.PathEscape(calendar)+"/events?"+q.Encode(), nil, &list); err != nil {
+			return nil, err
+		}
+		events = append(events, list.Items...)
+		if page = list.NextPageToken; page == "" {
+			return events, nil
+		}
+	}
+}
+
+// syncGoogle syncs the prayer times of the coming days to a calendar of
+// Google Calendar.
+func syncGoogle(ctx context.Context, cfg Config) error {
+	loc, err := cfg.location()
+	if err != nil {
+		return err
+	}
+	client, err := googleClient(ctx, cfg.Sync.Google)
+	if err != nil {
+		return err
+	}
+	g := googleCalendar{client: client}
+
+	calendar, err := g.calendarID(ctx, cfg.Sync.Google.calendar(), loc.String())
+	if err != nil {
+		return fmt.Errorf("failed to find the calendar: %w", err)
+	}
+
+	now := clock.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	events, err := syncEvents(ctx, cfg, from)
+	if err != nil {
+		return err
+	}
+	stats, err := g.sync(ctx, calendar, events, from, from.AddDate(0, 0, cfg.Sync.days()), loc)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Synced %d days of prayer times to the %s calendar: %s\n", cfg.Sync.days(), cfg.Sync.Google.calendar(), stats)
+	return nil
+}
+
+// sync makes events the events adhan added to calendar between from and to:
+// it adds those missing, updates those that changed and removes the others.
+func (g googleCalendar) sync(ctx context.Context, calendar string, events []calendarEvent, from, to time.Time, loc *time.Location) (syncStats, error) {
+	var stats syncStats
+	existing, err := g.events(ctx, calendar, from, to)
+	if err != nil {
+		return stats, fmt.Errorf("failed to list the events: %w", err)
+	}
+	synced := make(map[string]googleEvent, len(existing))
+	for _, e := range existing {
+		synced[e.ID] = e
+	}
+
+	path := "/calendars/" + url.PathEscape(calendar) + "/events"
+	for _, e := range events {
+		want := googleEvent{
+			ID:      googleEventPrefix + strings.ToLower(googleEventIDs.EncodeToString([]byte(e.ID))),
+			Summary: e.Summary,
+			Start:   googleTime{DateTime: e.Start, TimeZone: loc.String()},
+			End:     googleTime{DateTime: e.End, TimeZone: loc.String()},
+		}
+		have, ok := synced[want.ID]
+		delete(synced, want.ID)
+		switch {
+		case !ok:
+			err = g.do(ctx, http.MethodPost, path, want, nil)
+			// Events removed before keep their ID, and are restored by
+			// updating them instead.
+			var apiErr *googleError
+			if errors.As(err, &apiErr) && apiErr.Status == http.StatusConflict {
+				err = g.do(ctx, http.MethodPut, path+"/"+want.ID, want, nil)
+			}
+			stats.added++
+		case have.Summary != want.Summary || !have.Start.DateTime.Equal(want.Start.DateTime) || !have.End.DateTime.Equal(want.End.DateTime):
+			err = g.do(ctx, http.MethodPut, path+"/"+want.ID, want, nil)
+			stats.updated++
+		}
+		if err != nil {
+			return stats, fmt.Errorf("failed to sync %s on %s: %w", e.Summary, e.Start.Format(time.DateOnly), err)
+		}
+	}
+	for id := range synced {
+		if !strings.HasPrefix(id, googleEventPrefix) {
+			continue
+		}
+		if err := g.do(ctx, http.MethodDelete, path+"/"+id, nil, nil); err != nil {
+			return stats, fmt.Errorf("failed to remove an event: %w", err)
+		}
+		stats.removed++
+	}
+	return stats, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/gpio/gpioreg"
+	"periph.io/x/host/v3"
+)
+
+// GPIOConfig configures pulsing a GPIO pin at prayer times, as that of a
+// Raspberry Pi driving a relay, a buzzer or a speaker amplifier.
+type GPIOConfig struct {
+	// Pin is the name of the pin, e.g. "GPIO17". GPIO is disabled when it is
+	// empty.
+	Pin string `toml:"pin"`
+	// Duration is how many seconds the pin stays on, 5 by default.
+	Duration int `toml:"duration"`
+	// ActiveLow turns the pin on by driving it low, as many relay boards
+	// expect.
+	ActiveLow bool `toml:"active_low"`
+	// Prayers limits the pulses to some prayers, all but Sunrise by default.
+	Prayers []string `toml:"prayers"`
+}
+
+func (g GPIOConfig) duration() time.Duration {
+	if g.Duration <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(g.Duration) * time.Second
+}
+
+// pulses reports whether the pin is pulsed for prayer, Jumu'ah counting as
+// Dhuhr.
+func (g GPIOConfig) pulses(prayer string) bool {
+	if len(g.Prayers) > 0 {
+		return containsPrayer(g.Prayers, prayer) || prayer == "Jumu'ah" && containsPrayer(g.Prayers, "Dhuhr")
+	}
+	return prayer != "Sunrise"
+}
+
+// gpioPin pulses a pin when prayer times arrive.
+type gpioPin struct {
+	cfg GPIOConfig
+	pin gpio.PinIO
+	on  gpio.Level
+
+	mu  sync.Mutex
+	off *time.Timer
+}
+
+// newGPIOPin opens the configured pin and turns it off.
+func newGPIOPin(cfg GPIOConfig) (*gpioPin, error) {
+	if _, err := host.Init(); err != nil {
+		return nil, fmt.Errorf("failed to load GPIO drivers: %w", err)
+	}
+	pin := gpioreg.ByName(cfg.Pin)
+	if pin == nil {
+		return nil, fmt.Errorf("no GPIO pin named %q", cfg.Pin)
+	}
+	p := &gpioPin{cfg: cfg, pin: pin, on: gpio.Level(!cfg.ActiveLow)}
+	if err := pin.Out(!p.on); err != nil {
+		return nil, fmt.Errorf("failed to set up %s: %w", pin, err)
+	}
+	return p, nil
+}
+
+// onEvent turns the pin on when a prayer time arrives, and off again once
+// the pulse is over.
+func (p *gpioPin) onEvent(e prayerEvent) {
+	if e.Type != "prayer" || e.Prayer == nil || !p.cfg.pulses(e.Prayer.Name) {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	slog.Debug("Pulsing GPIO pin", "pin", p.pin, "prayer", e.Prayer.Name, "duration", p.cfg.duration())
+	if err := p.pin.Out(p.on); err != nil {
+		slog.Error("Failed to pulse GPIO pin", "pin", p.pin, "err", err)
+		return
+	}
+	if p.off != nil {
+		p.off.Stop()
+	}
+	p.off = time.AfterFunc(p.cfg.duration(), p.turnOff)
+}
+
+func (p *gpioPin) turnOff() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.pin.Out(!p.on); err != nil {
+		slog.Error("Failed to turn GPIO pin off", "pin", p.pin, "err", err)
+	}
+}
+
+// close turns the pin off, in case the daemon stops during a pulse.
+func (p *gpioPin) close() {
+	p.mu.Lock()
+	if p.off != nil {
+		p.off.Stop()
+	}
+	p.mu.Unlock()
+	p.turnOff()
+}
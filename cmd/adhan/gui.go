@@ -0,0 +1,264 @@
+//go:build gui
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+
+	"iustusae/adhan/internal/i18n"
+	"iustusae/adhan/pkg/adhan"
+)
+
+// gui is the window of adhan gui: today's timetable with a countdown to the
+// next prayer, and a settings editor writing to the config file.
+type gui struct {
+	r *reloader
+
+	mu  sync.Mutex
+	cfg Config
+	loc *time.Location
+
+	date     *widget.Label
+	next     *widget.Label
+	progress *widget.ProgressBar
+	timings  *fyne.Container
+}
+
+// runGUI opens the window until it is closed or ctx is done, while the
+// daemon notifies at prayer times in the background, unless another instance
+// already does.
+func runGUI(ctx context.Context, cfg Config, loc *time.Location, r *reloader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := runDaemon(ctx, cfg, loc, r); err != nil {
+			slog.Warn("Not notifying at prayer times from the window", "err", err)
+		}
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	a := app.NewWithID("io.github.iustusae.adhan")
+	a.SetIcon(fyne.NewStaticResource("adhan.png", iconPNG()))
+	w := a.NewWindow("adhan")
+
+	g := &gui{
+		r:        r,
+		cfg:      cfg,
+		loc:      loc,
+		date:     widget.NewLabel(""),
+		next:     widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		progress: widget.NewProgressBar(),
+		timings:  container.New(layout.NewFormLayout()),
+	}
+	g.progress.TextFormatter = func() string { return "" }
+
+	w.SetContent(container.NewAppTabs(
+		container.NewTabItem(cfg.tr("Today"), container.NewVBox(g.date, g.next, g.progress, widget.NewSeparator(), g.timings)),
+		container.NewTabItem(cfg.tr("Settings"), g.settings(w)),
+	))
+	w.Resize(fyne.NewSize(380, 420))
+
+	go g.refresh(ctx)
+	go func() {
+		<-ctx.Done()
+		a.Quit()
+	}()
+
+	w.ShowAndRun()
+	return nil
+}
+
+// config returns the config the window currently follows.
+func (g *gui) config() (Config, *time.Location) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.cfg, g.loc
+}
+
+// refresh updates the countdown every second, and the timetable when the day
+// or the config changes, until ctx is done.
+func (g *gui) refresh(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var today adhan.Day
+	var loaded time.Time
+	var loadedWith Config
+	for {
+		cfg, loc := g.config()
+		now := clock.Now().In(loc)
+
+		if loaded.IsZero() || loaded.YearDay() != now.YearDay() || cfg.cacheKey() != loadedWith.cacheKey() {
+			day, err := getToday(ctx, cfg)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				slog.Error("Failed to fetch prayer times", "err", err)
+				g.next.SetText(err.Error())
+			} else {
+				today, loaded, loadedWith = day, now, cfg
+				g.showTimings(cfg, today, now)
+			}
+		}
+
+		if !loaded.IsZero() {
+			if next, err := nextPrayer(ctx, cfg, today, now); err == nil {
+				g.next.SetText(cfg.tr("%s in %s", cfg.localName(next), formatCountdown(next.Time.Sub(now), true)))
+
+				since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+				if current, ok := currentPrayer(today, now); ok {
+					since = current.Time
+				}
+				g.progress.SetValue(float64(now.Sub(since)) / float64(next.Time.Sub(since)))
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// showTimings fills the timetable with today's timings.
+func (g *gui) showTimings(cfg Config, today adhan.Day, now time.Time) {
+	date := now.Format("Monday 02 January 2006")
+	if hijri := today.Date.HijriString(); hijri != "" {
+		date += "\n" + hijri
+	}
+	g.date.SetText(date)
+
+	g.timings.RemoveAll()
+	for _, row := range timingsTable(cfg, today, now) {
+		g.timings.Add(widget.NewLabelWithStyle(row[0], fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		g.timings.Add(widget.NewLabel(row[1]))
+	}
+}
+
+// settings lays out the editor of the most common settings, which are saved
+// to the config file, leaving the rest of it as it is.
+func (g *gui) settings(w fyne.Window) fyne.CanvasObject {
+	cfg, _ := g.config()
+
+	city := widget.NewEntry()
+	city.SetText(cfg.City)
+	country := widget.NewEntry()
+	country.SetText(cfg.Country)
+
+	presets := make([]string, 0, len(methodPresets))
+	for name := range methodPresets {
+		presets = append(presets, name)
+	}
+	sort.Strings(presets)
+	method := widget.NewSelect(presets, nil)
+	preset, _, _ := strings.Cut(cfg.Method.preset(), ",")
+	method.SetSelected(preset)
+
+	school := widget.NewSelect([]string{"shafi", "hanafi"}, nil)
+	school.SetSelected(strings.ToLower(cfg.School))
+	if school.Selected == "" {
+		school.SetSelected("shafi")
+	}
+
+	formats := []string{"24h", "12h", "locale"}
+	if cfg.TimeFormat != "" && !slices.Contains(formats, cfg.TimeFormat) {
+		formats = append(formats, cfg.TimeFormat)
+	}
+	timeFormat := widget.NewSelect(formats, nil)
+	timeFormat.SetSelected(cfg.TimeFormat)
+	if timeFormat.Selected == "" {
+		timeFormat.SetSelected("24h")
+	}
+
+	language := widget.NewSelect(i18n.Languages(), nil)
+	language.SetSelected(cfg.translator.Lang())
+
+	form := &widget.Form{
+		Items: []*widget.FormItem{
+			{Text: cfg.tr("City"), Widget: city},
+			{Text: cfg.tr("Country"), Widget: country},
+			{Text: cfg.tr("Method"), Widget: method},
+			{Text: cfg.tr("School"), Widget: school},
+			{Text: cfg.tr("Time format"), Widget: timeFormat},
+			{Text: cfg.tr("Language"), Widget: language},
+		},
+		SubmitText: cfg.tr("Save"),
+	}
+	form.OnSubmit = func() {
+		keys := []configKey{
+			{"method", method.Selected},
+			{"school", school.Selected},
+			{"time_format", timeFormat.Selected},
+			{"language", language.Selected},
+		}
+		cfg, _ := g.config()
+		if city.Text != cfg.City || country.Text != cfg.Country {
+			keys = append(keys, locationKeys(strings.TrimSpace(city.Text), strings.TrimSpace(country.Text))...)
+		}
+
+		if err := g.save(keys); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if cfg, _ := g.config(); cfg.Profile != "" {
+			dialog.ShowInformation("adhan", fmt.Sprintf("Profile %s is in use, run `adhan profile reset` to use the saved location", cfg.Profile), w)
+		}
+	}
+	return form
+}
+
+// locationKeys returns the config keys moving to city. Its coordinates and
+// time zone are those of the offline city database, or else left to the API.
+func locationKeys(city, country string) []configKey {
+	located, _ := Config{City: city, Country: country}.geocoded()
+	return []configKey{
+		{"city", city},
+		{"country", country},
+		{"latitude", located.Latitude},
+		{"longitude", located.Longitude},
+		{"timezone", located.Timezone},
+		{"auto_locate", false},
+	}
+}
+
+// save writes keys to the config file and loads it again. The daemon picks
+// up the change by itself.
+func (g *gui) save(keys []configKey) error {
+	if g.r == nil {
+		return errors.New("the config file is unknown")
+	}
+	if err := setConfigKeys(g.r.path, keys...); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	cfg, loc, err := g.r.reload()
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	g.cfg, g.loc = cfg, loc
+	g.mu.Unlock()
+	return nil
+}
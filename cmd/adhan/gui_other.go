@@ -0,0 +1,13 @@
+//go:build !gui
+
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+func runGUI(ctx context.Context, cfg Config, loc *time.Location, r *reloader) error {
+	return errors.New("adhan was built without the window, rebuild it with -tags gui")
+}
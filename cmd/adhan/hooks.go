@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// hooksListener runs the configured shell hooks: on_<prayer> when that
+// prayer time arrives (on_jumuah on Fridays, falling back to on_dhuhr),
+// on_prayer for every prayer and on_reminder for reminders. Hooks get the
+// details of the event in ADHAN_* environment variables.
+func hooksListener(hooks map[string]string) func(prayerEvent) {
+	return func(e prayerEvent) {
+		var names []string
+		switch e.Type {
+		case "prayer":
+			if e.Prayer == nil {
+				return
+			}
+			name := "on_" + strings.ToLower(strings.ReplaceAll(e.Prayer.Name, "'", ""))
+			if _, ok := hooks[name]; !ok && name == "on_jumuah" {
+				name = "on_dhuhr"
+			}
+			names = []string{name, "on_prayer"}
+		case "reminder":
+			names = []string{"on_reminder"}
+		default:
+			return
+		}
+
+		env := append(os.Environ(), "ADHAN_EVENT="+e.Type, "ADHAN_MESSAGE="+e.Message)
+		if e.Prayer != nil {
+			env = append(env, "ADHAN_PRAYER="+e.Prayer.Name, "ADHAN_TIME="+e.Prayer.Time.Format("15:04"))
+		}
+		if e.Title != "" {
+			env = append(env, "ADHAN_TITLE="+e.Title)
+		}
+
+		for _, name := range names {
+			script, ok := hooks[name]
+			if !ok || script == "" {
+				continue
+			}
+			go runHook(name, script, env)
+		}
+	}
+}
+
+// runHook runs script through the shell, logging its output when it fails.
+func runHook(name, script string, env []string) {
+	cmd := shellCommand(script)
+	cmd.Env = env
+	if out, err := cmd.CombinedOutput(); err != nil {
+		slog.Error("Hook failed", "hook", name, "err", err, "output", strings.TrimSpace(string(out)))
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+import "os/exec"
+
+func shellCommand(script string) *exec.Cmd {
+	return exec.Command("/bin/sh", "-c", script)
+}
@@ -0,0 +1,7 @@
+package main
+
+import "os/exec"
+
+func shellCommand(script string) *exec.Cmd {
+	return exec.Command("cmd", "/C", script)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"runtime"
+)
+
+// iconSize is the width and height of the icon, in pixels.
+const iconSize = 32
+
+// iconPNG draws a crescent moon, as a PNG.
+func iconPNG() []byte {
+	const size = iconSize
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+
+	inside := func(x, y, cx, cy, r float64) bool {
+		return (x-cx)*(x-cx)+(y-cy)*(y-cy) <= r*r
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			fx, fy := float64(x)+0.5, float64(y)+0.5
+			if inside(fx, fy, 14, 16, 13) && !inside(fx, fy, 20, 12, 11) {
+				img.Set(x, y, color.NRGBA{R: 0x2e, G: 0x9e, B: 0x5b, A: 0xff})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// trayIcon draws the icon for the tray, as a PNG or, on Windows, as an ICO
+// wrapping that PNG.
+func trayIcon() []byte {
+	png := iconPNG()
+	if runtime.GOOS != "windows" {
+		return png
+	}
+
+	var ico bytes.Buffer
+	binary.Write(&ico, binary.LittleEndian, struct {
+		Reserved, Type, Count uint16
+		Width, Height         uint8
+		Colors, Reserved2     uint8
+		Planes, BitCount      uint16
+		Size, Offset          uint32
+	}{0, 1, 1, iconSize, iconSize, 0, 0, 1, 32, uint32(len(png)), 22})
+	ico.Write(png)
+	return ico.Bytes()
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+var systemdUnit = template.Must(template.New("adhan.service").Parse(`[Unit]
+Description=Adhan prayer time notifications
+After=graphical-session.target
+
+[Service]
+ExecStart={{.ExecStart}}
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=default.target
+`))
+
+const launchdLabel = "com.github.iustusae.adhan"
+
+var launchdPlist = template.Must(template.New("adhan.plist").Funcs(template.FuncMap{
+	"xml": template.HTMLEscapeString,
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{.Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+{{- range .Args}}
+		<string>{{xml .}}</string>
+{{- end}}
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ThrottleInterval</key>
+	<integer>30</integer>
+	<key>StandardErrorPath</key>
+	<string>{{xml .Log}}</string>
+</dict>
+</plist>
+`))
+
+// daemonArgs returns the command line running the daemon with the given
+// config file. The path is made absolute, since the daemon doesn't run in
+// the current directory.
+func daemonArgs(configPath string) ([]string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return nil, err
+	}
+	if configPath, err = filepath.Abs(configPath); err != nil {
+		return nil, err
+	}
+	return []string{exe, "--config", configPath, "daemon"}, nil
+}
+
+// systemdQuote quotes arg for the command line of a unit: specifiers and
+// variables are escaped, and arguments with spaces, quotes or backslashes
+// are double quoted.
+func systemdQuote(arg string) string {
+	arg = strings.NewReplacer("%", "%%", "$", "$$").Replace(arg)
+	if arg != "" && arg != ";" && !strings.ContainsAny(arg, " \t\n\"'\\") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\t", `\t`, "\n", `\n`).Replace(arg) + `"`
+}
+
+// installSystemd writes a user unit running the daemon and enables it, so it
+// starts at login.
+func installSystemd(configPath string) error {
+	args, err := daemonArgs(configPath)
+	if err != nil {
+		return err
+	}
+	for i, arg := range args {
+		args[i] = systemdQuote(arg)
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "systemd", "user", "adhan.service")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = systemdUnit.Execute(f, struct{ ExecStart string }{strings.Join(args, " ")})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("Wrote", path)
+
+	if err := run("systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	return run("systemctl", "--user", "enable", "--now", "adhan.service")
+}
+
+// installLaunchd writes a LaunchAgent running the daemon, restarted by
+// launchd when it fails, and loads it.
+func installLaunchd(configPath string) error {
+	args, err := daemonArgs(configPath)
+	if err != nil {
+		return err
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = launchdPlist.Execute(f, struct {
+		Label string
+		Args  []string
+		Log   string
+	}{launchdLabel, args, filepath.Join(home, "Library", "Logs", "adhan.log")})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("Wrote", path)
+
+	// Unload any previous version first, it's fine if there was none.
+	exec.Command("launchctl", "unload", path).Run()
+	return run("launchctl", "load", "-w", path)
+}
+
+func run(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+var errNoInstallTarget = errors.New("choose what to install, --systemd or --launchd")
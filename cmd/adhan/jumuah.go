@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// JumuahConfig configures Jumu'ah, which replaces Dhuhr on Fridays.
+type JumuahConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Time is when the khutbah starts at the local mosque, as "13:30". Dhuhr
+	// time is used when it is empty.
+	Time string `toml:"time"`
+	// Reminder is how many minutes before Jumu'ah to remind to leave for the
+	// mosque, 0 to disable it.
+	Reminder int `toml:"reminder"`
+}
+
+// validate checks that the configured time is well formed.
+func (j JumuahConfig) validate() error {
+	if j.Time == "" {
+		return nil
+	}
+	if _, err := time.Parse("15:04", j.Time); err != nil {
+		return fmt.Errorf("invalid jumuah time %q, expected HH:MM", j.Time)
+	}
+	return nil
+}
+
+// isJumuah reports whether day is a Friday with Jumu'ah enabled.
+func (c Config) isJumuah(day time.Time) bool {
+	return c.Jumuah.Enabled && day.Weekday() == time.Friday
+}
+
+// withJumuah moves Dhuhr to the configured Jumu'ah time when day is a
+// Friday.
+func (c Config) withJumuah(d adhan.Day, day time.Time) adhan.Day {
+	if c.isJumuah(day) && c.Jumuah.Time != "" {
+		d.Timings.Dhuhr = c.Jumuah.Time
+	}
+	return d
+}
+
+// prayerName returns the name to show for p, Jumu'ah instead of Dhuhr on
+// Fridays.
+func (c Config) prayerName(p adhan.Prayer) string {
+	if p.Name == "Dhuhr" && c.isJumuah(p.Time) {
+		return "Jumu'ah"
+	}
+	return p.Name
+}
+
+// jumuahReminders reminds to leave for the mosque ahead of Jumu'ah, since
+// the khutbah starts before the prayer.
+func (s *scheduler) jumuahReminders(today adhan.Day, prayers []adhan.Prayer) []adhan.Reminder {
+	if s.cfg.Jumuah.Reminder <= 0 {
+		return nil
+	}
+
+	var reminders []adhan.Reminder
+	for _, p := range prayers {
+		if p.Name != "Dhuhr" || !s.cfg.isJumuah(p.Time) {
+			continue
+		}
+		reminders = append(reminders, adhan.Reminder{
+			Title:   s.cfg.tr("Jumu'ah"),
+			Message: s.cfg.tr("Jumu'ah is at %s, time to leave for the mosque.", s.cfg.formatTime(p.Time)),
+			Time:    p.Time.Add(-time.Duration(s.cfg.Jumuah.Reminder) * time.Minute),
+		})
+	}
+	return reminders
+}
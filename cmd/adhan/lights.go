@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// lightsTimeout bounds how long changing the lights waits for the bridge or
+// Home Assistant.
+const lightsTimeout = 10 * time.Second
+
+var lightsClient = &http.Client{Timeout: lightsTimeout}
+
+// LightsConfig configures flashing lights at prayer times, or changing their
+// colour for a while, as a visual adhan.
+type LightsConfig struct {
+	// Effect is "flash", the default, or "color" to turn the lights on in
+	// Color for Duration seconds, 60 by default, before putting them back as
+	// they were.
+	Effect   string `toml:"effect"`
+	Color    string `toml:"color"`
+	Duration int    `toml:"duration"`
+	// Prayers limits the lights to some prayers, all but Sunrise by default.
+	Prayers       []string           `toml:"prayers"`
+	Hue           HueConfig          `toml:"hue"`
+	HomeAssistant HomeAssistantLight `toml:"home_assistant"`
+}
+
+// HueConfig configures the lights of a Philips Hue bridge.
+type HueConfig struct {
+	// Bridge is the host name or IP address of the bridge, and Username the
+	// key of an app allowed to use it.
+	Bridge   string `toml:"bridge"`
+	Username string `toml:"username"`
+	// Lights are the IDs of the lights, as the bridge numbers them.
+	Lights []string `toml:"lights"`
+}
+
+// HomeAssistantLight configures lights of Home Assistant.
+type HomeAssistantLight struct {
+	// URL is the address of Home Assistant, e.g.
+	// http://homeassistant.local:8123, and Token a long-lived access token.
+	URL   string `toml:"url"`
+	Token string `toml:"token"`
+	// Entities are the lights, e.g. light.hallway.
+	Entities []string `toml:"entities"`
+}
+
+// enabled reports whether lights are configured at all.
+func (l LightsConfig) enabled() bool {
+	return len(l.Hue.Lights) > 0 || len(l.HomeAssistant.Entities) > 0
+}
+
+// validate checks the effect and colour.
+func (l LightsConfig) validate() error {
+	switch l.Effect {
+	case "", "flash", "color":
+	default:
+		return fmt.Errorf("invalid lights.effect %q, expected flash or color", l.Effect)
+	}
+	if _, err := l.rgb(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// rgb returns the colour the lights are turned, green by default.
+func (l LightsConfig) rgb() ([3]int, error) {
+	c := strings.TrimPrefix(l.Color, "#")
+	if c == "" {
+		return [3]int{0, 255, 0}, nil
+	}
+	n, err := strconv.ParseUint(c, 16, 32)
+	if err != nil || len(c) != 6 {
+		return [3]int{}, fmt.Errorf("invalid lights.color %q, expected a colour like #00ff00", l.Color)
+	}
+	return [3]int{int(n >> 16), int(n >> 8 & 0xff), int(n & 0xff)}, nil
+}
+
+func (l LightsConfig) duration() time.Duration {
+	if l.Duration <= 0 {
+		return time.Minute
+	}
+	return time.Duration(l.Duration) * time.Second
+}
+
+// shows reports whether the lights are changed for prayer.
+func (l LightsConfig) shows(prayer string) bool {
+	if !l.enabled() {
+		return false
+	}
+	if len(l.Prayers) > 0 {
+		return containsPrayer(l.Prayers, prayer)
+	}
+	return prayer != "Sunrise"
+}
+
+// lightGroup is a set of lights of one system.
+type lightGroup interface {
+	flash(ctx context.Context) error
+	// color turns the lights on in rgb, and returns how to put them back as
+	// they were.
+	color(ctx context.Context, rgb [3]int) (restore func(ctx context.Context) error, err error)
+}
+
+// groups returns the lights configured, by system.
+func (l LightsConfig) groups() []lightGroup {
+	var groups []lightGroup
+	if len(l.Hue.Lights) > 0 {
+		groups = append(groups, hueLights{cfg: l.Hue})
+	}
+	if len(l.HomeAssistant.Entities) > 0 {
+		groups = append(groups, haLights{cfg: l.HomeAssistant})
+	}
+	return groups
+}
+
+// changeLights flashes the lights or changes their colour, as configured,
+// and returns how to put back those whose colour changed.
+func changeLights(ctx context.Context, cfg LightsConfig) ([]func(ctx context.Context) error, error) {
+	ctx, cancel := context.WithTimeout(ctx, lightsTimeout)
+	defer cancel()
+
+	rgb, err := cfg.rgb()
+	if err != nil {
+		return nil, err
+	}
+	var restores []func(ctx context.Context) error
+	var errs []error
+	for _, g := range cfg.groups() {
+		if cfg.Effect != "color" {
+			errs = append(errs, g.flash(ctx))
+			continue
+		}
+		// Some lights may have changed even if others failed to.
+		restore, err := g.color(ctx, rgb)
+		if restore != nil {
+			restores = append(restores, restore)
+		}
+		errs = append(errs, err)
+	}
+	return restores, errors.Join(errs...)
+}
+
+// restoreLights puts the lights back as they were before changeLights.
+func restoreLights(restores []func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), lightsTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, restore := range restores {
+		errs = append(errs, restore(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// showPrayerLights changes the lights for prayer in the background, if
+// configured, and puts them back once the effect is over.
+func (s *scheduler) showPrayerLights(prayer adhan.Prayer) {
+	cfg := s.cfg.Lights
+	if !cfg.shows(prayer.Name) {
+		return
+	}
+	go func() {
+		slog.Debug("Changing the lights", "prayer", prayer.Name, "effect", cfg.Effect)
+		restores, err := changeLights(context.Background(), cfg)
+		if err != nil {
+			slog.Error("Failed to change the lights", "err", err)
+		}
+		if len(restores) == 0 {
+			return
+		}
+		time.Sleep(cfg.duration())
+		if err := restoreLights(restores); err != nil {
+			slog.Error("Failed to put the lights back", "err", err)
+		}
+	}()
+}
+
+// lightsRequest sends body, encoded as JSON if not nil, and decodes the
+// response into out if not nil.
+func lightsRequest(ctx context.Context, method, url, token string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "adhan")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := lightsClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Hue bridges take their key in the path, which is kept out of errors.
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", req.URL.Host, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// hueLights are lights of a Hue bridge, through its REST API.
+type hueLights struct {
+	cfg HueConfig
+}
+
+// hueState is the part of the state of a Hue light adhan changes.
+type hueState struct {
+	On        bool      `json:"on"`
+	Bri       int       `json:"bri,omitempty"`
+	XY        []float64 `json:"xy,omitempty"`
+	CT        int       `json:"ct,omitempty"`
+	Hue       *int      `json:"hue,omitempty"`
+	Sat       *int      `json:"sat,omitempty"`
+	ColorMode string    `json:"colormode,omitempty"`
+}
+
+// set changes the state of light.
+func (h hueLights) set(ctx context.Context, light string, state any) error {
+	var raw json.RawMessage
+	url := "http://" + h.cfg.Bridge + "/api/" + h.cfg.Username + "/lights/" + light + "/state"
+	if err := lightsRequest(ctx, http.MethodPut, url, "", state, &raw); err != nil {
+		return err
+	}
+	return hueError(light, raw)
+}
+
+// hueError returns the first error of the response of a Hue bridge about
+// light, which come as a list of results.
+func hueError(light string, raw []byte) error {
+	var results []struct {
+		Error *struct {
+			Description string `json:"description"`
+		} `json:"error"`
+	}
+	// Successful reads are objects rather than lists.
+	if json.Unmarshal(raw, &results) != nil {
+		return nil
+	}
+	for _, r := range results {
+		if r.Error != nil {
+			return fmt.Errorf("Hue light %s: %s", light, r.Error.Description)
+		}
+	}
+	return nil
+}
+
+func (h hueLights) flash(ctx context.Context) error {
+	var errs []error
+	for _, light := range h.cfg.Lights {
+		// lselect flashes for 15 seconds.
+		errs = append(errs, h.set(ctx, light, map[string]any{"alert": "lselect"}))
+	}
+	return errors.Join(errs...)
+}
+
+func (h hueLights) color(ctx context.Context, rgb [3]int) (func(ctx context.Context) error, error) {
+	saved := make(map[string]hueState)
+	for _, light := range h.cfg.Lights {
+		var raw json.RawMessage
+		url := "http://" + h.cfg.Bridge + "/api/" + h.cfg.Username + "/lights/" + light
+		if err := lightsRequest(ctx, http.MethodGet, url, "", nil, &raw); err != nil {
+			return nil, err
+		}
+		if err := hueError(light, raw); err != nil {
+			return nil, err
+		}
+		var l struct {
+			State hueState `json:"state"`
+		}
+		if err := json.Unmarshal(raw, &l); err != nil {
+			return nil, err
+		}
+		saved[light] = l.State
+	}
+
+	x, y := rgbToXY(rgb)
+	var errs []error
+	for _, light := range h.cfg.Lights {
+		errs = append(errs, h.set(ctx, light, map[string]any{"on": true, "bri": 254, "xy": []float64{x, y}}))
+	}
+	restore := func(ctx context.Context) error {
+		var errs []error
+		for light, s := range saved {
+			// Lights that were off get their colour back first, which
+			// they only take while on.
+			state := map[string]any{"on": true}
+			if s.Bri > 0 {
+				state["bri"] = s.Bri
+			}
+			switch s.ColorMode {
+			case "xy":
+				state["xy"] = s.XY
+			case "ct":
+				state["ct"] = s.CT
+			case "hs":
+				state["hue"], state["sat"] = s.Hue, s.Sat
+			}
+			err := h.set(ctx, light, state)
+			if err == nil && !s.On {
+				err = h.set(ctx, light, map[string]any{"on": false})
+			}
+			errs = append(errs, err)
+		}
+		return errors.Join(errs...)
+	}
+	return restore, errors.Join(errs...)
+}
+
+// rgbToXY converts an sRGB colour to the CIE xy coordinates Hue lights take.
+func rgbToXY(rgb [3]int) (x, y float64) {
+	var c [3]float64
+	for i, v := range rgb {
+		f := float64(v) / 255
+		if f > 0.04045 {
+			f = math.Pow((f+0.055)/1.055, 2.4)
+		} else {
+			f /= 12.92
+		}
+		c[i] = f
+	}
+	X := c[0]*0.664511 + c[1]*0.154324 + c[2]*0.162028
+	Y := c[0]*0.283881 + c[1]*0.668433 + c[2]*0.047685
+	Z := c[0]*0.000088 + c[1]*0.072310 + c[2]*0.986039
+	if X+Y+Z == 0 {
+		// White, rather than nothing.
+		return 0.3127, 0.3290
+	}
+	return X / (X + Y + Z), Y / (X + Y + Z)
+}
+
+// haLights are lights of Home Assistant, through its REST API.
+type haLights struct {
+	cfg HomeAssistantLight
+}
+
+// call calls service of Home Assistant, as light/turn_on, with data.
+func (h haLights) call(ctx context.Context, service string, data map[string]any) error {
+	url := strings.TrimSuffix(h.cfg.URL, "/") + "/api/services/" + service
+	return lightsRequest(ctx, http.MethodPost, url, h.cfg.Token, data, nil)
+}
+
+func (h haLights) flash(ctx context.Context) error {
+	return h.call(ctx, "light/turn_on", map[string]any{"entity_id": h.cfg.Entities, "flash": "long"})
+}
+
+func (h haLights) color(ctx context.Context, rgb [3]int) (func(ctx context.Context) error, error) {
+	// A scene snapshots the lights, turning it on puts them back.
+	if err := h.call(ctx, "scene/create", map[string]any{"scene_id": "adhan_lights", "snapshot_entities": h.cfg.Entities}); err != nil {
+		return nil, fmt.Errorf("failed to save the state of the lights: %w", err)
+	}
+	restore := func(ctx context.Context) error {
+		return h.call(ctx, "scene/turn_on", map[string]any{"entity_id": "scene.adhan_lights"})
+	}
+	err := h.call(ctx, "light/turn_on", map[string]any{"entity_id": h.cfg.Entities, "rgb_color": rgb, "brightness": 255})
+	return restore, err
+}
+
+// testLights changes the lights as at prayer times, and puts them back once
+// the effect is over or Ctrl-C is pressed.
+func testLights(ctx context.Context, cfg LightsConfig) error {
+	if !cfg.enabled() {
+		return errors.New("set lights.hue.lights or lights.home_assistant.entities to the lights to change")
+	}
+	restores, err := changeLights(ctx, cfg)
+	if len(restores) == 0 {
+		if err == nil {
+			fmt.Println("Flashed the lights")
+		}
+		return err
+	}
+
+	fmt.Printf("Changed the colour of the lights, putting them back in %s\n", cfg.duration())
+	select {
+	case <-ctx.Done():
+	case <-time.After(cfg.duration()):
+	}
+	return errors.Join(err, restoreLights(restores))
+}
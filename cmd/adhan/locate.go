@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"iustusae/adhan/internal/geo"
+)
+
+const (
+	geolocationURL = "https://ipapi.co/json/"
+	// geolocationTimeout bounds how long resolving the location waits for
+	// the geolocation service.
+	geolocationTimeout = 10 * time.Second
+
+	// locationTTL is how long a resolved location is reused before asking
+	// the geolocation service again.
+	locationTTL = 24 * time.Hour
+)
+
+var geolocationClient = &http.Client{Timeout: geolocationTimeout}
+
+// Location is an approximate location resolved from the public IP address.
+type Location struct {
+	City       string    `json:"city"`
+	Country    string    `json:"country_name"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	Timezone   string    `json:"timezone"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+func locationPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "adhan", "location.json"), nil
+}
+
+// autoLocate returns the approximate location of the machine, from the cache
+// when it was resolved less than locationTTL ago.
+func autoLocate(ctx context.Context) (Location, error) {
+	path, err := locationPath()
+	if err != nil {
+		return Location{}, err
+	}
+
+	if raw, err := os.ReadFile(path); err == nil {
+		var cached Location
+		if json.Unmarshal(raw, &cached) == nil && time.Since(cached.ResolvedAt) < locationTTL {
+			return cached, nil
+		}
+	}
+
+	location, err := resolveLocation(ctx)
+	if err != nil {
+		return Location{}, err
+	}
+	if err := saveLocation(location); err != nil {
+		return Location{}, err
+	}
+
+	return location, nil
+}
+
+// saveLocation caches location for autoLocate.
+func saveLocation(location Location) error {
+	path, err := locationPath()
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(location)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0o644)
+}
+
+// resolveLocation asks the geolocation service where the public IP address
+// is.
+func resolveLocation(ctx context.Context) (Location, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, geolocationURL, nil)
+	if err != nil {
+		return Location{}, err
+	}
+	req.Header.Set("User-Agent", "adhan")
+	resp, err := geolocationClient.Do(req)
+	if err != nil {
+		return Location{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Location{}, fmt.Errorf("geolocation request failed: %s", resp.Status)
+	}
+
+	var location Location
+	if err := json.NewDecoder(resp.Body).Decode(&location); err != nil {
+		return Location{}, err
+	}
+	location.ResolvedAt = time.Now()
+
+	return location, nil
+}
+
+// cityDBPath returns where the city database downloaded with adhan cities
+// update is kept, usually ~/.cache/adhan/cities.tsv.
+func cityDBPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "adhan", "cities.tsv"), nil
+}
+
+// geocoded returns c with the coordinates of its city looked up in the
+// offline city database when it has none, along with its time zone if none is
+// configured, so that its prayer times can be calculated locally. It reports
+// whether c has coordinates.
+func (c Config) geocoded() (Config, bool) {
+	if c.hasCoordinates() {
+		return c, true
+	}
+	if c.City == "" {
+		return c, false
+	}
+
+	path, _ := cityDBPath()
+	city, ok, err := geo.Lookup(path, c.City, c.Country)
+	if err != nil {
+		slog.Error("Failed to read city database", "err", err)
+	}
+	if !ok {
+		return c, false
+	}
+
+	c.Latitude, c.Longitude = city.Latitude, city.Longitude
+	if c.Timezone == "" {
+		c.Timezone = city.Timezone
+	}
+	return c, true
+}
+
+// updateCityDB downloads the city database.
+func updateCityDB(ctx context.Context) error {
+	path, err := cityDBPath()
+	if err != nil {
+		return err
+	}
+	n, err := geo.Download(ctx, path)
+	if err != nil {
+		return fmt.Errorf("failed to download city database: %w", err)
+	}
+	fmt.Printf("Downloaded %d cities to %s\n", n, path)
+	return nil
+}
+
+// apply sets the location of cfg to l.
+func (l Location) apply(cfg *Config) {
+	cfg.City = l.City
+	cfg.Country = l.Country
+	cfg.Latitude = l.Latitude
+	cfg.Longitude = l.Longitude
+	cfg.Timezone = l.Timezone
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// maxLogSize is how large the log file grows before it is rotated, keeping
+// a single older file next to it.
+const maxLogSize = 5 << 20
+
+// LogConfig configures what adhan logs and where, stderr by default.
+type LogConfig struct {
+	// Level is debug, info, warn or error. Info by default.
+	Level string `toml:"level"`
+	// Format is text or json.
+	Format string `toml:"format"`
+	// File is where to log instead of stderr, "default" for adhan.log under
+	// the state directory.
+	File string `toml:"file"`
+}
+
+// level parses the configured level.
+func (c LogConfig) level() (slog.Level, error) {
+	var level slog.Level
+	if c.Level == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		return level, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", c.Level)
+	}
+	return level, nil
+}
+
+// logFile is the file logged to, if any, closed when logging is set up
+// again.
+var logFile *rotatingFile
+
+// setupLogging makes the default logger follow cfg.
+func setupLogging(cfg LogConfig) error {
+	level, err := cfg.level()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stderr
+	var file *rotatingFile
+	if cfg.File != "" {
+		path := cfg.File
+		if path == "default" {
+			dir, err := stateDir()
+			if err != nil {
+				return fmt.Errorf("failed to locate log file: %w", err)
+			}
+			path = filepath.Join(dir, "adhan.log")
+		}
+		if file, err = openRotatingFile(path); err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		w = file
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown log format %q, expected text or json", cfg.Format)
+	}
+	slog.SetDefault(slog.New(handler))
+
+	if logFile != nil {
+		logFile.Close()
+	}
+	logFile = file
+	return nil
+}
+
+// stateDir returns where adhan keeps its logs: $XDG_STATE_HOME/adhan, or
+// ~/.local/state/adhan on Unix and the cache directory elsewhere.
+func stateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "adhan"), nil
+	}
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "adhan"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "adhan"), nil
+}
+
+// rotatingFile appends to a file, moving it to path.1 once it grows past
+// maxLogSize.
+type rotatingFile struct {
+	path string
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+func openRotatingFile(path string) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	r := &rotatingFile{path: path}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.f.Close()
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size+int64(len(p)) > maxLogSize && r.size > 0 {
+		r.f.Close()
+		if err := os.Rename(r.path, r.path+".1"); err != nil {
+			return 0, err
+		}
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
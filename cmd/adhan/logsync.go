@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// logSyncFile is the name of the prayer log in git repositories and gists.
+const logSyncFile = "prayers.json"
+
+// logSyncedKey keys the prayer log as last synced in the state bucket, the
+// base that tells additions from removals when merging.
+var logSyncedKey = []byte("log-synced")
+
+var gistClient = &http.Client{Timeout: 30 * time.Second}
+
+// LogSyncConfig configures syncing the prayer log across devices, through a
+// git repository or a GitHub gist.
+type LogSyncConfig struct {
+	// Git is the remote of a git repository to keep the log in, e.g.
+	// git@github.com:me/prayers.git.
+	Git string `toml:"git"`
+	// Gist is the ID of a GitHub gist to keep the log in, and Token a GitHub
+	// token allowed to edit gists.
+	Gist  string `toml:"gist"`
+	Token string `toml:"token"`
+}
+
+// logRemote is where the prayer log is synced to.
+type logRemote interface {
+	// read returns the log of the remote, nil if it has none yet.
+	read(ctx context.Context) (*prayerLog, error)
+	write(ctx context.Context, l *prayerLog) error
+}
+
+// syncLog merges the prayer log with that of the remote configured, and
+// writes the result to both.
+func syncLog(ctx context.Context, cfg LogSyncConfig) error {
+	var remote logRemote
+	switch {
+	case cfg.Git != "":
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return err
+		}
+		remote = gitLog{remote: cfg.Git, dir: filepath.Join(dir, "adhan", "log-git")}
+	case cfg.Gist != "":
+		if cfg.Token == "" {
+			return errors.New("set sync.log.token to a GitHub token allowed to edit gists")
+		}
+		remote = gistLog{id: cfg.Gist, token: cfg.Token}
+	default:
+		return errors.New("set sync.log.git to the remote of a git repository, or sync.log.gist to the ID of a gist")
+	}
+
+	theirs, err := remote.read(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the remote prayer log: %w", err)
+	}
+
+	var merged *prayerLog
+	err = updateStore(func(tx *bolt.Tx) error {
+		ours, base := &prayerLog{}, &prayerLog{}
+		if _, err := getJSON(tx, stateBucket, logKey, ours); err != nil {
+			return err
+		}
+		if _, err := getJSON(tx, stateBucket, logSyncedKey, base); err != nil {
+			return err
+		}
+		if theirs == nil {
+			theirs = base
+		}
+		merged = mergeLogs(base, ours, theirs)
+		return putJSON(tx, stateBucket, logKey, merged)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := remote.write(ctx, merged); err != nil {
+		return fmt.Errorf("failed to write the remote prayer log: %w", err)
+	}
+	// Only now is the remote up to date, what it lacked before still counts
+	// as added on this device otherwise.
+	err = updateStore(func(tx *bolt.Tx) error {
+		return putJSON(tx, stateBucket, logSyncedKey, merged)
+	})
+	if err != nil {
+		return err
+	}
+
+	prayed := 0
+	for _, prayers := range merged.Days {
+		prayed += len(prayers)
+	}
+	fmt.Printf("Synced the prayer log: %d prayers prayed, %d missed\n", prayed, len(merged.Missed))
+	return nil
+}
+
+// logEntries returns the prayers of the log as a set, prayed and missed
+// ones told apart, e.g. "prayed 2024-01-05 Fajr".
+func logEntries(l *prayerLog) map[string]bool {
+	entries := make(map[string]bool)
+	for date, prayers := range l.Days {
+		for _, p := range prayers {
+			entries["prayed "+date+" "+p] = true
+		}
+	}
+	for _, m := range l.Missed {
+		entries["missed "+m.Date+" "+m.Prayer] = true
+	}
+	return entries
+}
+
+// mergeLogs merges the logs ours and theirs, both changed since base: it
+// keeps the prayers in both, and those added to either, so that marking a
+// prayer or making it up on any device counts.
+func mergeLogs(base, ours, theirs *prayerLog) *prayerLog {
+	b, o, t := logEntries(base), logEntries(ours), logEntries(theirs)
+	var entries []string
+	for e := range o {
+		if t[e] || !b[e] {
+			entries = append(entries, e)
+		}
+	}
+	for e := range t {
+		if !o[e] && !b[e] {
+			entries = append(entries, e)
+		}
+	}
+	sort.Strings(entries)
+
+	merged := &prayerLog{Days: make(map[string][]string)}
+	for _, e := range entries {
+		kind, rest, _ := strings.Cut(e, " ")
+		date, prayer, _ := strings.Cut(rest, " ")
+		if kind == "prayed" {
+			merged.Days[date] = append(merged.Days[date], prayer)
+		} else {
+			merged.Missed = append(merged.Missed, missedPrayer{Date: date, Prayer: prayer})
+		}
+	}
+	// A prayer marked on one device is no longer missed on the others.
+	for i := 0; i < len(merged.Missed); i++ {
+		m := merged.Missed[i]
+		if day, err := time.Parse(logDateLayout, m.Date); err == nil && merged.prayed(day, m.Prayer) {
+			merged.Missed = append(merged.Missed[:i], merged.Missed[i+1:]...)
+			i--
+		}
+	}
+	return merged
+}
+
+// encodeLog returns the log as kept in remotes.
+func encodeLog(l *prayerLog) ([]byte, error) {
+	raw, err := json.MarshalIndent(l, "", "  ")
+	return append(raw, '\n'), err
+}
+
+// gitLog keeps the prayer log in a git repository, through a clone in dir.
+type gitLog struct {
+	remote string
+	dir    string
+}
+
+// git runs git in the clone and returns its output.
+func (g gitLog) git(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", g.dir}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (g gitLog) read(ctx context.Context) (*prayerLog, error) {
+	if _, err := os.Stat(filepath.Join(g.dir, ".git")); errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(g.dir, 0o755); err != nil {
+			return nil, err
+		}
+		// Cloning an empty repository works too, the log is added then.
+		if _, err := g.git(ctx, "clone", "--quiet", g.remote, "."); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := g.git(ctx, "fetch", "--quiet", "origin"); err != nil {
+		return nil, err
+	}
+	branch, err := g.git(ctx, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := g.git(ctx, "rev-parse", "--verify", "--quiet", "origin/"+branch); err == nil {
+		if _, err := g.git(ctx, "reset", "--quiet", "--hard", "origin/"+branch); err != nil {
+			return nil, err
+		}
+	}
+
+	raw, err := os.ReadFile(filepath.Join(g.dir, logSyncFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	l := &prayerLog{}
+	if err := json.Unmarshal(raw, l); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", logSyncFile, err)
+	}
+	return l, nil
+}
+
+func (g gitLog) write(ctx context.Context, l *prayerLog) error {
+	raw, err := encodeLog(l)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(g.dir, logSyncFile), raw, 0o644); err != nil {
+		return err
+	}
+	if _, err := g.git(ctx, "add", logSyncFile); err != nil {
+		return err
+	}
+	if status, err := g.git(ctx, "status", "--porcelain"); err != nil || status == "" {
+		return err
+	}
+
+	// Commits are made as adhan on this device, whoever git is set up for.
+	host, _ := os.Hostname()
+	if _, err := g.git(ctx, "-c", "user.name=adhan", "-c", "user.email=adhan@"+host, "commit", "--quiet", "-m", "Update prayer log"); err != nil {
+		return err
+	}
+	_, err = g.git(ctx, "push", "--quiet", "origin", "HEAD")
+	return err
+}
+
+// gistLog keeps the prayer log in a file of a GitHub gist.
+type gistLog struct {
+	id    string
+	token string
+}
+
+// gistFile is a file of a gist, Content being cut if Truncated.
+type gistFile struct {
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated,omitempty"`
+	RawURL    string `json:"raw_url,omitempty"`
+}
+
+// do sends a request to the GitHub API, with body encoded as JSON if not
+// nil, and returns the body of the response.
+func (g gistLog) do(ctx context.Context, method, url string, body any) ([]byte, error) {
+	var r io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+	req.Header.Set("User-Agent", "adhan")
+
+	resp, err := gistClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("GitHub: %s", resp.Status)
+	}
+	return raw, nil
+}
+
+func (g gistLog) read(ctx context.Context) (*prayerLog, error) {
+	raw, err := g.do(ctx, http.MethodGet, "https://api.github.com/gists/"+g.id, nil)
+	if err != nil {
+		return nil, err
+	}
+	var gist struct {
+		Files map[string]gistFile `json:"files"`
+	}
+	if err := json.Unmarshal(raw, &gist); err != nil {
+		return nil, err
+	}
+	f, ok := gist.Files[logSyncFile]
+	if !ok {
+		return nil, nil
+	}
+	content := []byte(f.Content)
+	// Large files only come in full from their raw address.
+	if f.Truncated {
+		if content, err = g.do(ctx, http.MethodGet, f.RawURL, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	l := &prayerLog{}
+	if err := json.Unmarshal(content, l); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", logSyncFile, err)
+	}
+	return l, nil
+}
+
+func (g gistLog) write(ctx context.Context, l *prayerLog) error {
+	raw, err := encodeLog(l)
+	if err != nil {
+		return err
+	}
+	body := map[string]any{"files": map[string]gistFile{logSyncFile: {Content: string(raw)}}}
+	_, err = g.do(ctx, http.MethodPatch, "https://api.github.com/gists/"+g.id, body)
+	return err
+}
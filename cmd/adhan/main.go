@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+
+	"iustusae/adhan/internal/control"
+	"iustusae/adhan/pkg/adhan"
+)
+
+// getToday returns today's timings and date, see getDay. Today is that of
+// the location the timings are for, which may not be that of this machine
+// when no timezone is configured.
+func getToday(ctx context.Context, cfg Config) (adhan.Day, error) {
+	loc, err := cfg.location()
+	if err != nil {
+		return adhan.Day{}, err
+	}
+
+	now := clock.Now().In(loc)
+	day, err := getDay(ctx, cfg, now)
+	if err != nil {
+		return day, err
+	}
+	if zone := day.Location(); zone != nil {
+		if there := now.In(zone); there.Day() != now.Day() {
+			return getDay(ctx, cfg, there)
+		}
+	}
+	return day, nil
+}
+
+// getDay returns the timings and date of day, with Dhuhr moved to the
+// Jumu'ah time on Fridays.
+func getDay(ctx context.Context, cfg Config, day time.Time) (adhan.Day, error) {
+	d, err := fetchDay(ctx, cfg, day)
+	if err != nil {
+		return d, err
+	}
+	return cfg.withJumuah(d, day), nil
+}
+
+// fetchDay returns the timings and date of day from the monthly calendar,
+// falling back to local calculation when the API can't be reached and the
+// coordinates are known, and to stale cached timings otherwise.
+func fetchDay(ctx context.Context, cfg Config, day time.Time) (adhan.Day, error) {
+	days, err := loadCalendar(ctx, cfg, day.Year(), day.Month())
+	if err == nil && day.Day() > len(days) {
+		err = fmt.Errorf("calendar has no timings for day %d", day.Day())
+	}
+	if err == nil {
+		return days[day.Day()-1], nil
+	}
+	if ctx.Err() != nil {
+		return adhan.Day{}, err
+	}
+
+	if located, ok := cfg.geocoded(); ok {
+		slog.Warn("Failed to fetch prayer times, calculating them locally", "err", err)
+		return calculateDay(ctx, located, day)
+	}
+
+	stale, ok := loadStaleDay(day)
+	if !ok {
+		return adhan.Day{}, err
+	}
+
+	slog.Warn("Failed to fetch prayer times, using stale cached timings, they may be a few minutes off", "err", err)
+	return stale, nil
+}
+
+// nextPrayer returns the first prayer after now, with tomorrow's Fajr once
+// Isha has passed.
+func nextPrayer(ctx context.Context, cfg Config, today adhan.Day, now time.Time) (adhan.Prayer, error) {
+	return adhan.NextPrayerAcross(today, now, func(date time.Time) (adhan.Day, error) {
+		return getDay(ctx, cfg, date)
+	})
+}
+
+// calculateDay computes the timings of day locally.
+func calculateDay(ctx context.Context, cfg Config, day time.Time) (adhan.Day, error) {
+	query, err := cfg.query()
+	if err != nil {
+		return adhan.Day{}, err
+	}
+
+	days, err := adhan.Calculator{}.Calendar(ctx, query, day.Year(), day.Month())
+	if err != nil {
+		return adhan.Day{}, err
+	}
+	return days[day.Day()-1], nil
+}
+
+func printTable(header []string, data [][]string) {
+	renderTable(os.Stdout, header, data)
+}
+
+func renderTable(w io.Writer, header []string, data [][]string) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(isolateRTL(header))
+	table.SetAlignment(tablewriter.ALIGN_CENTER)
+
+	for _, row := range data {
+		table.Append(isolateRTL(row))
+	}
+
+	table.Render()
+}
+
+func printDate(date adhan.Date) {
+	if date.Readable == "" {
+		return
+	}
+
+	g := date.Gregorian
+	fmt.Printf("Gregorian: %s %s %s %s\n", g.Weekday.En, g.Day, g.Month.En, g.Year)
+
+	h := date.Hijri
+	fmt.Printf("Hijri:     %s %s\n", h.Weekday.En, date.HijriString())
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printNext(ctx context.Context, cfg Config, loc *time.Location, asJSON bool) error {
+	today, err := getToday(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to fetch prayer times: %w", err)
+	}
+
+	now := clock.Now().In(loc)
+	next, err := nextPrayer(ctx, cfg, today, now)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		return printJSON(next)
+	}
+
+	fmt.Println(cfg.tr("Next prayer: %s, Time: %s", cfg.localName(next), cfg.formatTime(next.Time)))
+	if cfg.inRamadan(today) {
+		printIftar(cfg, today, now)
+	}
+	return nil
+}
+
+// printAll prints the timings of date, or of today when it is zero.
+func printAll(ctx context.Context, cfg Config, date time.Time, asJSON bool) error {
+	loc, err := cfg.location()
+	if err != nil {
+		return err
+	}
+
+	var day adhan.Day
+	if date.IsZero() {
+		date = clock.Now().In(loc)
+		day, err = getToday(ctx, cfg)
+	} else {
+		day, err = getDay(ctx, cfg, date)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch prayer times: %w", err)
+	}
+
+	if asJSON {
+		return printJSON(newTodayJSON(day))
+	}
+	if len(cfg.Cities) > 0 {
+		return printCities(ctx, cfg, day, date)
+	}
+
+	printDate(day.Date)
+	data := append(timingsTable(cfg, day, date), nightRows(ctx, cfg, day, date)...)
+	printTable([]string{cfg.tr("Prayer"), cfg.tr("Time")}, data)
+	printEvents(ctx, cfg, date)
+	return nil
+}
+
+// timingsTable lays out the timings of today with one row per prayer.
+func timingsTable(cfg Config, today adhan.Day, now time.Time) [][]string {
+	timings := cfg.formatTimings(today.Timings)
+	data := [][]string{
+		{cfg.nameOf("Fajr"), timings[0]},
+		{cfg.nameOf("Sunrise"), timings[1]},
+		{cfg.nameOf("Dhuhr"), timings[2]},
+		{cfg.nameOf("Asr"), timings[3]},
+		{cfg.nameOf("Maghrib"), timings[4]},
+		{cfg.nameOf("Isha"), timings[5]},
+	}
+	if cfg.inRamadan(today) {
+		// Highlight when to stop eating and when to break the fast.
+		data = append([][]string{{cfg.nameOf("Imsak"), cfg.formatTiming(today.Timings.Imsak)}}, data...)
+		data[5][0] = cfg.nameOf("Maghrib (Iftar)")
+	}
+	if cfg.isJumuah(now) {
+		data[len(data)-4][0] = cfg.nameOf("Jumu'ah")
+	}
+	if duha, ok := cfg.duhaRow(today, now); ok {
+		// Right after sunrise.
+		i := len(data) - 4
+		data = append(data[:i], append([][]string{duha}, data[i:]...)...)
+	}
+	return data
+}
+
+func printRemaining(ctx context.Context, cfg Config, loc *time.Location, watch bool) error {
+	today, err := getToday(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to fetch prayer times: %w", err)
+	}
+
+	if watch {
+		return watchCountdown(ctx, cfg, today, loc)
+	}
+
+	now := clock.Now().In(loc)
+	next, err := nextPrayer(ctx, cfg, today, now)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(cfg.tr("%s in %s", cfg.localName(next), formatCountdown(next.Time.Sub(now), false)))
+	return nil
+}
+
+func printToday(ctx context.Context, cfg Config) error {
+	today, err := getToday(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to fetch prayer times: %w", err)
+	}
+
+	printDate(today.Date)
+	return nil
+}
+
+// announce lets the user know adhan is running and which prayer is next.
+func announce(ctx context.Context, cfg Config, loc *time.Location, notifier Notifier) {
+	notify(notifier, Notification{Title: "Adhan", Message: cfg.tr("Adhan app is active!"), Urgency: UrgencyLow})
+	select {
+	case <-time.After(3 * time.Second):
+	case <-ctx.Done():
+		return
+	}
+	today, err := getToday(ctx, cfg)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("Failed to fetch prayer times", "err", err)
+		}
+		return
+	}
+	now := clock.Now().In(loc)
+	if next, err := nextPrayer(ctx, cfg, today, now); err == nil {
+		message := cfg.tr("Next Prayer is : %s at: %s", cfg.localName(next), cfg.formatTime(next.Time))
+		if hijri := today.Date.HijriString(); hijri != "" {
+			message += " (" + hijri + ")"
+		}
+		if cfg.Verse.Enabled {
+			if verse, err := cfg.verseOfDay(now); err != nil {
+				slog.Error("Failed to pick the verse of the day", "err", err)
+			} else {
+				message += "\n\n" + verse.String()
+			}
+		}
+		notify(notifier, Notification{Title: "Adhan", Message: message, Urgency: UrgencyLow})
+	}
+}
+
+// lockInstance makes sure a single instance notifies, see control.Listen.
+// Simulations run alongside it, without a control socket.
+func lockInstance() (*control.Listener, error) {
+	if simulated() {
+		return nil, nil
+	}
+	return control.Listen()
+}
+
+// runInteractive is what adhan does without a subcommand: the scheduler runs
+// in the background while commands are read from stdin, until the user quits
+// or ctx is done. When adhan is already running elsewhere, only the prompt
+// runs, and commands about the adhan are sent to the running instance.
+func runInteractive(ctx context.Context, cfg Config, loc *time.Location) {
+	inst, err := lockInstance()
+	if errors.Is(err, control.ErrAlreadyRunning) {
+		slog.Info("Leaving the notifications to the running instance", "reason", err)
+		handleUserInput(ctx, cfg, loc, control.Send)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to lock instance", "err", err)
+	}
+	defer inst.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	notifier := newNotifier(cfg.Notifications)
+	announce(ctx, cfg, loc, notifier)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	player := &audioPlayer{}
+	s := newScheduler(cfg, loc, notifier, player)
+	go s.run(ctx, &wg)
+	go inst.Serve(ctx, s.control)
+	go func() {
+		handleUserInput(ctx, cfg, loc, s.control)
+		cancel()
+	}()
+
+	<-ctx.Done()
+	player.stop()
+	closeCastServer()
+	wg.Wait()
+}
+
+// runDaemon runs the scheduler in the foreground, without reading stdin,
+// until ctx is done. The scheduler is restarted with the new config whenever
+// r reloads it, if r is not nil, which travel mode also has it do when the
+// location changes.
+func runDaemon(ctx context.Context, cfg Config, loc *time.Location, r *reloader) error {
+	inst, err := lockInstance()
+	if err != nil {
+		return err
+	}
+	defer inst.Close()
+
+	notifier := newNotifier(cfg.Notifications)
+	announce(ctx, cfg, loc, notifier)
+
+	player := &audioPlayer{}
+	var current atomic.Pointer[scheduler]
+	requests := make(chan chan error)
+	go inst.Serve(ctx, func(ctx context.Context, command string) (string, error) {
+		if command != "reload" {
+			// Commands may come in before the first scheduler is up.
+			s := current.Load()
+			if s == nil {
+				return "", errors.New("daemon not ready, try again")
+			}
+			return s.control(ctx, command)
+		}
+		if r == nil {
+			return "", errors.New("this instance can't reload its config")
+		}
+
+		reply := make(chan error, 1)
+		select {
+		case requests <- reply:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		if err := <-reply; err != nil {
+			return "", fmt.Errorf("failed to reload config: %w", err)
+		}
+		return "Reloaded config", nil
+	})
+
+	changes := r.watch(ctx)
+	for {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		sctx, cancel := context.WithCancel(ctx)
+		s := newScheduler(cfg, loc, notifier, player)
+		current.Store(s)
+		go s.run(sctx, &wg)
+
+		// Travel mode needs r to load the config with the new location.
+		var moved <-chan struct{}
+		if r != nil {
+			moved = travel(sctx, cfg)
+		}
+
+		prev := cfg
+		for reloaded := false; !reloaded; {
+			var reply chan error
+			select {
+			case <-ctx.Done():
+				cancel()
+				player.stop()
+				closeCastServer()
+				wg.Wait()
+				return nil
+			case <-changes:
+			case <-moved:
+			case reply = <-requests:
+			}
+
+			next, nextLoc, err := r.reload()
+			if reply != nil {
+				reply <- err
+			}
+			if err != nil {
+				slog.Warn("Failed to reload config, keeping the previous one", "err", err)
+				continue
+			}
+			cfg, loc, reloaded = next, nextLoc, true
+		}
+
+		cancel()
+		wg.Wait()
+		notifier = newNotifier(cfg.Notifications)
+		slog.Info("Reloaded config")
+		if change := locationChange(prev, cfg); change != "" {
+			showNotification(notifier, cfg.tr("Location changed"), change)
+		}
+	}
+}
+
+func main() {
+	// Ctrl-C is handled per command, see newRootCommand.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	err := newRootCommand().ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// MakruhConfig configures the times in which voluntary (nafl) prayers are
+// disliked (makruh): while the sun rises, at its zenith and while it sets.
+type MakruhConfig struct {
+	// Warn makes adhan now warn during them.
+	Warn bool `toml:"warn"`
+	// Zenith is how many minutes before Dhuhr the sun is held to be at its
+	// zenith, 10 by default.
+	Zenith int `toml:"zenith"`
+	// Sunset is how many minutes before Maghrib the sun is held to be
+	// setting, once it yellows, 15 by default.
+	Sunset int `toml:"sunset"`
+}
+
+func (m MakruhConfig) zenith() time.Duration {
+	if m.Zenith <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(m.Zenith) * time.Minute
+}
+
+func (m MakruhConfig) sunset() time.Duration {
+	if m.Sunset <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(m.Sunset) * time.Minute
+}
+
+// makruhTime is a time in which voluntary prayers are disliked.
+type makruhTime struct {
+	// Name is what happens to the sun meanwhile, e.g. "While the sun rises".
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// makruhTimes returns the three disliked times of today: from sunrise until
+// Duha, before Dhuhr and before Maghrib.
+func (c Config) makruhTimes(today adhan.Day, now time.Time) ([]makruhTime, error) {
+	prayers, err := today.Prayers(now)
+	if err != nil {
+		return nil, err
+	}
+	sunrise, dhuhr, maghrib := prayers[1].Time, prayers[2].Time, prayers[4].Time
+
+	return []makruhTime{
+		{"While the sun rises", sunrise, c.Duha.start(sunrise)},
+		{"While the sun is at its zenith", dhuhr.Add(-c.Makruh.zenith()), dhuhr},
+		{"While the sun sets", maghrib.Add(-c.Makruh.sunset()), maghrib},
+	}, nil
+}
+
+// makruhAt returns the disliked time now falls in, if any.
+func (c Config) makruhAt(today adhan.Day, now time.Time) (makruhTime, bool) {
+	times, err := c.makruhTimes(today, now)
+	if err != nil {
+		return makruhTime{}, false
+	}
+	for _, t := range times {
+		if !now.Before(t.Start) && now.Before(t.End) {
+			return t, true
+		}
+	}
+	return makruhTime{}, false
+}
+
+// printMakruh lists today's disliked times.
+func printMakruh(ctx context.Context, cfg Config, loc *time.Location) error {
+	today, err := getToday(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to fetch prayer times: %w", err)
+	}
+
+	times, err := cfg.makruhTimes(today, clock.Now().In(loc))
+	if err != nil {
+		return err
+	}
+	data := make([][]string, 0, len(times))
+	for _, t := range times {
+		data = append(data, []string{cfg.tr(t.Name), cfg.formatTime(t.Start), cfg.formatTime(t.End)})
+	}
+	printTable([]string{cfg.tr("Disliked time"), cfg.tr("From"), cfg.tr("Until")}, data)
+	return nil
+}
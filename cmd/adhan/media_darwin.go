@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// mediaApps are the players paused during the adhan.
+var mediaApps = []string{"Music", "Spotify"}
+
+// pauseMedia pauses the players that are playing, through AppleScript, and
+// returns a function resuming them.
+func pauseMedia() (func(), error) {
+	var paused []string
+	for _, app := range mediaApps {
+		script := fmt.Sprintf(`if application %[1]q is running then
+	tell application %[1]q
+		if player state is playing then
+			pause
+			return "paused"
+		end if
+	end tell
+end if
+return ""`, app)
+
+		out, err := exec.Command("osascript", "-e", script).Output()
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(string(out)) == "paused" {
+			paused = append(paused, app)
+		}
+	}
+
+	return func() {
+		for _, app := range paused {
+			exec.Command("osascript", "-e", fmt.Sprintf("tell application %q to play", app)).Run()
+		}
+	}, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// pauseMedia pauses the MPRIS players that are playing, through playerctl,
+// and returns a function resuming them.
+func pauseMedia() (func(), error) {
+	out, err := exec.Command("playerctl", "--list-all").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var paused []string
+	for _, player := range strings.Fields(string(out)) {
+		status, err := exec.Command("playerctl", "--player", player, "status").Output()
+		if err != nil || strings.TrimSpace(string(status)) != "Playing" {
+			continue
+		}
+		if exec.Command("playerctl", "--player", player, "pause").Run() == nil {
+			paused = append(paused, player)
+		}
+	}
+
+	return func() {
+		for _, player := range paused {
+			exec.Command("playerctl", "--player", player, "play").Run()
+		}
+	}, nil
+}
@@ -0,0 +1,9 @@
+//go:build !darwin && !linux
+
+package main
+
+import "errors"
+
+func pauseMedia() (func(), error) {
+	return nil, errors.New("pausing media is not supported on this platform")
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"iustusae/adhan/internal/calc"
+	"iustusae/adhan/pkg/adhan"
+)
+
+// MethodID is an aladhan calculation method. In the config file and on the
+// command line it can be given either as its number or as a preset name.
+type MethodID int
+
+// methodPresets maps preset names to aladhan method ids. Names are matched
+// ignoring case and punctuation, see normalizeMethodName.
+var methodPresets = map[string]MethodID{
+	"jafari":       0,
+	"karachi":      1,
+	"isna":         2,
+	"mwl":          3,
+	"ummalqura":    4,
+	"makkah":       4,
+	"egypt":        5,
+	"tehran":       7,
+	"gulf":         8,
+	"kuwait":       9,
+	"qatar":        10,
+	"singapore":    11,
+	"france":       12,
+	"turkey":       13,
+	"russia":       14,
+	"moonsighting": 15,
+	"dubai":        16,
+	"jakim":        17,
+	"tunisia":      18,
+	"algeria":      19,
+	"kemenag":      20,
+	"morocco":      21,
+	"portugal":     22,
+	"jordan":       23,
+	"custom":       99,
+}
+
+func normalizeMethodName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// parseMethod accepts a method number or preset name.
+func parseMethod(s string) (MethodID, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if !MethodID(n).known() {
+			return 0, fmt.Errorf("unknown method %d, run `adhan methods` for the list", n)
+		}
+		return MethodID(n), nil
+	}
+	if id, ok := methodPresets[normalizeMethodName(s)]; ok {
+		return id, nil
+	}
+	return 0, fmt.Errorf("unknown method %q, run `adhan methods` for the list", s)
+}
+
+// known reports whether m is one of the methods adhan methods lists, or the
+// custom one.
+func (m MethodID) known() bool {
+	_, ok := calc.Methods[int(m)]
+	return ok || m == methodPresets["custom"]
+}
+
+// preset returns the preset name of the method, or an empty string.
+func (m MethodID) preset() string {
+	var names []string
+	for name, id := range methodPresets {
+		if id == m {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func (m *MethodID) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case int64:
+		id, err := parseMethod(strconv.FormatInt(v, 10))
+		if err != nil {
+			return err
+		}
+		*m = id
+		return nil
+	case string:
+		id, err := parseMethod(v)
+		if err != nil {
+			return err
+		}
+		*m = id
+		return nil
+	default:
+		return fmt.Errorf("invalid method %v", v)
+	}
+}
+
+// String, Set and Type implement pflag.Value.
+func (m *MethodID) String() string { return strconv.Itoa(int(*m)) }
+func (m *MethodID) Type() string   { return "method" }
+
+func (m *MethodID) Set(s string) error {
+	id, err := parseMethod(s)
+	if err != nil {
+		return err
+	}
+	*m = id
+	return nil
+}
+
+func printMethods(ctx context.Context, cfg Config) error {
+	methods, err := cfg.client().Methods(ctx)
+	if err != nil {
+		slog.Warn("Failed to fetch methods, listing the ones known locally", "err", err)
+		methods = localMethods()
+	}
+
+	header := []string{"ID", "Preset", "Name", "Fajr", "Isha"}
+	data := make([][]string, 0, len(methods))
+	for _, m := range methods {
+		id := MethodID(m.ID)
+		data = append(data, []string{
+			strconv.Itoa(m.ID),
+			id.preset(),
+			m.Name,
+			formatParam(m.Params["Fajr"]),
+			formatParam(m.Params["Isha"]),
+		})
+	}
+	printTable(header, data)
+	return nil
+}
+
+// localMethods lists the methods the local calculation supports.
+func localMethods() []adhan.MethodInfo {
+	methods := make([]adhan.MethodInfo, 0, len(calc.Methods))
+	for id, m := range calc.Methods {
+		params := map[string]any{"Fajr": m.Fajr, "Isha": m.Isha}
+		if m.IshaMinutes > 0 {
+			params["Isha"] = fmt.Sprintf("%d min", m.IshaMinutes)
+		}
+		methods = append(methods, adhan.MethodInfo{ID: id, Name: m.Name, Params: params})
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].ID < methods[j].ID })
+	return methods
+}
+
+// formatParam formats a method parameter, which the API gives either as an
+// angle or as a string like "90 min".
+func formatParam(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64) + "°"
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// mqttTimeout bounds how long publishing waits for the broker.
+const mqttTimeout = 10 * time.Second
+
+// MQTTConfig configures publishing prayer events to an MQTT broker, with
+// Home Assistant discovery so that its sensors show up by themselves.
+type MQTTConfig struct {
+	// Broker is the URL of the broker, e.g. "tcp://localhost:1883". MQTT is
+	// disabled when it is empty.
+	Broker   string `toml:"broker"`
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+	ClientID string `toml:"client_id"`
+	// Topic prefixes every topic published, "adhan" by default.
+	Topic string `toml:"topic"`
+	// Discovery publishes Home Assistant discovery messages under
+	// DiscoveryPrefix, "homeassistant" by default.
+	Discovery       bool   `toml:"discovery"`
+	DiscoveryPrefix string `toml:"discovery_prefix"`
+}
+
+// mqttPublisher publishes the scheduler's events:
+//
+//	adhan/status           online or offline, retained
+//	adhan/state            the next prayer as JSON, retained
+//	adhan/prayer/<prayer>  the event, when the prayer time arrives
+//	adhan/reminder         the event, when a reminder fires
+//	adhan/event            {"event_type": "<prayer>"} for Home Assistant
+type mqttPublisher struct {
+	cfg    MQTTConfig
+	client mqtt.Client
+}
+
+// newMQTTPublisher connects to the broker in the background, retrying until
+// it is reachable.
+func newMQTTPublisher(cfg MQTTConfig) *mqttPublisher {
+	if cfg.Topic == "" {
+		cfg.Topic = "adhan"
+	}
+	if cfg.ClientID == "" {
+		cfg.ClientID = "adhan"
+	}
+	if cfg.DiscoveryPrefix == "" {
+		cfg.DiscoveryPrefix = "homeassistant"
+	}
+
+	p := &mqttPublisher{cfg: cfg}
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetConnectRetry(true).
+		SetAutoReconnect(true).
+		SetWill(p.topic("status"), "offline", 1, true).
+		SetOnConnectHandler(func(mqtt.Client) {
+			p.publish("status", "online", true)
+			if cfg.Discovery {
+				p.discover()
+			}
+		})
+	p.client = mqtt.NewClient(opts)
+	p.client.Connect()
+	return p
+}
+
+// close says the daemon is offline and disconnects from the broker.
+func (p *mqttPublisher) close() {
+	if p.client.IsConnected() {
+		p.client.Publish(p.topic("status"), 1, true, "offline").WaitTimeout(mqttTimeout)
+	}
+	p.client.Disconnect(250)
+}
+
+func (p *mqttPublisher) topic(name string) string {
+	return p.cfg.Topic + "/" + name
+}
+
+// publish sends payload to the topic name under the prefix, logging failures
+// in the background.
+func (p *mqttPublisher) publish(name string, payload any, retained bool) {
+	p.publishTopic(p.topic(name), payload, retained)
+}
+
+func (p *mqttPublisher) publishTopic(topic string, payload any, retained bool) {
+	if v, ok := payload.(string); !ok {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			slog.Error("Failed to publish to MQTT", "err", err)
+			return
+		}
+		payload = raw
+	} else {
+		payload = []byte(v)
+	}
+
+	token := p.client.Publish(topic, 1, retained, payload)
+	go func() {
+		if !token.WaitTimeout(mqttTimeout) {
+			slog.Error("Failed to publish to MQTT: timed out", "topic", topic)
+		} else if err := token.Error(); err != nil {
+			slog.Error("Failed to publish to MQTT", "topic", topic, "err", err)
+		}
+	}()
+}
+
+// onEvent publishes e.
+func (p *mqttPublisher) onEvent(e prayerEvent) {
+	switch e.Type {
+	case "refresh":
+		if e.Prayer != nil {
+			p.publish("state", e.Prayer, true)
+		}
+	case "prayer":
+		if e.Prayer == nil {
+			return
+		}
+		name := strings.ToLower(strings.ReplaceAll(e.Prayer.Name, "'", ""))
+		p.publish("prayer/"+name, e, false)
+		p.publish("event", map[string]string{"event_type": name}, false)
+	case "reminder":
+		p.publish("reminder", e, false)
+	}
+}
+
+// discover publishes the Home Assistant discovery messages of the next
+// prayer sensors and of the prayer event entity.
+func (p *mqttPublisher) discover() {
+	device := map[string]any{
+		"identifiers": []string{p.cfg.ClientID},
+		"name":        "adhan",
+		"model":       "adhan",
+	}
+	common := func(name, id string) map[string]any {
+		return map[string]any{
+			"name":               name,
+			"unique_id":          p.cfg.ClientID + "_" + id,
+			"availability_topic": p.topic("status"),
+			"device":             device,
+		}
+	}
+
+	nextPrayer := common("Next prayer", "next_prayer")
+	nextPrayer["state_topic"] = p.topic("state")
+	nextPrayer["value_template"] = "{{ value_json.name }}"
+	nextPrayer["icon"] = "mdi:mosque"
+
+	nextTime := common("Next prayer time", "next_prayer_time")
+	nextTime["state_topic"] = p.topic("state")
+	nextTime["value_template"] = "{{ value_json.time }}"
+	nextTime["device_class"] = "timestamp"
+
+	prayer := common("Prayer", "prayer")
+	prayer["state_topic"] = p.topic("event")
+	prayer["event_types"] = []string{"fajr", "sunrise", "dhuhr", "jumuah", "asr", "maghrib", "isha"}
+
+	base := p.cfg.DiscoveryPrefix
+	p.publishTopic(base+"/sensor/"+p.cfg.ClientID+"/next_prayer/config", nextPrayer, true)
+	p.publishTopic(base+"/sensor/"+p.cfg.ClientID+"/next_prayer_time/config", nextTime, true)
+	p.publishTopic(base+"/event/"+p.cfg.ClientID+"/prayer/config", prayer, true)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// TahajjudConfig configures the Tahajjud (Qiyam al-layl) alarm, rung when
+// the last third of the night begins.
+type TahajjudConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Offset is how many minutes into the last third to ring, to leave time
+	// before Fajr for the prayer and for Suhoor.
+	Offset int `toml:"offset"`
+}
+
+// night runs from Maghrib until the next Fajr.
+type night struct {
+	Maghrib time.Time
+	Fajr    time.Time
+}
+
+// midnight is the middle of the night, when the time of Isha ends.
+func (n night) midnight() time.Time {
+	return n.Maghrib.Add(n.Fajr.Sub(n.Maghrib) / 2).Truncate(time.Minute)
+}
+
+// lastThird is when the last third of the night begins, the best time for
+// Tahajjud.
+func (n night) lastThird() time.Time {
+	return n.Maghrib.Add(n.Fajr.Sub(n.Maghrib) * 2 / 3).Truncate(time.Minute)
+}
+
+// nightAfter returns the night that starts at the Maghrib of date.
+func nightAfter(ctx context.Context, cfg Config, today adhan.Day, date time.Time) (night, error) {
+	prayers, err := today.Prayers(date)
+	if err != nil {
+		return night{}, err
+	}
+	next := date.AddDate(0, 0, 1)
+	tomorrow, err := getDay(ctx, cfg, next)
+	if err != nil {
+		return night{}, err
+	}
+	following, err := tomorrow.Prayers(next)
+	if err != nil {
+		return night{}, err
+	}
+	return night{Maghrib: prayers[4].Time, Fajr: following[0].Time}, nil
+}
+
+// nightBefore returns the night that ends at fajr.
+func nightBefore(ctx context.Context, cfg Config, fajr adhan.Prayer) (night, error) {
+	eve := fajr.Time.AddDate(0, 0, -1)
+	day, err := getDay(ctx, cfg, eve)
+	if err != nil {
+		return night{}, err
+	}
+	prayers, err := day.Prayers(eve)
+	if err != nil {
+		return night{}, err
+	}
+	return night{Maghrib: prayers[4].Time, Fajr: fajr.Time}, nil
+}
+
+// nightRows returns the rows of the timings table for the night that starts
+// at the Maghrib of date.
+func nightRows(ctx context.Context, cfg Config, today adhan.Day, date time.Time) [][]string {
+	n, err := nightAfter(ctx, cfg, today, date)
+	if err != nil {
+		slog.Warn("Failed to work out the night", "err", err)
+		return nil
+	}
+	return [][]string{
+		{cfg.nameOf("Midnight"), cfg.formatTime(n.midnight())},
+		{cfg.nameOf("Last third"), cfg.formatTime(n.lastThird())},
+	}
+}
+
+// tahajjudReminders rings the Tahajjud alarm in the last third of the night
+// before each Fajr.
+func (s *scheduler) tahajjudReminders(ctx context.Context, prayers []adhan.Prayer) []adhan.Reminder {
+	if !s.cfg.Tahajjud.Enabled {
+		return nil
+	}
+
+	var reminders []adhan.Reminder
+	for _, p := range prayers {
+		if p.Name != "Fajr" {
+			continue
+		}
+		n, err := nightBefore(ctx, s.cfg, p)
+		if err != nil {
+			slog.Error("Failed to work out the night", "err", err)
+			continue
+		}
+		reminders = append(reminders, adhan.Reminder{
+			Title:   s.cfg.tr("Tahajjud"),
+			Message: s.cfg.tr("The last third of the night has begun, Fajr is at %s.", s.cfg.formatTime(p.Time)),
+			Time:    n.lastThird().Add(time.Duration(s.cfg.Tahajjud.Offset) * time.Minute),
+		})
+	}
+	return reminders
+}
@@ -0,0 +1,85 @@
+package main
+
+import "log/slog"
+
+type Urgency int
+
+const (
+	UrgencyLow Urgency = iota
+	UrgencyNormal
+	UrgencyCritical
+)
+
+// Action is a button shown on a notification.
+type Action struct {
+	Key   string
+	Label string
+}
+
+type Notification struct {
+	Title   string
+	Message string
+	Urgency Urgency
+	// Sound overrides the configured notification sound, where supported.
+	Sound string
+	// Actions are only shown where the platform supports them, OnAction is
+	// called with the key of the one clicked.
+	Actions  []Action
+	OnAction func(key string)
+}
+
+// Notifier delivers desktop notifications. Each platform has its own
+// implementation, picked at build time by newPlatformNotifier.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+type nopNotifier struct{}
+
+func (nopNotifier) Notify(n Notification) error { return nil }
+
+// newNotifier returns the notifier for the current platform along with the
+// configured push services, or one that drops everything when notifications
+// are disabled.
+func newNotifier(cfg NotificationConfig) Notifier {
+	if !cfg.Enabled {
+		return nopNotifier{}
+	}
+
+	var notifiers multiNotifier
+	if cfg.Desktop {
+		notifiers = append(notifiers, newPlatformNotifier(cfg))
+	}
+	if cfg.Ntfy.Topic != "" {
+		notifiers = append(notifiers, ntfyNotifier{cfg: cfg.Ntfy})
+	}
+	if cfg.Pushover.Token != "" && cfg.Pushover.User != "" {
+		notifiers = append(notifiers, pushoverNotifier{cfg: cfg.Pushover})
+	}
+	if cfg.Discord.Webhook != "" {
+		notifiers = append(notifiers, newDiscordNotifier(cfg.Discord))
+	}
+	if cfg.Slack.Webhook != "" {
+		notifiers = append(notifiers, newSlackNotifier(cfg.Slack))
+	}
+
+	switch len(notifiers) {
+	case 0:
+		return nopNotifier{}
+	case 1:
+		return notifiers[0]
+	default:
+		return notifiers
+	}
+}
+
+// showNotification shows a plain notification, logging failures.
+func showNotification(notifier Notifier, title, message string) {
+	notify(notifier, Notification{Title: title, Message: message, Urgency: UrgencyNormal})
+}
+
+func notify(notifier Notifier, n Notification) {
+	if err := notifier.Notify(n); err != nil {
+		slog.Error("Failed to show notification", "err", err)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import gosxnotifier "github.com/deckarep/gosx-notifier"
+
+// defaultSound is the notification sound unless configured otherwise.
+const defaultSound = "Basso"
+
+type macNotifier struct {
+	sound gosxnotifier.Sound
+	icon  string
+}
+
+func newPlatformNotifier(cfg NotificationConfig) Notifier {
+	return macNotifier{sound: gosxnotifier.Sound(cfg.Sound), icon: notificationIcon(cfg)}
+}
+
+// Notify ignores actions, which terminal-notifier can't report back.
+func (n macNotifier) Notify(notification Notification) error {
+	note := gosxnotifier.NewNotification(notification.Title)
+	note.Title = notification.Title
+	note.Subtitle = notification.Message
+	if notification.Urgency >= UrgencyNormal {
+		note.Sound = n.sound
+		if notification.Sound != "" {
+			note.Sound = gosxnotifier.Sound(notification.Sound)
+		}
+	}
+
+	// Only ever show one notification, replacing the previous one.
+	note.Group = "github.iustusae.adhan"
+
+	// App icons and content images are only supported on 10.9+.
+	note.AppIcon = n.icon
+	note.ContentImage = n.icon
+
+	return note.Push()
+}
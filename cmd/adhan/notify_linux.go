@@ -0,0 +1,154 @@
+package main
+
+import (
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	notificationsName      = "org.freedesktop.Notifications"
+	notificationsPath      = "/org/freedesktop/Notifications"
+	notificationsInterface = "org.freedesktop.Notifications"
+)
+
+// defaultSound is the notification sound unless configured otherwise, none
+// but what the notification server plays when empty.
+const defaultSound = ""
+
+// dbusNotifier talks to the notification daemon over D-Bus, which supports
+// urgency levels and reports the actions clicked back to us.
+type dbusNotifier struct {
+	conn  *dbus.Conn
+	sound string
+	icon  string
+
+	mu       sync.Mutex
+	handlers map[uint32]func(key string)
+}
+
+func newPlatformNotifier(cfg NotificationConfig) Notifier {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		slog.Warn("Failed to connect to the session bus, falling back to notify-send", "err", err)
+		return notifySendNotifier{sound: cfg.Sound, icon: notificationIcon(cfg)}
+	}
+
+	err = conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(notificationsPath),
+		dbus.WithMatchInterface(notificationsInterface),
+	)
+	if err != nil {
+		slog.Error("Failed to watch notification actions", "err", err)
+	}
+
+	n := &dbusNotifier{conn: conn, sound: cfg.Sound, icon: notificationIcon(cfg), handlers: make(map[uint32]func(string))}
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+	go n.listen(signals)
+
+	return n
+}
+
+func (n *dbusNotifier) Notify(notification Notification) error {
+	actions := make([]string, 0, 2*len(notification.Actions))
+	for _, a := range notification.Actions {
+		actions = append(actions, a.Key, a.Label)
+	}
+
+	hints := map[string]dbus.Variant{
+		"urgency": dbus.MakeVariant(byte(notification.Urgency)),
+	}
+	if key, sound := soundHint(notification, n.sound); sound != "" {
+		hints[key] = dbus.MakeVariant(sound)
+	}
+
+	// Critical notifications stay until dismissed, the others use the
+	// server's default timeout.
+	timeout := int32(-1)
+	if notification.Urgency == UrgencyCritical {
+		timeout = 0
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var id uint32
+	err := n.conn.Object(notificationsName, notificationsPath).Call(
+		notificationsInterface+".Notify", 0,
+		"adhan", uint32(0), n.icon, notification.Title, notification.Message,
+		actions, hints, timeout,
+	).Store(&id)
+	if err != nil {
+		return err
+	}
+
+	if notification.OnAction != nil && len(actions) > 0 {
+		n.handlers[id] = notification.OnAction
+	}
+	return nil
+}
+
+// listen dispatches clicked actions to their handlers, and forgets the
+// handlers of closed notifications.
+func (n *dbusNotifier) listen(signals <-chan *dbus.Signal) {
+	for signal := range signals {
+		if len(signal.Body) < 2 {
+			continue
+		}
+		id, ok := signal.Body[0].(uint32)
+		if !ok {
+			continue
+		}
+
+		n.mu.Lock()
+		handler := n.handlers[id]
+		if signal.Name == notificationsInterface+".NotificationClosed" {
+			delete(n.handlers, id)
+		}
+		n.mu.Unlock()
+
+		if key, ok := signal.Body[1].(string); ok && handler != nil && signal.Name == notificationsInterface+".ActionInvoked" {
+			handler(key)
+		}
+	}
+}
+
+// soundHint returns the hint playing the sound of notification, or else the
+// configured one: a file, or a name from the freedesktop sound theme, e.g.
+// "bell". Low urgency notifications are silent.
+func soundHint(notification Notification, sound string) (key, value string) {
+	if notification.Sound != "" {
+		sound = notification.Sound
+	}
+	switch {
+	case notification.Urgency == UrgencyLow || sound == "":
+		return "", ""
+	case strings.ContainsRune(sound, '/'):
+		return "sound-file", sound
+	default:
+		return "sound-name", sound
+	}
+}
+
+// notifySendNotifier shells out to notify-send when D-Bus isn't reachable
+// directly. Actions are not supported.
+type notifySendNotifier struct {
+	sound string
+	icon  string
+}
+
+func (s notifySendNotifier) Notify(n Notification) error {
+	urgency := [...]string{UrgencyLow: "low", UrgencyNormal: "normal", UrgencyCritical: "critical"}[n.Urgency]
+	args := []string{"--app-name=adhan", "--urgency=" + urgency}
+	if s.icon != "" {
+		args = append(args, "--icon="+s.icon)
+	}
+	if key, sound := soundHint(n, s.sound); sound != "" {
+		args = append(args, "--hint=string:"+key+":"+sound)
+	}
+	return exec.Command("notify-send", append(args, n.Title, n.Message)...).Run()
+}
@@ -0,0 +1,21 @@
+//go:build !darwin && !linux && !windows
+
+package main
+
+import "fmt"
+
+// defaultSound is unused, as the console makes no sound.
+const defaultSound = ""
+
+// consoleNotifier is used on platforms without a supported notification
+// system, it just prints to stdout.
+type consoleNotifier struct{}
+
+func newPlatformNotifier(cfg NotificationConfig) Notifier {
+	return consoleNotifier{}
+}
+
+func (consoleNotifier) Notify(n Notification) error {
+	_, err := fmt.Printf("%s: %s\n", n.Title, n.Message)
+	return err
+}
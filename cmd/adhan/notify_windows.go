@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/go-toast/toast"
+)
+
+// defaultSound is the notification sound unless configured otherwise, that
+// of the system when empty.
+const defaultSound = ""
+
+type windowsNotifier struct {
+	// sound is the name of a toast sound, e.g. "reminder" or "loopingalarm".
+	sound string
+	icon  string
+}
+
+func newPlatformNotifier(cfg NotificationConfig) Notifier {
+	return windowsNotifier{sound: cfg.Sound, icon: notificationIcon(cfg)}
+}
+
+// Notify ignores actions, toast buttons can only launch protocols rather than
+// call back into the daemon.
+func (w windowsNotifier) Notify(n Notification) error {
+	note := toast.Notification{
+		AppID:   "adhan",
+		Title:   n.Title,
+		Message: n.Message,
+		Icon:    w.icon,
+		Audio:   toast.Default,
+	}
+	sound := w.sound
+	if n.Sound != "" {
+		sound = n.Sound
+	}
+	if sound != "" {
+		audio, err := toast.Audio(sound)
+		if err != nil {
+			slog.Warn("Unknown notification sound, playing the default one", "sound", sound)
+		}
+		note.Audio = audio
+	}
+	if n.Urgency == UrgencyLow {
+		note.Audio = toast.Silent
+	}
+	if n.Urgency == UrgencyCritical {
+		note.Duration = toast.Long
+	}
+	return note.Push()
+}
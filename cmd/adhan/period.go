@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// period is the stretch of time from one prayer time to the next, e.g. the
+// time of Asr, which ends at Maghrib.
+type period struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	// Until is the prayer time that ends the period, at End.
+	Until string    `json:"until"`
+	End   time.Time `json:"end"`
+}
+
+// periods splits the day of today into periods, from Fajr until Isha, which
+// lasts until the Fajr of the following day at nextFajr.
+func (c Config) periods(today adhan.Day, now time.Time, nextFajr time.Time) ([]period, error) {
+	prayers, err := today.Prayers(now)
+	if err != nil {
+		return nil, err
+	}
+	sunrise := prayers[1].Time
+
+	// Between sunrise and Duha comes no prayer.
+	times := []adhan.Prayer{
+		prayers[0],
+		prayers[1],
+		{Name: "Duha", Time: c.Duha.start(sunrise)},
+		prayers[2],
+		prayers[3],
+		prayers[4],
+		prayers[5],
+		{Name: "Fajr", Time: nextFajr},
+	}
+
+	ps := make([]period, 0, len(times)-1)
+	for i, p := range times[:len(times)-1] {
+		ps = append(ps, period{Name: p.Name, Start: p.Time, Until: times[i+1].Name, End: times[i+1].Time})
+	}
+	return ps, nil
+}
+
+// currentPeriod returns the period now falls in. Before Fajr, that is the
+// Isha of the day before.
+func currentPeriod(ctx context.Context, cfg Config, today adhan.Day, now time.Time) (period, error) {
+	prayers, err := today.Prayers(now)
+	if err != nil {
+		return period{}, err
+	}
+	day := today
+	date := now
+	if now.Before(prayers[0].Time) {
+		date = now.AddDate(0, 0, -1)
+		if day, err = getDay(ctx, cfg, date); err != nil {
+			return period{}, fmt.Errorf("failed to fetch prayer times: %w", err)
+		}
+	}
+
+	tomorrow, err := getDay(ctx, cfg, date.AddDate(0, 0, 1))
+	if err != nil {
+		return period{}, fmt.Errorf("failed to fetch prayer times: %w", err)
+	}
+	next, err := tomorrow.Prayers(date.AddDate(0, 0, 1))
+	if err != nil {
+		return period{}, err
+	}
+
+	ps, err := cfg.periods(day, date, next[0].Time)
+	if err != nil {
+		return period{}, err
+	}
+	for _, p := range ps {
+		if !now.Before(p.Start) && now.Before(p.End) {
+			return p, nil
+		}
+	}
+	return period{}, fmt.Errorf("no prayer time found at %s", now.Format(time.RFC3339))
+}
+
+// printNow prints which period of the day it is, when it ends and how long
+// is left, e.g. "Asr, ends at Maghrib 19:45, 1h 12m left".
+func printNow(ctx context.Context, cfg Config, loc *time.Location, asJSON bool) error {
+	today, err := getToday(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to fetch prayer times: %w", err)
+	}
+
+	now := clock.Now().In(loc)
+	p, err := currentPeriod(ctx, cfg, today, now)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(p)
+	}
+
+	name := cfg.localName(adhan.Prayer{Name: p.Name, Time: p.Start})
+	until := cfg.localName(adhan.Prayer{Name: p.Until, Time: p.End})
+	fmt.Println(cfg.tr("%s, ends at %s %s, %s left", name, until, cfg.formatTime(p.End), formatCountdown(p.End.Sub(now), false)))
+	if !cfg.Makruh.Warn {
+		return nil
+	}
+	if t, ok := cfg.makruhAt(today, now); ok {
+		fmt.Println(cfg.tr("%s: voluntary prayers are disliked until %s", cfg.tr(t.Name), cfg.formatTime(t.End)))
+	}
+	return nil
+}
+
+// DuhaConfig configures the time of Duha, from once the sun has risen a
+// spear's length above the horizon until shortly before its zenith.
+type DuhaConfig struct {
+	// Show adds it to the timings table.
+	Show bool `toml:"show"`
+	// Delay is how many minutes after sunrise it begins, 15 by default.
+	Delay int `toml:"delay"`
+}
+
+// start returns when the time of Duha begins on the day of sunrise.
+func (d DuhaConfig) start(sunrise time.Time) time.Time {
+	if d.Delay <= 0 {
+		return sunrise.Add(15 * time.Minute)
+	}
+	return sunrise.Add(time.Duration(d.Delay) * time.Minute)
+}
+
+// duhaRow returns the row of the timings table for the time of Duha, which
+// ends when that of the zenith begins, see MakruhConfig.
+func (c Config) duhaRow(today adhan.Day, now time.Time) ([]string, bool) {
+	prayers, err := today.Prayers(now)
+	if !c.Duha.Show || err != nil {
+		return nil, false
+	}
+	start := c.Duha.start(prayers[1].Time)
+	end := prayers[2].Time.Add(-c.Makruh.zenith())
+	return []string{c.nameOf("Duha"), c.formatTime(start) + " – " + c.formatTime(end)}, true
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// breakRepeat is how often the prayer break locks the screen again, should
+// it be unlocked before the break is over.
+const breakRepeat = time.Minute
+
+var errNoScreenLock = errors.New("no command to lock the screen available")
+
+// BreakConfig configures the prayer break, a forced pause locking the screen
+// at prayer time, and again should it be unlocked before the break is over,
+// unless the prayer is acknowledged. There is none in quiet hours, nor while
+// busy.
+type BreakConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Mode is "lock" to lock the session, the default, or "off" to turn the
+	// screen off.
+	Mode string `toml:"mode"`
+	// Duration is how many minutes the break lasts, 10 by default.
+	Duration int `toml:"duration"`
+	// Prayers limits the break to some prayers, all but Sunrise by default.
+	Prayers []string `toml:"prayers"`
+}
+
+// validate checks the mode.
+func (b BreakConfig) validate() error {
+	switch b.Mode {
+	case "", "lock", "off":
+		return nil
+	}
+	return fmt.Errorf("unknown prayer break mode %q, expected lock or off", b.Mode)
+}
+
+func (b BreakConfig) duration() time.Duration {
+	if b.Duration <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(b.Duration) * time.Minute
+}
+
+// takes reports whether there is a break at prayer.
+func (b BreakConfig) takes(prayer string) bool {
+	if !b.Enabled {
+		return false
+	}
+	if len(b.Prayers) > 0 {
+		return containsPrayer(b.Prayers, prayer)
+	}
+	return prayer != "Sunrise"
+}
+
+// lookCommand returns a command for the first of the candidates that is
+// installed.
+func lookCommand(candidates [][]string) (*exec.Cmd, error) {
+	for _, c := range candidates {
+		if bin, err := exec.LookPath(c[0]); err == nil {
+			return exec.Command(bin, c[1:]...), nil
+		}
+	}
+	return nil, errNoScreenLock
+}
+
+// startBreak begins the prayer break for prayer, if configured.
+func (s *scheduler) startBreak(prayer adhan.Prayer) {
+	if !s.cfg.Break.takes(prayer.Name) {
+		return
+	}
+	slog.Info("Taking a prayer break", "prayer", prayer.Name, "duration", s.cfg.Break.duration())
+	s.lockScreen(prayer, clock.Now().Add(s.cfg.Break.duration()))
+}
+
+// lockScreen locks the screen, then again every breakRepeat until end, or
+// until prayer is acknowledged.
+func (s *scheduler) lockScreen(prayer adhan.Prayer, end time.Time) {
+	if s.acknowledged(prayer.Time) || !clock.Now().Before(end) {
+		return
+	}
+	cmd, err := screenCommand(s.cfg.Break.Mode)
+	if err == nil {
+		err = cmd.Run()
+	}
+	if err != nil {
+		slog.Error("Failed to lock the screen for the prayer break", "err", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pause != nil {
+		s.pause.Stop()
+	}
+	s.pause = clock.AfterFunc(breakRepeat, func() {
+		s.lockScreen(prayer, end)
+	})
+}
+
+// endBreak ends the prayer break, if any.
+func (s *scheduler) endBreak() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pause != nil {
+		s.pause.Stop()
+		s.pause = nil
+	}
+}
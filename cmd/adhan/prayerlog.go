@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// logDateLayout is how days are keyed in the prayer log.
+const logDateLayout = "2006-01-02"
+
+// fardPrayers are the five daily prayers tracked by the prayer log.
+var fardPrayers = []string{"Fajr", "Dhuhr", "Asr", "Maghrib", "Isha"}
+
+// prayerLog records the prayers marked as prayed, by day, and the missed
+// prayers still to be made up (qada).
+type prayerLog struct {
+	Days   map[string][]string `json:"days"`
+	Missed []missedPrayer      `json:"missed,omitempty"`
+}
+
+// missedPrayer is a prayer whose time passed without it being marked as
+// prayed.
+type missedPrayer struct {
+	Date   string `json:"date"`
+	Prayer string `json:"prayer"`
+}
+
+// loadPrayerLog reads the prayer log from the store, which is empty until a
+// prayer is marked.
+func loadPrayerLog() (*prayerLog, error) {
+	l := &prayerLog{Days: make(map[string][]string)}
+	err := viewStore(func(tx *bolt.Tx) error {
+		_, err := getJSON(tx, stateBucket, logKey, l)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if l.Days == nil {
+		l.Days = make(map[string][]string)
+	}
+	return l, nil
+}
+
+// updatePrayerLog runs fn on the prayer log and saves it when fn reports it
+// changed, in one transaction so that the daemon and commands run meanwhile
+// don't drop each other's changes.
+func updatePrayerLog(fn func(l *prayerLog) (bool, error)) error {
+	return updateStore(func(tx *bolt.Tx) error {
+		l := &prayerLog{}
+		if _, err := getJSON(tx, stateBucket, logKey, l); err != nil {
+			return err
+		}
+		if l.Days == nil {
+			l.Days = make(map[string][]string)
+		}
+		changed, err := fn(l)
+		if err != nil || !changed {
+			return err
+		}
+		return putJSON(tx, stateBucket, logKey, l)
+	})
+}
+
+// prayed reports whether prayer was marked as prayed on day.
+func (l *prayerLog) prayed(day time.Time, prayer string) bool {
+	for _, p := range l.Days[day.Format(logDateLayout)] {
+		if p == prayer {
+			return true
+		}
+	}
+	return false
+}
+
+// mark records prayer as prayed on day, and reports whether it wasn't
+// already. A prayer marked late is no longer missed.
+func (l *prayerLog) mark(day time.Time, prayer string) bool {
+	if l.prayed(day, prayer) {
+		return false
+	}
+	key := day.Format(logDateLayout)
+	l.Days[key] = append(l.Days[key], prayer)
+	l.Missed = removeMissed(l.Missed, func(m missedPrayer) bool {
+		return m.Date == key && m.Prayer == prayer
+	})
+	return true
+}
+
+// count returns how many of the five prayers were prayed on day.
+func (l *prayerLog) count(day time.Time) int {
+	n := 0
+	for _, p := range fardPrayers {
+		if l.prayed(day, p) {
+			n++
+		}
+	}
+	return n
+}
+
+// complete reports whether all five prayers were prayed on day.
+func (l *prayerLog) complete(day time.Time) bool {
+	return l.count(day) == len(fardPrayers)
+}
+
+// fardName returns the canonical name of one of the five prayers, given in
+// any case. Jumu'ah is logged as Dhuhr.
+func fardName(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "jumuah", "jumu'ah", "jummah":
+		return "Dhuhr", nil
+	}
+	for _, p := range fardPrayers {
+		if strings.EqualFold(p, name) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown prayer %q, expected one of %s", name, strings.Join(fardPrayers, ", "))
+}
+
+// logPrayer marks prayer as prayed on day in the prayer log.
+func logPrayer(day time.Time, prayer string) error {
+	return updatePrayerLog(func(l *prayerLog) (bool, error) {
+		return l.mark(day, prayer), nil
+	})
+}
+
+// markPrayed marks name as prayed today, or the last prayer whose time has
+// come when name is empty. Isha marked after midnight, before Fajr, is that
+// of the day before.
+func markPrayed(ctx context.Context, cfg Config, loc *time.Location, name string) error {
+	now := clock.Now().In(loc)
+
+	var today adhan.Day
+	if name == "" || strings.EqualFold(name, "isha") {
+		var err error
+		if today, err = getToday(ctx, cfg); err != nil {
+			return fmt.Errorf("failed to fetch prayer times: %w", err)
+		}
+	}
+	if name == "" {
+		current, ok := currentPrayer(today, now)
+		switch {
+		case !ok:
+			// Before Fajr, Isha is what is being caught up.
+			name = "Isha"
+		case current.Name == "Sunrise":
+			// Sunrise ends the time of Fajr, which is what is being caught
+			// up.
+			name = "Fajr"
+		default:
+			name = current.Name
+		}
+	}
+
+	prayer, err := fardName(name)
+	if err != nil {
+		return err
+	}
+	day := logDay(today, prayer, now)
+	if err := logPrayer(day, prayer); err != nil {
+		return fmt.Errorf("failed to save prayer log: %w", err)
+	}
+
+	if key := day.Format(logDateLayout); key != now.Format(logDateLayout) {
+		fmt.Printf("Marked %s of %s as prayed\n", prayer, key)
+		return nil
+	}
+	fmt.Printf("Marked %s as prayed\n", prayer)
+	return nil
+}
+
+// logDay returns the day prayer marked at now is logged on: the day before
+// for Isha marked after midnight, before the Fajr of today.
+func logDay(today adhan.Day, prayer string, now time.Time) time.Time {
+	if prayer != "Isha" {
+		return now
+	}
+	prayers, err := today.Prayers(now)
+	if err == nil && now.Before(prayers[0].Time) {
+		return now.AddDate(0, 0, -1)
+	}
+	return now
+}
+
+// printStats shows today's progress, the completion rate of the last week
+// and month, and streaks of days with all five prayers.
+func printStats(loc *time.Location) error {
+	l, err := loadPrayerLog()
+	if err != nil {
+		return err
+	}
+
+	today := clock.Now().In(loc)
+	rate := func(days int) string {
+		prayed := 0
+		for i := 1; i <= days; i++ {
+			prayed += l.count(today.AddDate(0, 0, -i))
+		}
+		total := days * len(fardPrayers)
+		return fmt.Sprintf("%d%% (%d/%d)", prayed*100/total, prayed, total)
+	}
+
+	// The current streak runs until yesterday while today isn't complete.
+	current := 0
+	day := today
+	if !l.complete(day) {
+		day = day.AddDate(0, 0, -1)
+	}
+	for ; l.complete(day); day = day.AddDate(0, 0, -1) {
+		current++
+	}
+
+	fmt.Printf("Today:          %d/%d\n", l.count(today), len(fardPrayers))
+	fmt.Printf("Last 7 days:    %s\n", rate(7))
+	fmt.Printf("Last 30 days:   %s\n", rate(30))
+	fmt.Printf("Current streak: %s\n", pluralDays(current))
+	fmt.Printf("Longest streak: %s\n", pluralDays(l.longestStreak()))
+	return nil
+}
+
+// longestStreak returns the longest run of consecutive complete days.
+func (l *prayerLog) longestStreak() int {
+	var days []time.Time
+	for key := range l.Days {
+		day, err := time.Parse(logDateLayout, key)
+		if err == nil && l.complete(day) {
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	longest, run := 0, 0
+	for i, day := range days {
+		if i > 0 && days[i-1].AddDate(0, 0, 1).Equal(day) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+func pluralDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
+
+// currentPrayer returns the last prayer whose time has come today.
+func currentPrayer(today adhan.Day, now time.Time) (adhan.Prayer, bool) {
+	prayers, err := today.Prayers(now)
+	if err != nil {
+		return adhan.Prayer{}, false
+	}
+
+	var current adhan.Prayer
+	var ok bool
+	for _, p := range prayers {
+		if !p.Time.After(now) {
+			current, ok = p, true
+		}
+	}
+	return current, ok
+}
+
+// previousPrayer maps each prayer time to the prayer whose time it ends.
+// Isha ends at the next Fajr, on the following day.
+var previousPrayer = map[string]string{
+	"Sunrise": "Fajr",
+	"Asr":     "Dhuhr",
+	"Maghrib": "Asr",
+	"Isha":    "Maghrib",
+	"Fajr":    "Isha",
+}
+
+// recordMissed adds the prayer whose time ends at prayer to the missed
+// prayers when it wasn't marked as prayed. Nothing is recorded until the
+// first prayer is logged, so that the log only fills up for those using it.
+func recordMissed(prayer adhan.Prayer) (string, error) {
+	name, ok := previousPrayer[prayer.Name]
+	if !ok {
+		return "", nil
+	}
+	day := prayer.Time
+	if prayer.Name == "Fajr" {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	var missed bool
+	err := updatePrayerLog(func(l *prayerLog) (bool, error) {
+		if len(l.Days) == 0 || l.prayed(day, name) {
+			return false, nil
+		}
+		key := day.Format(logDateLayout)
+		for _, m := range l.Missed {
+			if m.Date == key && m.Prayer == name {
+				return false, nil
+			}
+		}
+		l.Missed = append(l.Missed, missedPrayer{Date: key, Prayer: name})
+		missed = true
+		return true, nil
+	})
+	if err != nil || !missed {
+		return "", err
+	}
+	return name, nil
+}
+
+// removeMissed returns missed without the first prayer matching match.
+func removeMissed(missed []missedPrayer, match func(missedPrayer) bool) []missedPrayer {
+	for i, m := range missed {
+		if match(m) {
+			return append(missed[:i:i], missed[i+1:]...)
+		}
+	}
+	return missed
+}
+
+// printQada lists the missed prayers still to be made up, oldest first, with
+// a count per prayer.
+func printQada() error {
+	l, err := loadPrayerLog()
+	if err != nil {
+		return err
+	}
+	if len(l.Missed) == 0 {
+		fmt.Println("No missed prayers")
+		return nil
+	}
+
+	missed := append([]missedPrayer(nil), l.Missed...)
+	sort.SliceStable(missed, func(i, j int) bool { return missed[i].Date < missed[j].Date })
+
+	data := make([][]string, 0, len(missed))
+	for _, m := range missed {
+		data = append(data, []string{m.Date, m.Prayer})
+	}
+	printTable([]string{"Date", "Prayer"}, data)
+
+	counts := make([]string, 0, len(fardPrayers))
+	for _, p := range fardPrayers {
+		n := 0
+		for _, m := range missed {
+			if m.Prayer == p {
+				n++
+			}
+		}
+		if n > 0 {
+			counts = append(counts, fmt.Sprintf("%s %d", p, n))
+		}
+	}
+	fmt.Printf("%d to make up: %s\n", len(missed), strings.Join(counts, ", "))
+	return nil
+}
+
+// clearQada marks the oldest missed occurrence of name as made up, or every
+// missed prayer when all is set.
+func clearQada(name string, all bool) error {
+	if all {
+		var n int
+		err := updatePrayerLog(func(l *prayerLog) (bool, error) {
+			n = len(l.Missed)
+			l.Missed = nil
+			return n > 0, nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Cleared %d missed prayers\n", n)
+		return nil
+	}
+
+	prayer, err := fardName(name)
+	if err != nil {
+		return err
+	}
+
+	var date string
+	err = updatePrayerLog(func(l *prayerLog) (bool, error) {
+		oldest := -1
+		for i, m := range l.Missed {
+			if m.Prayer == prayer && (oldest < 0 || m.Date < l.Missed[oldest].Date) {
+				oldest = i
+			}
+		}
+		if oldest < 0 {
+			return false, fmt.Errorf("no missed %s to make up", prayer)
+		}
+		date = l.Missed[oldest].Date
+		l.Missed = append(l.Missed[:oldest:oldest], l.Missed[oldest+1:]...)
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Made up %s of %s\n", prayer, date)
+	return nil
+}
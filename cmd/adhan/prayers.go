@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// PrayerConfig overrides the notification settings for a single prayer.
+// Unset fields fall back to the [notifications] and [audio] settings.
+type PrayerConfig struct {
+	Notify *bool `toml:"notify"`
+	// Urgency is "low" (silent), "normal" or "critical" (the default).
+	Urgency string `toml:"urgency"`
+	Sound   string `toml:"sound"`
+	// Message replaces the notification text, {prayer} and {time} are
+	// replaced with the name and time of the prayer.
+	Message string `toml:"message"`
+	Audio   *bool  `toml:"audio"`
+	File    string `toml:"file"`
+	// Adhkar is how many minutes after the prayer to remind of the adhkar,
+	// 0 not to, see AdhkarConfig.
+	Adhkar *int `toml:"adhkar"`
+}
+
+// validate checks the urgency.
+func (p PrayerConfig) validate() error {
+	_, err := parseUrgency(p.Urgency)
+	return err
+}
+
+func parseUrgency(s string) (Urgency, error) {
+	switch strings.ToLower(s) {
+	case "", "critical":
+		return UrgencyCritical, nil
+	case "normal":
+		return UrgencyNormal, nil
+	case "low":
+		return UrgencyLow, nil
+	default:
+		return 0, fmt.Errorf("unknown urgency %q, expected low, normal or critical", s)
+	}
+}
+
+// prayerConfig returns the settings of the prayer named name, configured in
+// any case.
+func (c Config) prayerConfig(name string) PrayerConfig {
+	for key, p := range c.Prayers {
+		if strings.EqualFold(key, name) {
+			return p
+		}
+	}
+	return PrayerConfig{}
+}
+
+// validatePrayers checks the per-prayer settings.
+func (c Config) validatePrayers() error {
+	for name, p := range c.Prayers {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("prayers.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// notifies reports whether the prayer is notified at all.
+func (p PrayerConfig) notifies() bool {
+	return p.Notify == nil || *p.Notify
+}
+
+func (p PrayerConfig) urgency() Urgency {
+	u, _ := parseUrgency(p.Urgency)
+	return u
+}
+
+// message returns the custom message for prayer, empty if there is none.
+func (p PrayerConfig) message(name, at string) string {
+	return strings.NewReplacer("{prayer}", name, "{time}", at).Replace(p.Message)
+}
+
+// audio returns the adhan recording to play for prayer, empty if none.
+func (c Config) audio(prayer adhan.Prayer) string {
+	p := c.prayerConfig(prayer.Name)
+
+	enabled := c.Audio.Enabled && prayer.Name != "Sunrise"
+	// Per-prayer settings don't bring the adhan back when headless.
+	if p.Audio != nil && !c.Headless {
+		enabled = *p.Audio
+	}
+	if !enabled {
+		return ""
+	}
+
+	if p.File != "" {
+		return p.File
+	}
+	return c.Audio.File
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"iustusae/adhan/internal/control"
+)
+
+// Profile is a named location with its calculation settings, e.g. "home" or
+// "office", switched to with adhan profile use. Settings left out are those
+// of the config.
+type Profile struct {
+	City      string  `toml:"city"`
+	Country   string  `toml:"country"`
+	Latitude  float64 `toml:"latitude"`
+	Longitude float64 `toml:"longitude"`
+	Timezone  string  `toml:"timezone"`
+	// Method is a pointer since 0 is a valid method.
+	Method       *MethodID     `toml:"method"`
+	School       string        `toml:"school"`
+	CustomMethod *CustomMethod `toml:"custom_method"`
+}
+
+// apply sets the settings of p on cfg. A profile with a location replaces the
+// configured one as a whole, so that the coordinates of one aren't used with
+// the city of the other.
+func (p Profile) apply(cfg *Config) {
+	if p.City != "" || p.Latitude != 0 || p.Longitude != 0 {
+		cfg.City, cfg.Country = p.City, p.Country
+		cfg.Latitude, cfg.Longitude = p.Latitude, p.Longitude
+		cfg.Timezone = p.Timezone
+		cfg.AutoLocate = false
+		cfg.Travel.Enabled = false
+	} else if p.Timezone != "" {
+		cfg.Timezone = p.Timezone
+	}
+	if p.Method != nil {
+		cfg.Method = *p.Method
+	}
+	if p.School != "" {
+		cfg.School = p.School
+	}
+	if p.CustomMethod != nil {
+		cfg.CustomMethod = *p.CustomMethod
+	}
+}
+
+// profilePath returns where the profile in use is kept, usually
+// ~/.config/adhan/profile.
+func profilePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "adhan", "profile"), nil
+}
+
+// activeProfile returns the name of the profile in use, empty if none.
+func activeProfile() (string, error) {
+	path, err := profilePath()
+	if err != nil {
+		return "", err
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(raw)), err
+}
+
+// useProfile applies the profile named on cfg, or nothing if name is empty.
+func useProfile(cfg *Config, name string) error {
+	if name == "" {
+		return nil
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile %q, run `adhan profile list` for the list", name)
+	}
+	p.apply(cfg)
+	cfg.Profile = name
+	return nil
+}
+
+// switchProfile makes name the profile in use, or none if it is empty, and
+// has the running instance, if any, reload its config to reschedule.
+func switchProfile(ctx context.Context, cfg Config, name string) error {
+	if name != "" {
+		if _, ok := cfg.Profiles[name]; !ok {
+			return fmt.Errorf("unknown profile %q, run `adhan profile list` for the list", name)
+		}
+	}
+
+	path, err := profilePath()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		err = os.Remove(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+	} else if err = os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+		err = os.WriteFile(path, []byte(name+"\n"), 0o644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save profile: %w", err)
+	}
+
+	out, err := control.Send(ctx, "reload")
+	switch {
+	case errors.Is(err, control.ErrNotRunning):
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "The running instance keeps the previous settings until restarted:", err)
+	case out != "":
+		fmt.Println(out)
+	}
+	return nil
+}
+
+// printProfiles lists the profiles, marking the one in use.
+func printProfiles(cfg Config) {
+	if len(cfg.Profiles) == 0 {
+		fmt.Println("No profiles configured")
+		return
+	}
+
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		mark := "  "
+		if name == cfg.Profile {
+			mark = "* "
+		}
+		fmt.Println(mark + name)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// printPrompt prints the next prayer and the time left as a single token,
+// e.g. "Asr-1h03m", for shell prompts. It only reads the stored timings, even
+// if they were fetched with other settings, and never the network, so that
+// it doesn't slow the prompt down. Nothing is printed when there is nothing
+// stored.
+func printPrompt(cfg Config, loc *time.Location) {
+	now := clock.Now().In(loc)
+	days, err := storedCalendar(now.Year(), now.Month())
+	if err != nil || now.Day() > len(days) {
+		return
+	}
+
+	today := cfg.withJumuah(days[now.Day()-1], now)
+	next, err := adhan.NextPrayerAcross(today, now, func(date time.Time) (adhan.Day, error) {
+		if date.Month() == now.Month() && date.Day() <= len(days) {
+			return days[date.Day()-1], nil
+		}
+		next, err := storedCalendar(date.Year(), date.Month())
+		if err != nil || len(next) == 0 {
+			return adhan.Day{}, errors.New("next month isn't cached")
+		}
+		return next[0], nil
+	})
+	if err != nil {
+		return
+	}
+
+	countdown := strings.ReplaceAll(formatCountdown(next.Time.Sub(now), false), " ", "")
+	fmt.Printf("%s-%s\n", strings.ReplaceAll(cfg.prayerName(next), "'", ""), countdown)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pushClient sends push notifications.
+var pushClient = &http.Client{Timeout: 10 * time.Second}
+
+// NtfyConfig configures push notifications through ntfy
+// (https://ntfy.sh or a self-hosted server).
+type NtfyConfig struct {
+	// Topic is the topic to publish to, ntfy is disabled when it is empty.
+	Topic  string `toml:"topic"`
+	Server string `toml:"server"`
+	// Token is an access token, for protected topics.
+	Token string `toml:"token"`
+}
+
+// PushoverConfig configures push notifications through Pushover.
+type PushoverConfig struct {
+	// Token is the API token of the application, and User the key of the
+	// user or group to notify. Pushover is disabled unless both are set.
+	Token string `toml:"token"`
+	User  string `toml:"user"`
+	Sound string `toml:"sound"`
+}
+
+// ChatConfig configures posting notifications to a Discord or Slack channel
+// through an incoming webhook.
+type ChatConfig struct {
+	// Webhook is the URL of the incoming webhook, posting is disabled when
+	// it is empty.
+	Webhook string `toml:"webhook"`
+}
+
+// chatNotifier posts notifications to a Discord or Slack channel. Low
+// urgency ones, such as adhan starting, are left out since the channel is
+// usually shared.
+type chatNotifier struct {
+	webhook string
+	// payload builds the JSON body of the webhook from the message text.
+	payload func(title, message string) any
+}
+
+func newDiscordNotifier(cfg ChatConfig) chatNotifier {
+	return chatNotifier{webhook: cfg.Webhook, payload: func(title, message string) any {
+		return map[string]string{"username": "adhan", "content": "**" + title + "**\n" + message}
+	}}
+}
+
+func newSlackNotifier(cfg ChatConfig) chatNotifier {
+	return chatNotifier{webhook: cfg.Webhook, payload: func(title, message string) any {
+		return map[string]string{"text": "*" + title + "*\n" + message}
+	}}
+}
+
+func (n chatNotifier) Notify(notification Notification) error {
+	if notification.Urgency == UrgencyLow {
+		return nil
+	}
+
+	body, err := json.Marshal(n.payload(notification.Title, notification.Message))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, n.webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doPush(req)
+}
+
+// ntfyNotifier publishes notifications to an ntfy topic. Actions are
+// ignored, ntfy can't call back into the daemon.
+type ntfyNotifier struct {
+	cfg NtfyConfig
+}
+
+func (n ntfyNotifier) Notify(notification Notification) error {
+	server := n.cfg.Server
+	if server == "" {
+		server = "https://ntfy.sh"
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(server, "/")+"/"+url.PathEscape(n.cfg.Topic), strings.NewReader(notification.Message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", notification.Title)
+	req.Header.Set("Tags", "mosque")
+	switch notification.Urgency {
+	case UrgencyLow:
+		req.Header.Set("Priority", "low")
+	case UrgencyCritical:
+		req.Header.Set("Priority", "high")
+	}
+	if n.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.cfg.Token)
+	}
+
+	return doPush(req)
+}
+
+// pushoverNotifier sends notifications through the Pushover API.
+type pushoverNotifier struct {
+	cfg PushoverConfig
+}
+
+func (n pushoverNotifier) Notify(notification Notification) error {
+	priority := 0
+	switch notification.Urgency {
+	case UrgencyLow:
+		priority = -1
+	case UrgencyCritical:
+		priority = 1
+	}
+
+	form := url.Values{
+		"token":    {n.cfg.Token},
+		"user":     {n.cfg.User},
+		"title":    {notification.Title},
+		"message":  {notification.Message},
+		"priority": {strconv.Itoa(priority)},
+	}
+	if n.cfg.Sound != "" {
+		form.Set("sound", n.cfg.Sound)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.pushover.net/1/messages.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doPush(req)
+}
+
+func doPush(req *http.Request) error {
+	req.Header.Set("User-Agent", "adhan")
+	resp, err := pushClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: unexpected status %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
+
+// multiNotifier delivers notifications through several notifiers.
+type multiNotifier []Notifier
+
+func (m multiNotifier) Notify(n Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
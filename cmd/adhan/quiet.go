@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// QuietConfig configures do-not-disturb: prayers never notified, and quiet
+// hours during which notifications are silent and the adhan isn't played.
+type QuietConfig struct {
+	// Start and End bound the quiet hours as "22:00" and "06:00", which may
+	// span midnight. There are none when either is empty.
+	Start string `toml:"start"`
+	End   string `toml:"end"`
+	// Except lists the prayers still notified as usual during quiet hours.
+	Except []string `toml:"except"`
+	// Skip lists the prayers that are never notified.
+	Skip []string `toml:"skip"`
+}
+
+// validate checks that the quiet hours are well formed.
+func (q QuietConfig) validate() error {
+	for _, clock := range []string{q.Start, q.End} {
+		if clock == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return fmt.Errorf("invalid quiet hours %q, expected HH:MM", clock)
+		}
+	}
+	return nil
+}
+
+// skips reports whether prayer is never notified.
+func (q QuietConfig) skips(prayer adhan.Prayer) bool {
+	return containsPrayer(q.Skip, prayer.Name)
+}
+
+// silences reports whether prayer falls in the quiet hours and isn't one of
+// the exceptions.
+func (q QuietConfig) silences(prayer adhan.Prayer) bool {
+	if q.Start == "" || q.End == "" || containsPrayer(q.Except, prayer.Name) {
+		return false
+	}
+
+	start, err := time.Parse("15:04", q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", q.End)
+	if err != nil {
+		return false
+	}
+
+	minutes := func(t time.Time) int { return t.Hour()*60 + t.Minute() }
+	t, from, to := minutes(prayer.Time), minutes(start), minutes(end)
+	if from <= to {
+		return t >= from && t < to
+	}
+	// The quiet hours span midnight.
+	return t >= from || t < to
+}
+
+// containsPrayer reports whether name is in names, in any case.
+func containsPrayer(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// ramadan is the number of the month of Ramadan in the Hijri calendar.
+const ramadan = 9
+
+// RamadanConfig configures Ramadan mode, which turns on by itself when the
+// Hijri date falls in Ramadan.
+type RamadanConfig struct {
+	Enabled bool `toml:"enabled"`
+	// SuhoorWarning is how many minutes before Fajr to warn that Suhoor is
+	// about to end.
+	SuhoorWarning int `toml:"suhoor_warning"`
+}
+
+// inRamadan reports whether Ramadan mode applies to day.
+func (c Config) inRamadan(day adhan.Day) bool {
+	return c.Ramadan.Enabled && day.Date.Hijri.Month.Number == ramadan
+}
+
+// suhoorReminders warns that Suhoor is about to end before each Fajr of a
+// day of Ramadan.
+func (s *scheduler) suhoorReminders(ctx context.Context, today adhan.Day, prayers []adhan.Prayer) []adhan.Reminder {
+	if !s.cfg.Ramadan.Enabled || s.cfg.Ramadan.SuhoorWarning <= 0 {
+		return nil
+	}
+
+	var reminders []adhan.Reminder
+	for _, p := range prayers {
+		if p.Name != "Fajr" {
+			continue
+		}
+
+		day := today
+		if p.Time.YearDay() != prayers[0].Time.YearDay() {
+			var err error
+			if day, err = getDay(ctx, s.cfg, p.Time); err != nil {
+				slog.Error("Failed to fetch tomorrow's prayer times", "err", err)
+				continue
+			}
+		}
+		if !s.cfg.inRamadan(day) {
+			continue
+		}
+
+		warning := time.Duration(s.cfg.Ramadan.SuhoorWarning) * time.Minute
+		reminders = append(reminders, adhan.Reminder{
+			Title:   s.cfg.tr("Suhoor"),
+			Message: s.cfg.tr("Suhoor ends in %d minutes, Fajr is at %s.", s.cfg.Ramadan.SuhoorWarning, s.cfg.formatTime(p.Time)),
+			Time:    p.Time.Add(-warning),
+		})
+	}
+	return reminders
+}
+
+// printIftar prints the time left until Iftar when it is still ahead today.
+func printIftar(cfg Config, today adhan.Day, now time.Time) {
+	prayers, err := today.Prayers(now)
+	if err != nil {
+		return
+	}
+	for _, p := range prayers {
+		if p.Name == "Maghrib" && p.Time.After(now) {
+			fmt.Println(cfg.tr("Iftar in %s, at %s", formatCountdown(p.Time.Sub(now), false), cfg.formatTime(p.Time)))
+		}
+	}
+}
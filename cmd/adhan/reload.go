@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay is how long to wait for further changes to the config file
+// before reloading it, since editors often save in several steps.
+const reloadDelay = 500 * time.Millisecond
+
+// reloader loads the config again, the same way as at startup, when the
+// config file changes.
+type reloader struct {
+	path string
+	load func() (Config, error)
+}
+
+// reload returns the config loaded afresh along with its location.
+func (r *reloader) reload() (Config, *time.Location, error) {
+	cfg, err := r.load()
+	if err != nil {
+		return cfg, nil, err
+	}
+	loc, err := cfg.location()
+	if err != nil {
+		return cfg, nil, fmt.Errorf("invalid timezone: %w", err)
+	}
+	return cfg, loc, nil
+}
+
+// watch sends on the returned channel when the config file is written or on
+// SIGHUP, until ctx is done. It never sends if r is nil.
+func (r *reloader) watch(ctx context.Context) <-chan struct{} {
+	if r == nil {
+		return nil
+	}
+
+	changes := make(chan struct{}, 1)
+	changed := func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	// The directory is watched rather than the file, which editors replace
+	// when saving.
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		if err = watcher.Add(filepath.Dir(r.path)); err != nil {
+			watcher.Close()
+		}
+	}
+	if err != nil {
+		slog.Warn("Failed to watch config file, send SIGHUP to reload it", "err", err)
+	} else {
+		events, errs = watcher.Events, watcher.Errors
+	}
+
+	go func() {
+		defer signal.Stop(hup)
+		if events != nil {
+			defer watcher.Close()
+		}
+
+		var settled <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				changed()
+			case e := <-events:
+				if filepath.Clean(e.Name) == filepath.Clean(r.path) && e.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+					settled = time.After(reloadDelay)
+				}
+			case err := <-errs:
+				slog.Error("Failed to watch config file", "err", err)
+			case <-settled:
+				settled = nil
+				changed()
+			}
+		}
+	}()
+
+	return changes
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// RemindersSyncConfig configures syncing to Apple Reminders on macOS, which
+// iCloud brings to the iPhone and Apple Watch.
+type RemindersSyncConfig struct {
+	// List is the name of the list of the reminders, created if needed,
+	// "Prayer Times" by default.
+	List string `toml:"list"`
+}
+
+func (r RemindersSyncConfig) list() string {
+	if r.List == "" {
+		return "Prayer Times"
+	}
+	return r.List
+}
+
+// syncReminders adds a reminder of each prayer of the coming days to a list
+// of Apple Reminders, and removes those of the prayers past.
+func syncReminders(ctx context.Context, cfg Config) error {
+	if runtime.GOOS != "darwin" {
+		return errors.New("Reminders is only available on macOS")
+	}
+	loc, err := cfg.location()
+	if err != nil {
+		return err
+	}
+	now := clock.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	events, err := syncEvents(ctx, cfg, from)
+	if err != nil {
+		return err
+	}
+	stats, err := remindersSync(ctx, cfg.Sync.Reminders.list(), events)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Synced %d days of prayer times to the %s list of Reminders: %s\n", cfg.Sync.days(), cfg.Sync.Reminders.list(), stats)
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// remindersScript syncs the reminders given as JSON to a list of Reminders,
+// through its JavaScript for Automation dictionary. The reminders of adhan
+// are told apart by their notes, "adhan:" followed by the ID of the event;
+// those not given are removed.
+const remindersScript = `
+function run(argv) {
+	const want = JSON.parse(argv[0]);
+	const app = Application("Reminders");
+	let list;
+	const lists = app.lists.whose({name: want.list});
+	if (lists.length > 0) {
+		list = lists[0];
+	} else {
+		list = app.List({name: want.list});
+		app.lists.push(list);
+	}
+
+	const ids = list.reminders.id();
+	const bodies = list.reminders.body();
+	const names = list.reminders.name();
+	const dues = list.reminders.dueDate();
+	const ours = {};
+	for (let i = 0; i < ids.length; i++) {
+		if (bodies[i] && bodies[i].startsWith("adhan:")) {
+			ours[bodies[i]] = {id: ids[i], name: names[i], due: dues[i] ? dues[i].getTime() : 0};
+		}
+	}
+
+	const stats = {added: 0, updated: 0, removed: 0};
+	for (const e of want.events) {
+		const body = "adhan:" + e.id;
+		const have = ours[body];
+		delete ours[body];
+		const due = new Date(e.time);
+		if (!have) {
+			list.reminders.push(app.Reminder({name: e.name, body: body, dueDate: due, remindMeDate: due}));
+			stats.added++;
+		} else if (have.name !== e.name || have.due !== e.time) {
+			const r = list.reminders.byId(have.id);
+			r.name = e.name;
+			r.dueDate = due;
+			r.remindMeDate = due;
+			stats.updated++;
+		}
+	}
+	for (const body in ours) {
+		app.delete(list.reminders.byId(ours[body].id));
+		stats.removed++;
+	}
+	return JSON.stringify(stats);
+}`
+
+func remindersSync(ctx context.Context, list string, events []calendarEvent) (syncStats, error) {
+	type reminder struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		// Time is in milliseconds since the epoch, as JavaScript dates.
+		Time int64 `json:"time"`
+	}
+	want := struct {
+		List   string     `json:"list"`
+		Events []reminder `json:"events"`
+	}{List: list, Events: []reminder{}}
+	for _, e := range events {
+		want.Events = append(want.Events, reminder{ID: e.ID, Name: e.Summary, Time: e.Start.UnixMilli()})
+	}
+	arg, err := json.Marshal(want)
+	if err != nil {
+		return syncStats{}, err
+	}
+
+	// The first run asks for access to Reminders.
+	out, err := exec.CommandContext(ctx, "osascript", "-l", "JavaScript", "-e", remindersScript, string(arg)).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return syncStats{}, fmt.Errorf("failed to sync Reminders: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return syncStats{}, fmt.Errorf("failed to sync Reminders: %w", err)
+	}
+	var stats struct {
+		Added   int `json:"added"`
+		Updated int `json:"updated"`
+		Removed int `json:"removed"`
+	}
+	if err := json.Unmarshal(out, &stats); err != nil {
+		return syncStats{}, fmt.Errorf("failed to sync Reminders: %w", err)
+	}
+	return syncStats{added: stats.Added, updated: stats.Updated, removed: stats.Removed}, nil
+}
@@ -0,0 +1,12 @@
+//go:build !darwin
+
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+func remindersSync(ctx context.Context, list string, events []calendarEvent) (syncStats, error) {
+	return syncStats{}, errors.ErrUnsupported
+}
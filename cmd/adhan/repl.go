@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/peterh/liner"
+
+	"iustusae/adhan/internal/control"
+)
+
+// replCommands are the commands of the interactive prompt, in the order help
+// lists them.
+var replCommands = []struct {
+	Name string
+	Help string
+}{
+	{"next", "show the next prayer and its time"},
+	{"remaining", "show the time left until the next prayer"},
+	{"remaining -w", "live countdown to the next prayer, Ctrl-C to stop"},
+	{"all", "show today's timings along with the Hijri date"},
+	{"date", "show today's Gregorian and Hijri dates"},
+	{"prayed", "mark the current prayer as prayed"},
+	{"stats", "show how many prayers were prayed and streaks"},
+	{"ack", "stop reminding of the current prayer"},
+	{"stop", "silence the adhan"},
+	{"snooze", "silence the adhan and remind again in 10 minutes"},
+	{"help", "show this list"},
+	{"q", "quit"},
+}
+
+func historyPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "adhan", "history"), nil
+}
+
+func completeCommand(line string) []string {
+	var matches []string
+	for _, c := range replCommands {
+		if strings.HasPrefix(c.Name, line) {
+			matches = append(matches, c.Name)
+		}
+	}
+	return matches
+}
+
+func printHelp() {
+	data := make([][]string, 0, len(replCommands))
+	for _, c := range replCommands {
+		data = append(data, []string{c.Name, c.Help})
+	}
+	printTable([]string{"Command", "Description"}, data)
+}
+
+// handleUserInput reads commands from the terminal, with line editing,
+// history that persists across runs and tab completion, until the user quits.
+// The terminal is restored when ctx is done, even while waiting for a
+// command. Commands about the adhan are run by send.
+func handleUserInput(ctx context.Context, cfg Config, loc *time.Location, send control.Handler) {
+	line := liner.NewLiner()
+	line.SetCtrlCAborts(true)
+	line.SetCompleter(completeCommand)
+
+	path, err := historyPath()
+	if err == nil {
+		if f, err := os.Open(path); err == nil {
+			line.ReadHistory(f)
+			f.Close()
+		}
+	}
+
+	var once sync.Once
+	quit := func() {
+		once.Do(func() {
+			if path != "" {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+					if f, err := os.Create(path); err == nil {
+						line.WriteHistory(f)
+						f.Close()
+					}
+				}
+			}
+			line.Close()
+		})
+	}
+	defer quit()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			quit()
+		case <-done:
+		}
+	}()
+
+	for {
+		command, err := line.Prompt("Enter a command (or 'help'): ")
+		if errors.Is(err, liner.ErrPromptAborted) || errors.Is(err, io.EOF) {
+			fmt.Println()
+			return
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Failed to read command", "err", err)
+			}
+			return
+		}
+
+		command = strings.TrimSpace(command)
+		if command == "" {
+			continue
+		}
+		line.AppendHistory(command)
+
+		switch command {
+		case "next":
+			err = printNext(ctx, cfg, loc, false)
+		case "all":
+			err = printAll(ctx, cfg, time.Time{}, false)
+		case "remaining":
+			err = printRemaining(ctx, cfg, loc, false)
+		case "remaining -w":
+			err = printRemaining(ctx, cfg, loc, true)
+		case "date":
+			err = printToday(ctx, cfg)
+		case "prayed":
+			err = markPrayed(ctx, cfg, loc, "")
+		case "stats":
+			err = printStats(loc)
+		case "ack", "stop", "snooze":
+			var out string
+			if out, err = send(ctx, command); out != "" {
+				fmt.Println(out)
+			}
+		case "help", "?":
+			printHelp()
+		case "q", "quit", "exit":
+			return
+		default:
+			fmt.Printf("Unknown command %q, type 'help' for the list of commands\n", command)
+		}
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+	}
+}
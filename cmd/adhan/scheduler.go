@@ -0,0 +1,488 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// snoozeDelay is how long snoozing waits before reminding of the prayer
+// again.
+const snoozeDelay = 10 * time.Minute
+
+// prayerEvent is what the scheduler tells integrations: a prayer time or a
+// reminder arriving, or the next prayer being scheduled (refresh).
+type prayerEvent struct {
+	Type    string        `json:"type"`
+	Prayer  *adhan.Prayer `json:"prayer,omitempty"`
+	Title   string        `json:"title,omitempty"`
+	Message string        `json:"message,omitempty"`
+	Hijri   string        `json:"hijri,omitempty"`
+	Time    time.Time     `json:"time"`
+}
+
+// scheduler notifies and plays the adhan when prayer times arrive.
+type scheduler struct {
+	cfg      Config
+	loc      *time.Location
+	notifier Notifier
+	player   *audioPlayer
+	// listeners are told of every event, for integrations.
+	listeners []func(prayerEvent)
+	// closers release the integrations once the scheduler stops.
+	closers []func()
+	// busy is the calendar of the times in which the user is busy, if any.
+	busy *busyCalendar
+	// checked is the last date whose timetable was compared with the day
+	// before, see checkChanges.
+	checked string
+
+	mu       sync.Mutex
+	reminder *time.Timer
+	// alarm rings before the Fajr at alarmFor, ringing silences it while it
+	// rings.
+	alarm    *time.Timer
+	alarmFor time.Time
+	ringing  context.CancelFunc
+	// pause locks the screen again during the prayer break.
+	pause *time.Timer
+	// castUntil is when the adhan cast is over, see stopCast.
+	castUntil time.Time
+	// acked is when the current prayer was acknowledged under a simulated
+	// clock, which is kept out of the store, see acknowledged.
+	acked time.Time
+}
+
+// newScheduler returns a scheduler telling the integrations configured in
+// cfg of its events, along with extra listeners.
+func newScheduler(cfg Config, loc *time.Location, notifier Notifier, player *audioPlayer, listeners ...func(prayerEvent)) *scheduler {
+	if len(cfg.Webhooks) > 0 {
+		listeners = append(listeners, webhookListener(cfg.Webhooks))
+	}
+	if len(cfg.Hooks) > 0 {
+		listeners = append(listeners, hooksListener(cfg.Hooks))
+	}
+	var closers []func()
+	if cfg.MQTT.Broker != "" {
+		p := newMQTTPublisher(cfg.MQTT)
+		listeners = append(listeners, p.onEvent)
+		closers = append(closers, p.close)
+	}
+	if cfg.GPIO.Pin != "" {
+		if p, err := newGPIOPin(cfg.GPIO); err != nil {
+			slog.Error("Failed to set up GPIO", "err", err)
+		} else {
+			listeners = append(listeners, p.onEvent)
+			closers = append(closers, p.close)
+		}
+	}
+	return &scheduler{
+		cfg:       cfg,
+		loc:       loc,
+		notifier:  notifier,
+		player:    player,
+		listeners: listeners,
+		closers:   closers,
+		busy:      newBusyCalendar(cfg.Busy.Calendar, loc),
+	}
+}
+
+// run schedules prayers until ctx is done.
+func (s *scheduler) run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer func() {
+		s.cancelReminder()
+		s.cancelAlarm()
+		s.endBreak()
+		for _, release := range s.closers {
+			release()
+		}
+	}()
+
+	sched := adhan.Scheduler{
+		Today: func(ctx context.Context) (adhan.Day, error) {
+			return getToday(ctx, s.cfg)
+		},
+		Day: func(ctx context.Context, date time.Time) (adhan.Day, error) {
+			return getDay(ctx, s.cfg, date)
+		},
+		Location: s.loc,
+		Clock:    clock,
+		OnNext: func(today adhan.Day, next adhan.Prayer) {
+			slog.Debug("Scheduled next prayer", "prayer", next.Name, "time", next.Time)
+			fmt.Println(s.cfg.tr("Next prayer: %s, Time: %s", s.cfg.localName(next), s.cfg.formatTime(next.Time)))
+			s.publish(today, "refresh", next, adhan.Reminder{})
+			s.checkChanges(ctx, next)
+			s.scheduleAlarm(ctx, next)
+		},
+		Reminders: s.reminders,
+		OnPrayer: func(today adhan.Day, prayer adhan.Prayer) {
+			if !firstPrayer(prayer) {
+				slog.Debug("Already notified of prayer", "prayer", prayer.Name, "time", prayer.Time)
+				return
+			}
+			s.publish(today, "prayer", prayer, adhan.Reminder{})
+			s.onPrayer(today, prayer)
+		},
+		OnMissed: func(today adhan.Day, prayer adhan.Prayer) {
+			if !firstPrayer(prayer) {
+				slog.Debug("Already notified of prayer", "prayer", prayer.Name, "time", prayer.Time)
+				return
+			}
+			s.publish(today, "missed", prayer, adhan.Reminder{})
+			s.onMissed(prayer)
+		},
+		OnReminder: func(today adhan.Day, r adhan.Reminder) {
+			slog.Debug("Reminder due", "title", r.Title, "time", r.Time)
+			if !firstReminder(r) {
+				slog.Debug("Already notified of reminder", "title", r.Title, "time", r.Time)
+				return
+			}
+			s.publish(today, "reminder", adhan.Prayer{}, r)
+			showNotification(s.notifier, r.Title, r.Message)
+		},
+		OnError: func(err error) {
+			slog.Error("Failed to schedule prayers", "err", err)
+		},
+	}
+	s.resumeSnooze(ctx)
+	sched.Run(ctx)
+}
+
+// publish tells the listeners of an event about prayer, or about r for
+// reminders.
+func (s *scheduler) publish(today adhan.Day, typ string, prayer adhan.Prayer, r adhan.Reminder) {
+	if len(s.listeners) == 0 {
+		return
+	}
+
+	e := prayerEvent{Type: typ, Hijri: today.Date.HijriString(), Time: clock.Now().In(s.loc)}
+	if prayer.Name != "" {
+		prayer.Name = s.cfg.prayerName(prayer)
+		e.Prayer = &prayer
+	}
+	if r.Title != "" {
+		e.Title, e.Message = r.Title, r.Message
+	}
+	for _, listener := range s.listeners {
+		listener(e)
+	}
+}
+
+// reminders returns the reminders to schedule around prayers.
+func (s *scheduler) reminders(ctx context.Context, today adhan.Day, prayers []adhan.Prayer) []adhan.Reminder {
+	var reminders []adhan.Reminder
+	reminders = append(reminders, s.suhoorReminders(ctx, today, prayers)...)
+	reminders = append(reminders, s.jumuahReminders(today, prayers)...)
+	reminders = append(reminders, s.eventReminders(ctx, today, prayers)...)
+	reminders = append(reminders, s.tahajjudReminders(ctx, prayers)...)
+	reminders = append(reminders, s.fastingReminders(ctx, prayers)...)
+	reminders = append(reminders, s.adhkarReminders(prayers)...)
+	return reminders
+}
+
+func (s *scheduler) onPrayer(today adhan.Day, prayer adhan.Prayer) {
+	// Simulations leave the prayer log alone.
+	if !simulated() {
+		if missed, err := recordMissed(prayer); err != nil {
+			slog.Error("Failed to save prayer log", "err", err)
+		} else if missed != "" {
+			slog.Info("Prayer was not marked as prayed, added to qada", "prayer", missed)
+		}
+	}
+
+	// The time of the previous prayer is over, stop reminding of it.
+	s.cancelReminder()
+	settings := s.cfg.prayerConfig(prayer.Name)
+	if s.cfg.Quiet.skips(prayer) || !settings.notifies() {
+		slog.Debug("Not notifying of prayer, as configured", "prayer", prayer.Name)
+		return
+	}
+	quiet := s.cfg.Quiet.silences(prayer)
+	slog.Debug("Prayer time", "prayer", prayer.Name, "time", prayer.Time, "quiet", quiet)
+	// Lights are silent, they show during quiet hours and while busy too.
+	s.showPrayerLights(prayer)
+
+	message := s.cfg.tr("It's time for %s prayer.", s.cfg.localName(prayer))
+	if prayer.Name == "Maghrib" && s.cfg.inRamadan(today) {
+		message = s.cfg.tr("It's time for Maghrib prayer and to break your fast.")
+	}
+	if custom := settings.message(s.cfg.localName(prayer), s.cfg.formatTime(prayer.Time)); custom != "" {
+		message = custom
+	} else if hijri := today.Date.HijriString(); hijri != "" {
+		message += " " + hijri
+	}
+	if quiet {
+		// Low urgency notifications are silent.
+		notify(s.notifier, Notification{Title: s.cfg.tr("Prayer Time"), Message: message, Urgency: UrgencyLow})
+		return
+	}
+	if until, busy := s.busyUntil(prayer.Time); busy {
+		// Silent for now, the reminder catches up once no longer busy.
+		slog.Info("Busy, notifying of prayer silently", "prayer", prayer.Name, "until", until)
+		notify(s.notifier, Notification{Title: s.cfg.tr("Prayer Time"), Message: message, Urgency: UrgencyLow})
+		s.remindIn(prayer, busyWait(until))
+		return
+	}
+
+	s.notifyPrayer(prayer, message)
+	s.playAdhan(prayer)
+	s.castAdhan(prayer)
+	s.startBreak(prayer)
+	if repeat := s.cfg.Notifications.Repeat; repeat > 0 && prayer.Name != "Sunrise" {
+		s.remindIn(prayer, time.Duration(repeat)*time.Minute)
+	}
+}
+
+// onMissed lets the user know that the time of prayer came while the
+// machine was asleep, without the adhan since it is late.
+func (s *scheduler) onMissed(prayer adhan.Prayer) {
+	slog.Debug("Prayer time passed while asleep", "prayer", prayer.Name, "time", prayer.Time)
+	s.cancelReminder()
+	if s.cfg.Quiet.skips(prayer) || !s.cfg.prayerConfig(prayer.Name).notifies() {
+		return
+	}
+	s.notifyPrayer(prayer, s.cfg.tr("While the computer was asleep, it became time for %s prayer at %s.", s.cfg.localName(prayer), s.cfg.formatTime(prayer.Time)))
+}
+
+// notifyPrayer sends a notification for prayer, critical unless configured
+// otherwise, with actions to snooze, mark it as prayed or dismiss it.
+func (s *scheduler) notifyPrayer(prayer adhan.Prayer, message string) {
+	settings := s.cfg.prayerConfig(prayer.Name)
+	notify(s.notifier, Notification{
+		Title:   s.cfg.tr("Prayer Time"),
+		Message: message,
+		Urgency: settings.urgency(),
+		Sound:   settings.Sound,
+		Actions: []Action{
+			{Key: "snooze", Label: s.cfg.tr("Snooze 10m")},
+			{Key: "prayed", Label: s.cfg.tr("Mark prayed")},
+			{Key: "ack", Label: s.cfg.tr("Dismiss")},
+		},
+		OnAction: func(key string) {
+			switch key {
+			case "snooze":
+				s.snooze(prayer)
+			case "prayed":
+				s.markPrayed(prayer)
+				s.ack()
+			case "ack":
+				s.ack()
+			}
+		},
+	})
+}
+
+// remindIn notifies of prayer again after d, or once no longer busy, and
+// then every repeat minutes until it is acknowledged. It replaces any pending
+// reminder.
+func (s *scheduler) remindIn(prayer adhan.Prayer, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.reminder != nil {
+		s.reminder.Stop()
+	}
+	s.reminder = clock.AfterFunc(d, func() {
+		if s.acknowledged(prayer.Time) {
+			return
+		}
+		if until, busy := s.busyUntil(clock.Now()); busy {
+			s.remindIn(prayer, busyWait(until))
+			return
+		}
+		s.notifyPrayer(prayer, s.cfg.tr("Reminder: it's time for %s prayer.", s.cfg.localName(prayer)))
+		if !simulated() {
+			if err := clearSnooze(prayer); err != nil {
+				slog.Error("Failed to clear the snooze", "err", err)
+			}
+		}
+		if repeat := s.cfg.Notifications.Repeat; repeat > 0 {
+			s.remindIn(prayer, time.Duration(repeat)*time.Minute)
+		}
+	})
+}
+
+// cancelReminder stops the pending reminder, if any.
+func (s *scheduler) cancelReminder() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.reminder != nil {
+		s.reminder.Stop()
+		s.reminder = nil
+	}
+}
+
+// ack silences the adhan or the Fajr alarm, stops reminding of the current
+// prayer and ends the prayer break.
+func (s *scheduler) ack() {
+	s.dismissAlarm()
+	s.player.stop()
+	s.stopCast()
+	s.cancelReminder()
+	s.endBreak()
+	if simulated() {
+		s.mu.Lock()
+		s.acked = clock.Now()
+		s.mu.Unlock()
+		return
+	}
+	if err := acknowledge(); err != nil {
+		slog.Error("Failed to acknowledge", "err", err)
+	}
+}
+
+// acknowledged reports whether the prayer due at t was acknowledged, in the
+// store or, under a simulated clock, by this scheduler only.
+func (s *scheduler) acknowledged(t time.Time) bool {
+	if !simulated() {
+		return acknowledged(t)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !s.acked.Before(t.Truncate(time.Second))
+}
+
+// markPrayed records prayer in the prayer log.
+func (s *scheduler) markPrayed(prayer adhan.Prayer) {
+	if simulated() {
+		return
+	}
+	name, err := fardName(prayer.Name)
+	if err != nil {
+		return
+	}
+	if err := logPrayer(prayer.Time, name); err != nil {
+		slog.Error("Failed to save prayer log", "err", err)
+		return
+	}
+	slog.Info("Marked prayer as prayed", "prayer", name)
+}
+
+// control runs a command sent over the control socket, or typed at the
+// interactive prompt, and returns what to print.
+func (s *scheduler) control(ctx context.Context, command string) (string, error) {
+	switch command {
+	case "stop":
+		dismissed := s.dismissAlarm()
+		stopped := s.player.stop()
+		if !s.stopCast() && !dismissed && !stopped {
+			return "Nothing is playing", nil
+		}
+		return "", nil
+	case "ack":
+		s.ack()
+		return "", nil
+	case "snooze":
+		today, err := getToday(ctx, s.cfg)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch prayer times: %w", err)
+		}
+		prayer, ok := currentPrayer(today, clock.Now().In(s.loc))
+		if !ok {
+			s.player.stop()
+			s.stopCast()
+			return "", nil
+		}
+		s.snooze(prayer)
+		return s.cfg.tr("Reminding of %s again in %d minutes", s.cfg.localName(prayer), int(snoozeDelay.Minutes())), nil
+	case "next", "remaining", "today":
+		today, err := getToday(ctx, s.cfg)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch prayer times: %w", err)
+		}
+		now := clock.Now().In(s.loc)
+		if command == "today" {
+			var b strings.Builder
+			renderTable(&b, []string{s.cfg.tr("Prayer"), s.cfg.tr("Time")}, timingsTable(s.cfg, today, now))
+			return strings.TrimSuffix(b.String(), "\n"), nil
+		}
+
+		next, err := nextPrayer(ctx, s.cfg, today, now)
+		if err != nil {
+			return "", err
+		}
+		if command == "remaining" {
+			return s.cfg.tr("%s in %s", s.cfg.localName(next), formatCountdown(next.Time.Sub(now), false)), nil
+		}
+		return s.cfg.tr("Next prayer: %s, Time: %s", s.cfg.localName(next), s.cfg.formatTime(next.Time)), nil
+	case "status":
+		today, err := getToday(ctx, s.cfg)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch prayer times: %w", err)
+		}
+		next, err := nextPrayer(ctx, s.cfg, today, clock.Now().In(s.loc))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Running as pid %d, next prayer: %s at %s", os.Getpid(), s.cfg.prayerName(next), s.cfg.formatTime(next.Time)), nil
+	default:
+		return "", fmt.Errorf("unknown command %q", command)
+	}
+}
+
+// snooze silences the adhan and reminds of prayer again after snoozeDelay.
+func (s *scheduler) snooze(prayer adhan.Prayer) {
+	s.player.stop()
+	s.stopCast()
+	s.remindIn(prayer, snoozeDelay)
+	if simulated() {
+		return
+	}
+	if err := saveSnooze(prayer, clock.Now().Add(snoozeDelay)); err != nil {
+		slog.Error("Failed to save the snooze", "err", err)
+	}
+}
+
+// playAdhan announces prayer and plays the configured adhan for it. There is
+// neither for sunrise unless configured otherwise.
+func (s *scheduler) playAdhan(prayer adhan.Prayer) {
+	// The Fajr alarm rings on until dismissed.
+	if s.alarmRinging() {
+		return
+	}
+	var cmds []*exec.Cmd
+	if s.cfg.Speech.Enabled && prayer.Name != "Sunrise" {
+		text := strings.ReplaceAll(s.cfg.Speech.Message, "{prayer}", s.cfg.localName(prayer))
+		cmd, err := speechCommand(text, s.cfg.Speech.Voice)
+		if err != nil {
+			slog.Error("Failed to announce prayer", "err", err)
+		} else {
+			cmds = append(cmds, cmd)
+		}
+	}
+	if file := s.cfg.audio(prayer); file != "" {
+		cmd, err := playerCommand(file)
+		if err != nil {
+			slog.Error("Failed to play adhan", "err", err)
+		} else {
+			cmds = append(cmds, cmd)
+		}
+	}
+	if len(cmds) == 0 {
+		return
+	}
+
+	var done func()
+	if s.cfg.Audio.PauseMedia {
+		resume, err := pauseMedia()
+		if err != nil {
+			slog.Error("Failed to pause media", "err", err)
+		} else if s.cfg.Audio.ResumeMedia {
+			done = resume
+		}
+	}
+
+	if err := s.player.start(done, cmds...); err != nil {
+		slog.Error("Failed to play adhan", "err", err)
+	}
+}
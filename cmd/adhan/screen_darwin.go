@@ -0,0 +1,9 @@
+package main
+
+import "os/exec"
+
+// screenCommand puts the display to sleep, which also locks the screen when
+// a password is required right after the display turns off.
+func screenCommand(mode string) (*exec.Cmd, error) {
+	return exec.Command("pmset", "displaysleepnow"), nil
+}
@@ -0,0 +1,16 @@
+package main
+
+import "os/exec"
+
+func screenCommand(mode string) (*exec.Cmd, error) {
+	if mode == "off" {
+		return lookCommand([][]string{
+			{"xset", "dpms", "force", "off"},
+			{"xdg-screensaver", "activate"},
+		})
+	}
+	return lookCommand([][]string{
+		{"loginctl", "lock-session"},
+		{"xdg-screensaver", "lock"},
+	})
+}
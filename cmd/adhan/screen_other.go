@@ -0,0 +1,12 @@
+//go:build !darwin && !linux && !windows
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func screenCommand(mode string) (*exec.Cmd, error) {
+	return nil, errors.New("locking the screen is not supported on this platform")
+}
@@ -0,0 +1,12 @@
+package main
+
+import "os/exec"
+
+func screenCommand(mode string) (*exec.Cmd, error) {
+	if mode == "off" {
+		// Broadcasts SC_MONITORPOWER, turning the monitors off.
+		script := `(Add-Type -MemberDefinition '[DllImport("user32.dll")] public static extern int SendMessage(int hWnd, int hMsg, int wParam, int lParam);' -Name Monitor -PassThru)::SendMessage(0xFFFF, 0x0112, 0xF170, 2)`
+		return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script), nil
+	}
+	return exec.Command("rundll32.exe", "user32.dll,LockWorkStation"), nil
+}
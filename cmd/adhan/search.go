@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"iustusae/adhan/internal/geo"
+)
+
+// searchLimit is how many cities adhan search offers at most.
+const searchLimit = 10
+
+// searchCity looks up the cities whose name is close to query in the city
+// database, and writes the one the user picks into the config file at path.
+func searchCity(cfg Config, path, query string) error {
+	dbPath, err := cityDBPath()
+	if err != nil {
+		return err
+	}
+	cities, err := geo.Search(dbPath, query, searchLimit)
+	if err != nil {
+		return fmt.Errorf("failed to read city database: %w", err)
+	}
+	if len(cities) == 0 {
+		return fmt.Errorf("no city matches %q, run `adhan cities update` to search more of them", query)
+	}
+
+	data := make([][]string, len(cities))
+	for i, c := range cities {
+		data[i] = []string{
+			strconv.Itoa(i + 1),
+			c.Name,
+			c.Country,
+			c.Timezone,
+			fmt.Sprintf("%.4f, %.4f", c.Latitude, c.Longitude),
+		}
+	}
+	printTable([]string{"#", "City", "Country", "Time zone", "Coordinates"}, data)
+
+	fmt.Printf("Pick a city, 1-%d, or press Enter to cancel: ", len(cities))
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return err
+	}
+	n, err := strconv.Atoi(line)
+	if err != nil || n < 1 || n > len(cities) {
+		return fmt.Errorf("invalid choice %q", line)
+	}
+	city := cities[n-1]
+
+	keys := []configKey{
+		{"city", city.Name},
+		{"country", city.Country},
+		{"latitude", city.Latitude},
+		{"longitude", city.Longitude},
+		{"timezone", city.Timezone},
+	}
+	if cfg.AutoLocate {
+		keys = append(keys, configKey{"auto_locate", false})
+	}
+	if err := setConfigKeys(path, keys...); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved %s, %s to %s\n", city.Name, city.Country, path)
+	if cfg.Profile != "" {
+		fmt.Printf("Profile %s is in use, run `adhan profile reset` to use it\n", cfg.Profile)
+	}
+	return nil
+}
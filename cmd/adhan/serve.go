@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+//go:embed web
+var web embed.FS
+
+// webFS returns the dashboard served at the root.
+func webFS() fs.FS {
+	sub, err := fs.Sub(web, "web")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// server exposes the timings over HTTP, for dashboards and other devices on
+// the network, along with a dashboard of its own.
+type server struct {
+	cfg Config
+	loc *time.Location
+	hub *hub
+}
+
+// todayJSON is the JSON form of a day of timings, shared by `all --json` and
+// /api/today.
+type todayJSON struct {
+	Date          string        `json:"date,omitempty"`
+	Hijri         string        `json:"hijri,omitempty"`
+	Readable      string        `json:"readable,omitempty"`
+	HijriReadable string        `json:"hijri_readable,omitempty"`
+	Timings       adhan.Timings `json:"timings"`
+}
+
+func newTodayJSON(day adhan.Day) todayJSON {
+	return todayJSON{
+		Date:          day.Date.Gregorian.Date,
+		Hijri:         day.Date.Hijri.Date,
+		Readable:      day.Date.Readable,
+		HijriReadable: day.Date.HijriString(),
+		Timings:       day.Timings,
+	}
+}
+
+// nextJSON is the next prayer along with the seconds left until it.
+type nextJSON struct {
+	adhan.Prayer
+	Remaining int `json:"remaining"`
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/next", s.handleNext)
+	mux.HandleFunc("/api/today", s.handleToday)
+	mux.HandleFunc("/api/calendar", s.handleCalendar)
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.Handle("/", http.FileServer(http.FS(webFS())))
+	return mux
+}
+
+func (s *server) handleNext(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+
+	today, err := getToday(r.Context(), s.cfg)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to fetch prayer times: %w", err))
+		return
+	}
+	now := clock.Now().In(s.loc)
+	next, err := nextPrayer(r.Context(), s.cfg, today, now)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	next.Name = s.cfg.prayerName(next)
+	writeJSON(w, nextJSON{Prayer: next, Remaining: int(next.Time.Sub(now).Seconds())})
+}
+
+func (s *server) handleToday(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+
+	today, err := getToday(r.Context(), s.cfg)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to fetch prayer times: %w", err))
+		return
+	}
+	writeJSON(w, newTodayJSON(today))
+}
+
+// handleCalendar serves a month of timings, the current one unless the year
+// and month query parameters say otherwise.
+func (s *server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+
+	param := func(name string) (int, error) {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q", name, v)
+		}
+		return n, nil
+	}
+	year, err := param("year")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	month, err := param("month")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	y, m, err := monthOrCurrent(s.loc, year, month)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	days, err := loadCalendar(r.Context(), s.cfg, y, m)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("failed to fetch calendar: %w", err))
+		return
+	}
+	writeJSON(w, days)
+}
+
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	w.Header().Set("Allow", "GET, HEAD")
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	// Let dashboards served from elsewhere query the API.
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write response", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
+
+// serve notifies at prayer times like the daemon, and listens on addr until
+// the server fails or ctx is done.
+func serve(ctx context.Context, cfg Config, loc *time.Location, addr string) error {
+	s := &server{cfg: cfg, loc: loc, hub: newHub()}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		// Requests are cancelled along with ctx, which also ends the event
+		// streams.
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	inst, err := lockInstance()
+	if err != nil {
+		return err
+	}
+	defer inst.Close()
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	slog.Info("Serving prayer times", "url", "http://"+ln.Addr().String())
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	player := &audioPlayer{}
+	sched := newScheduler(cfg, loc, newNotifier(cfg.Notifications), player, s.hub.publish)
+	go sched.run(ctx, &wg)
+	go inst.Serve(ctx, sched.control)
+
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdown); err != nil {
+			slog.Error("Failed to shut down server", "err", err)
+		}
+	}()
+
+	err = srv.Serve(ln)
+	cancel()
+	player.stop()
+	closeCastServer()
+	wg.Wait()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var errNoService = errors.New("services are only supported on Windows, see adhan install")
+
+func installService(configPath string) error {
+	return errNoService
+}
+
+func uninstallService() error {
+	return errNoService
+}
+
+func runService(ctx context.Context, cfg Config, loc *time.Location, r *reloader) error {
+	return errNoService
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const serviceName = "adhan"
+
+// installService registers adhan as a Windows service started at boot, which
+// runs the daemon with the given config file.
+func installService(configPath string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	// Services run in the system directory.
+	if configPath, err = filepath.Abs(configPath); err != nil {
+		return err
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(serviceName); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s already exists", serviceName)
+	}
+
+	s, err := m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName: "Adhan",
+		Description: "Notifies at prayer times.",
+		StartType:   mgr.StartAutomatic,
+	}, "--config", configPath, "service", "run")
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	return s.Start()
+}
+
+func uninstallService() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", serviceName)
+	}
+	defer s.Close()
+
+	s.Control(svc.Stop)
+	return s.Delete()
+}
+
+// runService runs the daemon under the service control manager, until it
+// stops the service or ctx is done.
+func runService(ctx context.Context, cfg Config, loc *time.Location, r *reloader) error {
+	return svc.Run(serviceName, &windowsService{ctx: ctx, cfg: cfg, loc: loc, reloader: r})
+}
+
+type windowsService struct {
+	ctx      context.Context
+	cfg      Config
+	loc      *time.Location
+	reloader *reloader
+}
+
+func (ws *windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	ctx, cancel := context.WithCancel(ws.ctx)
+	done := make(chan struct{})
+	go func() {
+		if err := runDaemon(ctx, ws.cfg, ws.loc, ws.reloader); err != nil {
+			slog.Error("Failed to run daemon", "err", err)
+		}
+		close(done)
+	}()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case r := <-requests:
+			switch r.Cmd {
+			case svc.Interrogate:
+				status <- r.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+				<-done
+				return false, 0
+			}
+		case <-done:
+			cancel()
+			return false, 0
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// snoozeState is the snoozed prayer, kept in the store so that the daemon
+// still reminds of it when restarted meanwhile.
+type snoozeState struct {
+	Prayer adhan.Prayer `json:"prayer"`
+	Until  time.Time    `json:"until"`
+}
+
+// saveSnooze records that prayer is snoozed until until.
+func saveSnooze(prayer adhan.Prayer, until time.Time) error {
+	return updateStore(func(tx *bolt.Tx) error {
+		return putJSON(tx, stateBucket, snoozeKey, snoozeState{Prayer: prayer, Until: until})
+	})
+}
+
+// clearSnooze forgets the snooze of prayer, once reminded of it.
+func clearSnooze(prayer adhan.Prayer) error {
+	return updateStore(func(tx *bolt.Tx) error {
+		var snoozed snoozeState
+		found, err := getJSON(tx, stateBucket, snoozeKey, &snoozed)
+		if err != nil || !found || !snoozed.Prayer.Time.Equal(prayer.Time) {
+			return err
+		}
+		return tx.Bucket(stateBucket).Delete(snoozeKey)
+	})
+}
+
+// resumeSnooze reminds of the prayer snoozed before the scheduler started,
+// when it is still the current prayer and wasn't acknowledged since.
+func (s *scheduler) resumeSnooze(ctx context.Context) {
+	if simulated() {
+		return
+	}
+	var (
+		snoozed snoozeState
+		found   bool
+	)
+	err := viewStore(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx, stateBucket, snoozeKey, &snoozed)
+		return err
+	})
+	if err != nil {
+		slog.Error("Failed to read the snoozed prayer", "err", err)
+		return
+	}
+	if !found || acknowledged(snoozed.Prayer.Time) {
+		return
+	}
+
+	today, err := getToday(ctx, s.cfg)
+	if err != nil {
+		slog.Error("Failed to fetch prayer times", "err", err)
+		return
+	}
+	now := clock.Now().In(s.loc)
+	current, ok := currentPrayer(today, now)
+	if !ok || current.Name != snoozed.Prayer.Name || !current.Time.Equal(snoozed.Prayer.Time) {
+		return
+	}
+	slog.Info("Resuming snooze", "prayer", snoozed.Prayer.Name, "until", snoozed.Until)
+	s.remindIn(snoozed.Prayer, max(snoozed.Until.Sub(now), 0))
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+const (
+	// statusSoon is how close the next prayer has to be for status bars to
+	// highlight it.
+	statusSoon = 15 * time.Minute
+	// statusImminent is how close it has to be for the tmux segment to turn
+	// red.
+	statusImminent = 5 * time.Minute
+)
+
+// statusLine is the compact form of the next prayer shown in status bars,
+// e.g. "Asr 17:42 (-1h03m)", with the time formatted as configured.
+func statusLine(cfg Config, next adhan.Prayer, now time.Time) string {
+	countdown := strings.ReplaceAll(formatCountdown(next.Time.Sub(now), false), " ", "")
+	return fmt.Sprintf("%s %s (-%s)", cfg.localName(next), cfg.formatTime(next.Time), countdown)
+}
+
+// printStatus prints a single line about the next prayer for status bars,
+// in the given format. It only hits the network when the month isn't
+// cached yet, so it can be called every few seconds.
+func printStatus(ctx context.Context, cfg Config, loc *time.Location, format string) error {
+	today, err := getToday(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to fetch prayer times: %w", err)
+	}
+	now := clock.Now().In(loc)
+	next, err := nextPrayer(ctx, cfg, today, now)
+	if err != nil {
+		return err
+	}
+
+	line := statusLine(cfg, next, now)
+	soon := next.Time.Sub(now) <= statusSoon
+
+	switch format {
+	case "plain":
+		fmt.Println(line)
+	case "polybar":
+		if soon {
+			line = "%{F#e3b448}" + line + "%{F-}"
+		}
+		fmt.Println(line)
+	case "waybar":
+		class := "normal"
+		if soon {
+			class = "soon"
+		}
+		return json.NewEncoder(os.Stdout).Encode(struct {
+			Text    string `json:"text"`
+			Tooltip string `json:"tooltip"`
+			Alt     string `json:"alt"`
+			Class   string `json:"class"`
+		}{line, timingsTooltip(cfg, today, now), strings.ToLower(next.Name), class})
+	case "tmux":
+		colour := "green"
+		switch left := next.Time.Sub(now); {
+		case left <= statusImminent:
+			colour = "red"
+		case soon:
+			colour = "yellow"
+		}
+		fmt.Printf("#[fg=%s]%s#[default]\n", colour, line)
+	case "xbar":
+		printXbar(cfg, today, next, now, line, soon)
+	default:
+		return fmt.Errorf("unknown format %q, expected plain, polybar, waybar, xbar or tmux", format)
+	}
+	return nil
+}
+
+// timingsTooltip lists the day's timings, one per line.
+func timingsTooltip(cfg Config, today adhan.Day, now time.Time) string {
+	prayers, err := today.Prayers(now)
+	if err != nil {
+		return ""
+	}
+
+	lines := make([]string, 0, len(prayers)+1)
+	if hijri := today.Date.HijriString(); hijri != "" {
+		lines = append(lines, hijri)
+	}
+	for _, p := range prayers {
+		lines = append(lines, fmt.Sprintf("%-8s %s", cfg.localName(p), cfg.formatTime(p.Time)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// printXbar prints the xbar and SwiftBar plugin format: the summary shown in
+// the menu bar, then the dropdown with the day's timings, the next prayer in
+// bold.
+func printXbar(cfg Config, today adhan.Day, next adhan.Prayer, now time.Time, line string, soon bool) {
+	if soon {
+		fmt.Printf("%s | color=#e3b448\n", line)
+	} else {
+		fmt.Println(line)
+	}
+	fmt.Println("---")
+
+	if hijri := today.Date.HijriString(); hijri != "" {
+		fmt.Println(hijri)
+		fmt.Println("---")
+	}
+
+	prayers, err := today.Prayers(now)
+	if err != nil {
+		return
+	}
+	for _, p := range prayers {
+		entry := fmt.Sprintf("%-8s %s", cfg.localName(p), cfg.formatTime(p.Time))
+		switch {
+		case p.Time.Equal(next.Time):
+			fmt.Printf("%s | font=Menlo-Bold\n", entry)
+		case p.Time.Before(now):
+			fmt.Printf("%s | font=Menlo color=gray\n", entry)
+		default:
+			fmt.Printf("%s | font=Menlo\n", entry)
+		}
+	}
+}
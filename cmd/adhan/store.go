@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets of the store.
+var (
+	// calendarsBucket holds the months of timings, by month as "2024-03".
+	calendarsBucket = []byte("calendars")
+	// stateBucket holds single values: the prayer log, when the prayer
+	// notifications were last acknowledged and the pending snooze.
+	stateBucket = []byte("state")
+	// notifiedBucket holds the events notified of, with their time.
+	notifiedBucket = []byte("notified")
+)
+
+// Keys of the state bucket.
+var (
+	logKey    = []byte("log")
+	ackKey    = []byte("ack")
+	snoozeKey = []byte("snooze")
+)
+
+// storeTimeout is how long to wait for another instance of adhan to be done
+// with the store, which only one may open at a time.
+const storeTimeout = 5 * time.Second
+
+// storePath returns where the store is kept, usually
+// ~/.config/adhan/adhan.db. It lives next to the config rather than in the
+// cache since the prayer log can't be fetched again.
+func storePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "adhan", "adhan.db"), nil
+}
+
+// openStore opens the store, creating it the first time along with its
+// buckets. The store is only open for as long as a transaction lasts, so
+// that the commands run alongside the daemon get their turn.
+func openStore() (*bolt.DB, error) {
+	path, err := storePath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: storeTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	var ready bool
+	db.View(func(tx *bolt.Tx) error {
+		ready = tx.Bucket(stateBucket) != nil
+		return nil
+	})
+	if ready {
+		return db, nil
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{calendarsBucket, stateBucket, notifiedBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return importPrayerLog(tx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set up %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// importPrayerLog moves the prayer log kept in prayers.json by earlier
+// versions into the store, leaving the file as a backup.
+func importPrayerLog(tx *bolt.Tx) error {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "adhan", "prayers.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var l prayerLog
+	if err := json.Unmarshal(raw, &l); err != nil {
+		return fmt.Errorf("failed to read prayer log: %w", err)
+	}
+	return putJSON(tx, stateBucket, logKey, l)
+}
+
+// viewStore runs fn in a read-only transaction of the store.
+func viewStore(fn func(tx *bolt.Tx) error) error {
+	db, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+// updateStore runs fn in a read-write transaction of the store, committed
+// unless fn fails.
+func updateStore(fn func(tx *bolt.Tx) error) error {
+	db, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+// getJSON decodes the value of key in bucket into v, and reports whether
+// there is one.
+func getJSON(tx *bolt.Tx, bucket, key []byte, v any) (bool, error) {
+	raw := tx.Bucket(bucket).Get(key)
+	if raw == nil {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("failed to read %s %s: %w", bucket, key, err)
+	}
+	return true, nil
+}
+
+// putJSON sets the value of key in bucket to v encoded as JSON.
+func putJSON(tx *bolt.Tx, bucket, key []byte, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucket).Put(key, raw)
+}
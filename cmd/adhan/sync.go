@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// SyncConfig configures syncing the prayer times to an online calendar, so
+// that they show on every device the calendar is on.
+type SyncConfig struct {
+	// Days is how many days ahead to sync, 30 by default.
+	Days int `toml:"days"`
+	// Duration is how many minutes the events last, 20 by default.
+	Duration int `toml:"duration"`
+	// Prayers limits the events to some prayers, all but Sunrise by default.
+	Prayers   []string            `toml:"prayers"`
+	Google    GoogleSyncConfig    `toml:"google"`
+	CalDAV    CalDAVSyncConfig    `toml:"caldav"`
+	Reminders RemindersSyncConfig `toml:"reminders"`
+	Log       LogSyncConfig       `toml:"log"`
+}
+
+func (s SyncConfig) days() int {
+	if s.Days <= 0 {
+		return 30
+	}
+	return s.Days
+}
+
+func (s SyncConfig) duration() time.Duration {
+	if s.Duration <= 0 {
+		return 20 * time.Minute
+	}
+	return time.Duration(s.Duration) * time.Minute
+}
+
+// includes reports whether to sync prayer.
+func (s SyncConfig) includes(prayer string) bool {
+	if len(s.Prayers) > 0 {
+		return containsPrayer(s.Prayers, prayer)
+	}
+	return prayer != "Sunrise"
+}
+
+// calendarEvent is a prayer time in a synced calendar.
+type calendarEvent struct {
+	// ID is unique to the prayer and its date, as "20240105-fajr", so that
+	// syncing again updates the event in place.
+	ID      string
+	Summary string
+	Start   time.Time
+	End     time.Time
+}
+
+// syncEvents returns the events of the prayers of the days to sync, starting
+// with that of from.
+func syncEvents(ctx context.Context, cfg Config, from time.Time) ([]calendarEvent, error) {
+	var events []calendarEvent
+	for i := 0; i < cfg.Sync.days(); i++ {
+		date := from.AddDate(0, 0, i)
+		day, err := getDay(ctx, cfg, date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch prayer times: %w", err)
+		}
+		prayers, err := day.Prayers(date)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range prayers {
+			if !cfg.Sync.includes(p.Name) {
+				continue
+			}
+			events = append(events, calendarEvent{
+				ID:      date.Format("20060102") + "-" + strings.ToLower(p.Name),
+				Summary: cfg.localName(p),
+				Start:   p.Time,
+				End:     p.Time.Add(cfg.Sync.duration()),
+			})
+		}
+	}
+	return events, nil
+}
+
+// syncStats counts the changes made to a calendar.
+type syncStats struct {
+	added, updated, removed int
+}
+
+func (s syncStats) String() string {
+	return fmt.Sprintf("%d added, %d updated, %d removed", s.added, s.updated, s.removed)
+}
+
+// newSyncCommand returns the sync command, cfg being set by the time it runs.
+func newSyncCommand(cfg *Config) *cobra.Command {
+	sync := &cobra.Command{
+		Use:   "sync",
+		Short: "Sync the prayer times of the coming days to an online calendar, or the prayer log across devices",
+	}
+	sync.AddCommand(&cobra.Command{
+		Use:   "gcal",
+		Short: "Sync to a Prayer Times calendar of Google Calendar, signing in the first time",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return syncGoogle(cmd.Context(), *cfg)
+		},
+	})
+	sync.AddCommand(&cobra.Command{
+		Use:   "caldav",
+		Short: "Sync to a calendar of a CalDAV server, as Nextcloud, Fastmail or iCloud",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return syncCalDAV(cmd.Context(), *cfg)
+		},
+	})
+	sync.AddCommand(&cobra.Command{
+		Use:   "reminders",
+		Short: "Sync to a Prayer Times list of Apple Reminders, on macOS",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return syncReminders(cmd.Context(), *cfg)
+		},
+	})
+	sync.AddCommand(&cobra.Command{
+		Use:   "log",
+		Short: "Merge the prayer log with that of a git repository or gist, and update both",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return syncLog(cmd.Context(), cfg.Sync.Log)
+		},
+	})
+	return sync
+}
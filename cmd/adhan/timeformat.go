@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// localeLayouts are the clock layouts of the locales that don't write times
+// as "15:04", by language_TERRITORY or language.
+var localeLayouts = map[string]string{
+	"en_US": "3:04 PM",
+	"en_CA": "3:04 PM",
+	"en_AU": "3:04 PM",
+	"en_NZ": "3:04 PM",
+	"en_IN": "3:04 PM",
+	"en_PK": "3:04 PM",
+	"en_PH": "3:04 PM",
+	"hi":    "3:04 PM",
+	"ur":    "3:04 PM",
+	"bn":    "3:04 PM",
+	"fil":   "3:04 PM",
+	"da":    "15.04",
+	"fi":    "15.04",
+	"id":    "15.04",
+}
+
+// locale returns the locale of the environment, e.g. "en_US", from
+// LC_ALL, LC_TIME or LANG.
+func locale() string {
+	for _, env := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if v := os.Getenv(env); v != "" {
+			// Drop the encoding and modifier, as in en_US.UTF-8@euro.
+			v, _, _ = strings.Cut(v, ".")
+			v, _, _ = strings.Cut(v, "@")
+			return v
+		}
+	}
+	return ""
+}
+
+// timeLayout returns the layout times are shown with: "15:04" for 24h, the
+// default, "3:04 PM" for 12h, that of the locale, or a Go layout given as is.
+func (c Config) timeLayout() (string, error) {
+	switch strings.ToLower(c.TimeFormat) {
+	case "", "24h":
+		return "15:04", nil
+	case "12h":
+		return "3:04 PM", nil
+	case "locale":
+		loc := locale()
+		if layout, ok := localeLayouts[loc]; ok {
+			return layout, nil
+		}
+		lang, _, _ := strings.Cut(loc, "_")
+		if layout, ok := localeLayouts[lang]; ok {
+			return layout, nil
+		}
+		return "15:04", nil
+	}
+	if !strings.Contains(c.TimeFormat, "04") {
+		return "", fmt.Errorf("invalid time format %q, expected 24h, 12h, locale or a Go layout like 15.04", c.TimeFormat)
+	}
+	return c.TimeFormat, nil
+}
+
+// formatTime formats the clock time of t as configured.
+func (c Config) formatTime(t time.Time) string {
+	layout, err := c.timeLayout()
+	if err != nil {
+		layout = "15:04"
+	}
+	return t.Format(layout)
+}
+
+// formatTiming formats a timing as given by the API, "15:04", as configured.
+// Timings that can't be parsed are returned as they are.
+func (c Config) formatTiming(timing string) string {
+	t, err := time.Parse("15:04", timing)
+	if err != nil {
+		return timing
+	}
+	return c.formatTime(t)
+}
+
+// formatTimings returns the timings of the five prayers and sunrise, in
+// order, formatted as configured.
+func (c Config) formatTimings(t adhan.Timings) []string {
+	timings := []string{t.Fajr, t.Sunrise, t.Dhuhr, t.Asr, t.Maghrib, t.Isha}
+	for i, timing := range timings {
+		timings[i] = c.formatTiming(timing)
+	}
+	return timings
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"unicode"
+
+	"iustusae/adhan/internal/i18n"
+	"iustusae/adhan/pkg/adhan"
+)
+
+// tr translates msg into the configured language, formatting it with args
+// like fmt.Sprintf when there are any.
+func (c Config) tr(msg string, args ...any) string {
+	return c.translator.T(msg, args...)
+}
+
+// localName returns the name of p to show to the user, translated. Webhooks,
+// MQTT and the HTTP API keep using prayerName.
+func (c Config) localName(p adhan.Prayer) string {
+	return c.nameOf(c.prayerName(p))
+}
+
+// arabic shows prayer names in Arabic whatever the language, for
+// prayer_names = "arabic".
+var arabic, _ = i18n.New("ar")
+
+// validatePrayerNames checks prayer_names.
+func (c Config) validatePrayerNames() error {
+	switch c.PrayerNames {
+	case "", "arabic", "transliterated":
+		return nil
+	default:
+		return fmt.Errorf("unknown prayer_names %q, expected arabic or transliterated", c.PrayerNames)
+	}
+}
+
+// nameOf returns how to show the prayer or time called name, e.g. "Fajr":
+// in Arabic, transliterated as is, or else in the configured language.
+func (c Config) nameOf(name string) string {
+	switch c.PrayerNames {
+	case "arabic":
+		return arabic.T(name)
+	case "transliterated":
+		return name
+	default:
+		return c.tr(name)
+	}
+}
+
+// isolateRTL wraps the right-to-left cells of row, in Arabic or Urdu, in
+// left-to-right marks, so that terminals laying out bidirectional text keep
+// the columns of tables in order. The marks take no width, leaving the
+// alignment as is.
+func isolateRTL(row []string) []string {
+	isolated := make([]string, len(row))
+	for i, cell := range row {
+		isolated[i] = cell
+		for _, r := range cell {
+			if unicode.In(r, unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana) {
+				isolated[i] = "‎" + cell + "‎"
+				break
+			}
+		}
+	}
+	return isolated
+}
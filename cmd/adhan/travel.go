@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// TravelConfig configures travel mode, which resolves the location again
+// every Interval minutes, and switches to the timetable of the new location
+// when it changes. It implies auto_locate.
+type TravelConfig struct {
+	Enabled  bool `toml:"enabled"`
+	Interval int  `toml:"interval"`
+}
+
+func (t TravelConfig) interval() time.Duration {
+	if t.Interval <= 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(t.Interval) * time.Minute
+}
+
+// travel resolves the location every interval until ctx is done, and sends
+// on the returned channel once it differs from that of cfg. The new location
+// is cached for autoLocate to pick up when the config is loaded again. It
+// never sends unless travel mode is on.
+func travel(ctx context.Context, cfg Config) <-chan struct{} {
+	if !cfg.Travel.Enabled || !cfg.AutoLocate {
+		return nil
+	}
+
+	moved := make(chan struct{}, 1)
+	go func() {
+		ticker := time.NewTicker(cfg.Travel.interval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			location, err := resolveLocation(ctx)
+			if err != nil {
+				slog.Warn("Failed to resolve location", "err", err)
+				continue
+			}
+			if err := saveLocation(location); err != nil {
+				slog.Error("Failed to cache location", "err", err)
+				continue
+			}
+			if location.City != cfg.City || location.Timezone != cfg.Timezone {
+				slog.Info("Location changed", "city", location.City, "timezone", location.Timezone)
+				moved <- struct{}{}
+				return
+			}
+		}
+	}()
+	return moved
+}
+
+// locationChange describes how the location of cfg differs from that of
+// prev, or returns an empty string if it doesn't.
+func locationChange(prev, cfg Config) string {
+	if cfg.City == prev.City && cfg.Country == prev.Country && cfg.Timezone == prev.Timezone {
+		return ""
+	}
+
+	place := cfg.City
+	if cfg.Country != "" {
+		place += ", " + cfg.Country
+	}
+	if cfg.Timezone != prev.Timezone && cfg.Timezone != "" {
+		return cfg.tr("Prayer times now follow %s, in the %s time zone", place, cfg.Timezone)
+	}
+	return cfg.tr("Prayer times now follow %s", place)
+}
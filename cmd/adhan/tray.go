@@ -0,0 +1,125 @@
+//go:build linux || darwin || windows
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"fyne.io/systray"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// runTray shows the next prayer and a countdown in the system tray or menu
+// bar, with the day's timings in its menu, while the scheduler notifies in
+// the background, until it is quit or ctx is done.
+func runTray(ctx context.Context, cfg Config, loc *time.Location) error {
+	inst, err := lockInstance()
+	if err != nil {
+		return err
+	}
+	defer inst.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	notifier := newNotifier(cfg.Notifications)
+	player := &audioPlayer{}
+	var wg sync.WaitGroup
+
+	onReady := func() {
+		systray.SetIcon(trayIcon())
+		systray.SetTitle("adhan")
+		systray.SetTooltip("adhan")
+
+		names := []string{"Fajr", "Sunrise", "Dhuhr", "Asr", "Maghrib", "Isha"}
+		timings := systray.AddMenuItem("Today's timings", "")
+		items := make(map[string]*systray.MenuItem, len(names))
+		for _, name := range names {
+			items[name] = timings.AddSubMenuItem(name, "")
+			items[name].Disable()
+		}
+
+		systray.AddSeparator()
+		stop := systray.AddMenuItem("Stop adhan", "Silence the adhan")
+		snooze := systray.AddMenuItem("Snooze 10 minutes", "Silence the adhan and remind again in 10 minutes")
+		systray.AddSeparator()
+		quit := systray.AddMenuItem("Quit", "Quit adhan")
+
+		wg.Add(1)
+		s := newScheduler(cfg, loc, notifier, player)
+		go s.run(ctx, &wg)
+		go inst.Serve(ctx, s.control)
+
+		go func() {
+			var today adhan.Day
+			var loaded time.Time
+			ticker := time.NewTicker(30 * time.Second)
+			defer ticker.Stop()
+
+			for {
+				now := clock.Now().In(loc)
+				if loaded.IsZero() || loaded.YearDay() != now.YearDay() {
+					day, err := getToday(ctx, cfg)
+					if err != nil {
+						slog.Error("Failed to fetch prayer times", "err", err)
+					} else {
+						today, loaded = day, now
+						refreshTrayTimings(cfg, items, today, now)
+					}
+				}
+
+				if next, err := nextPrayer(ctx, cfg, today, now); err == nil {
+					countdown := formatCountdown(next.Time.Sub(now), false)
+					systray.SetTitle(fmt.Sprintf("%s -%s", cfg.localName(next), countdown))
+					systray.SetTooltip(fmt.Sprintf("%s at %s, in %s", cfg.localName(next), cfg.formatTime(next.Time), countdown))
+				}
+
+				select {
+				case <-ticker.C:
+				case <-stop.ClickedCh:
+					player.stop()
+					s.stopCast()
+				case <-snooze.ClickedCh:
+					if current, ok := currentPrayer(today, now); ok {
+						s.snooze(current)
+					} else {
+						player.stop()
+						s.stopCast()
+					}
+				case <-quit.ClickedCh:
+					systray.Quit()
+					return
+				case <-ctx.Done():
+					systray.Quit()
+					return
+				}
+			}
+		}()
+	}
+
+	systray.Run(onReady, func() {
+		cancel()
+		player.stop()
+		closeCastServer()
+		wg.Wait()
+	})
+	return nil
+}
+
+func refreshTrayTimings(cfg Config, items map[string]*systray.MenuItem, today adhan.Day, now time.Time) {
+	prayers, err := today.Prayers(now)
+	if err != nil {
+		slog.Error("Failed to read prayer times", "err", err)
+		return
+	}
+	for _, p := range prayers {
+		if item, ok := items[p.Name]; ok {
+			item.SetTitle(fmt.Sprintf("%-8s %s", cfg.nameOf(p.Name), cfg.formatTime(p.Time)))
+		}
+	}
+}
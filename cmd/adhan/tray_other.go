@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+func runTray(ctx context.Context, cfg Config, loc *time.Location) error {
+	return errors.New("the tray is not supported on this platform")
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"iustusae/adhan/pkg/adhan"
+)
+
+// tuiView is what the dashboard shows below the countdown.
+type tuiView int
+
+const (
+	viewToday tuiView = iota
+	viewWeek
+	viewMonth
+)
+
+// tuiBarWidth is the width of the countdown bar, in cells.
+const tuiBarWidth = 40
+
+var (
+	tuiTitle = lipgloss.NewStyle().Bold(true)
+	tuiNext  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("3"))
+	tuiFaint = lipgloss.NewStyle().Faint(true)
+)
+
+// tuiModel is the state of the dashboard of adhan tui.
+type tuiModel struct {
+	ctx context.Context
+	cfg Config
+	loc *time.Location
+
+	now   time.Time
+	today adhan.Day
+	// since is when the time of the prayer before next began, from which
+	// the countdown bar fills up.
+	since time.Time
+	next  adhan.Prayer
+	log   *prayerLog
+
+	view tuiView
+	// cursor is the index in fardPrayers of the prayer selected to be marked
+	// as done.
+	cursor int
+	// table is the week or month view, loaded when it is first shown.
+	table  map[tuiView]string
+	status string
+	err    error
+}
+
+type (
+	tickMsg  time.Time
+	dayMsg   adhan.Day
+	tableMsg struct {
+		view  tuiView
+		table string
+	}
+	errMsg struct{ err error }
+)
+
+// runTUI shows the dashboard until it is quit with q or ctx is done.
+func runTUI(ctx context.Context, cfg Config, loc *time.Location) error {
+	log, err := loadPrayerLog()
+	if err != nil {
+		return err
+	}
+
+	// Logging to stderr would garble the screen, so log to the file when
+	// none is configured.
+	if cfg.Log.File == "" {
+		logs := cfg.Log
+		logs.File = "default"
+		if err := setupLogging(logs); err != nil {
+			return err
+		}
+		defer setupLogging(cfg.Log)
+	}
+
+	m := tuiModel{ctx: ctx, cfg: cfg, loc: loc, log: log, table: make(map[tuiView]string)}
+	m.now = clock.Now().In(loc)
+
+	_, err = tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+	if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	return tea.Batch(m.loadToday, tick())
+}
+
+func tick() tea.Cmd {
+	return tea.Every(time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+// loadToday fetches today's timings.
+func (m tuiModel) loadToday() tea.Msg {
+	day, err := getDay(m.ctx, m.cfg, m.now)
+	if err != nil {
+		return errMsg{fmt.Errorf("failed to fetch prayer times: %w", err)}
+	}
+	return dayMsg(day)
+}
+
+// loadTable lays out the week or month view.
+func (m tuiModel) loadTable(view tuiView) tea.Cmd {
+	return func() tea.Msg {
+		var header []string
+		var rows [][]string
+		highlight := 0
+		if view == viewWeek {
+			var err error
+			if header, rows, err = weekTable(m.ctx, m.cfg, m.loc); err != nil {
+				return errMsg{err}
+			}
+		} else {
+			days, err := loadCalendar(m.ctx, m.cfg, m.now.Year(), m.now.Month())
+			if err != nil {
+				return errMsg{fmt.Errorf("failed to fetch calendar: %w", err)}
+			}
+			header, rows = calendarTable(m.cfg, days)
+			highlight = m.now.Day() - 1
+		}
+
+		var b strings.Builder
+		renderHighlighted(&b, header, rows, highlight, true)
+		return tableMsg{view, b.String()}
+	}
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		now := clock.Now().In(m.loc)
+		dayChanged := now.YearDay() != m.now.YearDay()
+		m.now = now
+		if dayChanged {
+			m.table = make(map[tuiView]string)
+			return m, tea.Batch(m.loadToday, tick())
+		}
+		if !m.next.Time.IsZero() && !m.now.Before(m.next.Time) {
+			m.update()
+		}
+		return m, tick()
+
+	case dayMsg:
+		m.today = adhan.Day(msg)
+		m.err = nil
+		m.update()
+		return m, nil
+
+	case tableMsg:
+		m.table[msg.view] = msg.table
+		return m, nil
+
+	case errMsg:
+		m.err = msg.err
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "t":
+			m.view = viewToday
+		case "w":
+			return m.show(viewWeek)
+		case "m":
+			return m.show(viewMonth)
+		case "up", "k":
+			m.cursor = max(m.cursor-1, 0)
+		case "down", "j":
+			m.cursor = min(m.cursor+1, len(fardPrayers)-1)
+		case " ", "enter", "d":
+			m.markDone()
+		}
+	}
+	return m, nil
+}
+
+// show switches to the week or month view, loading it the first time.
+func (m tuiModel) show(view tuiView) (tea.Model, tea.Cmd) {
+	m.view = view
+	if _, ok := m.table[view]; ok {
+		return m, nil
+	}
+	return m, m.loadTable(view)
+}
+
+// update finds the next prayer and when the countdown to it started.
+func (m *tuiModel) update() {
+	next, err := nextPrayer(m.ctx, m.cfg, m.today, m.now)
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.next = next
+
+	// Before Fajr, the bar fills up from midnight.
+	m.since = time.Date(m.now.Year(), m.now.Month(), m.now.Day(), 0, 0, 0, 0, m.loc)
+	if current, ok := currentPrayer(m.today, m.now); ok {
+		m.since = current.Time
+	}
+}
+
+// markDone marks the selected prayer as prayed today, or Isha of the day
+// before after midnight, see logDay.
+func (m *tuiModel) markDone() {
+	prayer := fardPrayers[m.cursor]
+	day := logDay(m.today, prayer, m.now)
+	if !m.log.mark(day, prayer) {
+		return
+	}
+	if err := logPrayer(day, prayer); err != nil {
+		m.err = fmt.Errorf("failed to save prayer log: %w", err)
+		return
+	}
+	m.status = m.cfg.tr("Marked %s as prayed", m.cfg.nameOf(prayer))
+}
+
+func (m tuiModel) View() string {
+	var b strings.Builder
+
+	date := m.now.Format("Monday 02 January 2006")
+	if hijri := m.today.Date.HijriString(); hijri != "" {
+		date += " · " + hijri
+	}
+	b.WriteString(tuiTitle.Render(date) + "\n\n")
+
+	if m.next.Time.IsZero() {
+		b.WriteString(m.cfg.tr("Fetching prayer times…") + "\n")
+	} else {
+		left := m.next.Time.Sub(m.now)
+		b.WriteString(tuiNext.Render(m.cfg.tr("%s in %s", m.cfg.localName(m.next), formatCountdown(left, true))) + "\n")
+		b.WriteString(countdownBar(m.now.Sub(m.since), m.next.Time.Sub(m.since)) + "\n\n")
+	}
+
+	switch m.view {
+	case viewToday:
+		if m.today.Timings.Fajr != "" {
+			b.WriteString(m.todayTable())
+		}
+	default:
+		if table, ok := m.table[m.view]; ok {
+			b.WriteString(table)
+		} else {
+			b.WriteString(m.cfg.tr("Fetching prayer times…") + "\n")
+		}
+	}
+
+	if m.err != nil {
+		b.WriteString("\n" + m.err.Error() + "\n")
+	} else if m.status != "" {
+		b.WriteString("\n" + m.status + "\n")
+	}
+	b.WriteString("\n" + tuiFaint.Render("t today · w week · m month · ↑/↓ select · space mark as prayed · q quit"))
+	return b.String()
+}
+
+// todayTable lays out today's timings with the selected prayer and those
+// marked as prayed.
+func (m tuiModel) todayTable() string {
+	rows := timingsTable(m.cfg, m.today, m.now)
+	prayers, _ := m.today.Prayers(m.now)
+
+	// Imsak in Ramadan and Duha come between the prayers.
+	j := 0
+	for i, row := range rows {
+		if row[0] == m.cfg.nameOf("Imsak") || row[0] == m.cfg.nameOf("Duha") || j >= len(prayers) {
+			rows[i] = append([]string{""}, append(row, "")...)
+			continue
+		}
+		p := prayers[j]
+		j++
+
+		selected, prayed := "", ""
+		if p.Name == fardPrayers[m.cursor] {
+			selected = "▸"
+		}
+		if m.log.prayed(m.now, p.Name) {
+			prayed = "✓"
+		}
+		rows[i] = append([]string{selected}, append(row, prayed)...)
+	}
+
+	var b strings.Builder
+	renderTable(&b, []string{"", m.cfg.tr("Prayer"), m.cfg.tr("Time"), m.cfg.tr("Prayed")}, rows)
+	return b.String()
+}
+
+// countdownBar draws how much of total has elapsed.
+func countdownBar(elapsed, total time.Duration) string {
+	filled := 0
+	if total > 0 {
+		filled = int(int64(tuiBarWidth) * int64(elapsed) / int64(total))
+	}
+	filled = min(max(filled, 0), tuiBarWidth)
+	return strings.Repeat("█", filled) + strings.Repeat("░", tuiBarWidth-filled)
+}
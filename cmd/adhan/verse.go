@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"iustusae/adhan/internal/daily"
+)
+
+// VerseConfig configures the verse or dua of the day, added to the
+// notification shown when adhan starts.
+type VerseConfig struct {
+	Enabled bool `toml:"enabled"`
+	// File replaces the built-in verses and duas, one per line as text and
+	// source separated by a tab.
+	File string `toml:"file"`
+}
+
+// verseOfDay returns the verse or dua of day.
+func (c Config) verseOfDay(day time.Time) (daily.Entry, error) {
+	entries, err := daily.Load(c.Verse.File)
+	if err != nil {
+		return daily.Entry{}, fmt.Errorf("failed to load verses: %w", err)
+	}
+	return daily.Pick(entries, day)
+}
+
+// printVerse prints the verse or dua of today.
+func printVerse(cfg Config, loc *time.Location) error {
+	verse, err := cfg.verseOfDay(clock.Now().In(loc))
+	if err != nil {
+		return err
+	}
+	fmt.Println(verse)
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// webhookClient posts webhooks, without holding up the scheduler for long.
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// WebhookConfig is a URL that receives prayer events as JSON, for
+// automations such as IFTTT, Zapier or Home Assistant.
+type WebhookConfig struct {
+	URL string `toml:"url"`
+	// Events are the types of event sent, "prayer" and "reminder" by
+	// default. "refresh" is sent whenever the next prayer is scheduled.
+	Events  []string          `toml:"events"`
+	Headers map[string]string `toml:"headers"`
+}
+
+// wants reports whether the webhook is sent events of type typ.
+func (w WebhookConfig) wants(typ string) bool {
+	if len(w.Events) == 0 {
+		return typ == "prayer" || typ == "reminder"
+	}
+	for _, e := range w.Events {
+		if e == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// send posts e to the webhook.
+func (w WebhookConfig) send(e prayerEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "adhan")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// webhookListener sends events to the configured webhooks in the
+// background.
+func webhookListener(hooks []WebhookConfig) func(prayerEvent) {
+	return func(e prayerEvent) {
+		for _, hook := range hooks {
+			if !hook.wants(e.Type) {
+				continue
+			}
+			go func(hook WebhookConfig) {
+				if err := hook.send(e); err != nil {
+					slog.Error("Failed to send webhook", "url", hook.URL, "err", err)
+				}
+			}(hook)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Command adhanctl controls the running adhan daemon over its control
+// socket: it asks for the next prayer and today's timings, and snoozes,
+// acknowledges or silences the adhan, without fetching anything itself.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"iustusae/adhan/internal/control"
+)
+
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:          "adhanctl",
+		Short:        "Control the running adhan daemon",
+		Long:         "adhanctl sends commands to the adhan daemon, started with adhan daemon, adhan install or as a service.",
+		SilenceUsage: true,
+	}
+
+	commands := []struct {
+		name, short string
+	}{
+		{"next", "Show the next prayer and its time"},
+		{"remaining", "Show the time left until the next prayer"},
+		{"today", "Show today's timings"},
+		{"status", "Show whether the daemon runs and the next prayer"},
+		{"snooze", "Silence the adhan and remind again in 10 minutes"},
+		{"ack", "Stop reminding of the current prayer"},
+		{"stop", "Silence the adhan"},
+		{"reload", "Reload the config file"},
+	}
+	for _, c := range commands {
+		name := c.name
+		root.AddCommand(&cobra.Command{
+			Use:   name,
+			Short: c.short,
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				out, err := control.Send(cmd.Context(), name)
+				if errors.Is(err, control.ErrNotRunning) {
+					return fmt.Errorf("%w, start it with adhan daemon", err)
+				}
+				if out != "" {
+					fmt.Println(out)
+				}
+				return err
+			},
+		})
+	}
+
+	return root
+}
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,55 @@
+package calc
+
+import "math"
+
+// The trigonometric helpers work in degrees, like the formulas in the
+// astronomical almanac they are taken from.
+
+func dsin(d float64) float64 { return math.Sin(d * math.Pi / 180) }
+func dcos(d float64) float64 { return math.Cos(d * math.Pi / 180) }
+func dtan(d float64) float64 { return math.Tan(d * math.Pi / 180) }
+
+func darcsin(x float64) float64     { return math.Asin(x) * 180 / math.Pi }
+func darccos(x float64) float64     { return math.Acos(x) * 180 / math.Pi }
+func darctan2(y, x float64) float64 { return math.Atan2(y, x) * 180 / math.Pi }
+func darccot(x float64) float64     { return math.Atan(1/x) * 180 / math.Pi }
+
+func fix(a, b float64) float64 {
+	a = a - b*math.Floor(a/b)
+	if a < 0 {
+		return a + b
+	}
+	return a
+}
+
+func fixAngle(a float64) float64 { return fix(a, 360) }
+func fixHour(a float64) float64  { return fix(a, 24) }
+
+// julianDate returns the julian day number of the given gregorian date at
+// midnight UTC.
+func julianDate(year, month, day int) float64 {
+	if month <= 2 {
+		year--
+		month += 12
+	}
+	a := math.Floor(float64(year) / 100)
+	b := 2 - a + math.Floor(a/4)
+
+	return math.Floor(365.25*float64(year+4716)) + math.Floor(30.6001*float64(month+1)) + float64(day) + b - 1524.5
+}
+
+// sunPosition returns the declination of the sun and the equation of time for
+// the given julian date.
+func sunPosition(jd float64) (declination, equation float64) {
+	d := jd - 2451545.0
+	g := fixAngle(357.529 + 0.98560028*d)
+	q := fixAngle(280.459 + 0.98564736*d)
+	l := fixAngle(q + 1.915*dsin(g) + 0.020*dsin(2*g))
+
+	e := 23.439 - 0.00000036*d
+	ra := darctan2(dcos(e)*dsin(l), dcos(l)) / 15
+
+	declination = darcsin(dsin(e) * dsin(l))
+	equation = q/15 - fixHour(ra)
+	return declination, equation
+}
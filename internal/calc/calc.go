@@ -0,0 +1,157 @@
+// Package calc computes prayer times locally from the position of the sun, so
+// that they are available without asking api.aladhan.com.
+package calc
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// riseSetAngle is the sun angle at sunrise and sunset, accounting for
+// refraction and the radius of the sun.
+const riseSetAngle = 0.833
+
+// Params describes where and how to compute prayer times.
+type Params struct {
+	Latitude  float64
+	Longitude float64
+	Method    Method
+	// AsrFactor is the shadow length factor used for Asr: 1 for the
+	// majority (Shafi'i, Maliki, Hanbali) and 2 for Hanafi. Zero means 1.
+	AsrFactor float64
+}
+
+// Times are the prayer times of a single day.
+type Times struct {
+	Imsak    time.Time
+	Fajr     time.Time
+	Sunrise  time.Time
+	Dhuhr    time.Time
+	Asr      time.Time
+	Sunset   time.Time
+	Maghrib  time.Time
+	Isha     time.Time
+	Midnight time.Time
+}
+
+// ErrNoTimes is returned for the days, near the poles, on which the sun
+// doesn't rise, set, or get high enough for Asr, so that there are prayer
+// times even the rule for high latitudes can't place.
+var ErrNoTimes = errors.New("the sun doesn't rise, set or get high enough for the prayer times that day")
+
+type calculator struct {
+	params Params
+	jd     float64
+}
+
+// Compute returns the prayer times for the day of date, expressed in the
+// location of date and rounded to the minute, or ErrNoTimes.
+func Compute(date time.Time, p Params) (Times, error) {
+	if p.AsrFactor == 0 {
+		p.AsrFactor = 1
+	}
+
+	year, month, day := date.Date()
+	c := calculator{params: p, jd: julianDate(year, int(month), day) - p.Longitude/(15*24)}
+	m := p.Method
+
+	fajr := c.sunAngleTime(m.Fajr, 5, true)
+	sunrise := c.sunAngleTime(riseSetAngle, 6, true)
+	dhuhr := c.midDay(12)
+	asr := c.asrTime(p.AsrFactor, 13)
+	sunset := c.sunAngleTime(riseSetAngle, 18, false)
+
+	night := fixHour(sunrise - sunset)
+
+	maghrib := sunset
+	switch {
+	case m.MaghribMinutes > 0:
+		maghrib = sunset + float64(m.MaghribMinutes)/60
+	case m.Maghrib > 0:
+		maghrib = adjustAfter(c.sunAngleTime(m.Maghrib, 18, false), sunset, m.Maghrib, night)
+	}
+
+	var isha float64
+	if m.IshaMinutes > 0 {
+		isha = maghrib + float64(m.IshaMinutes)/60
+	} else {
+		isha = adjustAfter(c.sunAngleTime(m.Isha, 18, false), sunset, m.Isha, night)
+	}
+
+	fajr = adjustBefore(fajr, sunrise, m.Fajr, night)
+	midnight := sunset + night/2
+
+	for _, t := range []float64{fajr, sunrise, dhuhr, asr, sunset, maghrib, isha, midnight} {
+		if math.IsNaN(t) {
+			return Times{}, ErrNoTimes
+		}
+	}
+
+	base := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	loc := date.Location()
+	at := func(hours float64) time.Time {
+		hours -= p.Longitude / 15
+		return base.Add(time.Duration(hours * float64(time.Hour))).Round(time.Minute).In(loc)
+	}
+
+	return Times{
+		Imsak:    at(fajr - 10.0/60),
+		Fajr:     at(fajr),
+		Sunrise:  at(sunrise),
+		Dhuhr:    at(dhuhr),
+		Asr:      at(asr),
+		Sunset:   at(sunset),
+		Maghrib:  at(maghrib),
+		Isha:     at(isha),
+		Midnight: at(midnight),
+	}, nil
+}
+
+// midDay returns the time of solar noon, hours being the approximate time of
+// day used to evaluate the position of the sun.
+func (c calculator) midDay(hours float64) float64 {
+	_, eqt := sunPosition(c.jd + hours/24)
+	return fixHour(12 - eqt)
+}
+
+// sunAngleTime returns the time at which the sun reaches angle degrees below
+// the horizon, before noon when ccw is set and after it otherwise.
+func (c calculator) sunAngleTime(angle, hours float64, ccw bool) float64 {
+	decl, _ := sunPosition(c.jd + hours/24)
+	noon := c.midDay(hours)
+	lat := c.params.Latitude
+
+	t := darccos((-dsin(angle)-dsin(decl)*dsin(lat))/(dcos(decl)*dcos(lat))) / 15
+	if ccw {
+		return noon - t
+	}
+	return noon + t
+}
+
+// asrTime returns the time at which the shadow of an object is factor times
+// its length plus the length of its shadow at noon.
+func (c calculator) asrTime(factor, hours float64) float64 {
+	decl, _ := sunPosition(c.jd + hours/24)
+	angle := -darccot(factor + dtan(math.Abs(c.params.Latitude-decl)))
+	return c.sunAngleTime(angle, hours, false)
+}
+
+// adjustBefore and adjustAfter apply the angle based method for high
+// latitudes: when twilight never ends, or lasts longer than angle/60 of the
+// night, the time is clamped to that portion of the night.
+func adjustBefore(t, sunrise, angle, night float64) float64 {
+	portion := angle / 60 * night
+	if math.IsNaN(t) || fixHour(sunrise-t) > portion {
+		return sunrise - portion
+	}
+	return t
+}
+
+func adjustAfter(t, sunset, angle, night float64) float64 {
+	portion := angle / 60 * night
+	if math.IsNaN(t) || fixHour(t-sunset) > portion {
+		return sunset + portion
+	}
+	return t
+}
@@ -0,0 +1,78 @@
+package calc
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// The times expected are those of the NOAA solar calculator for the sun
+// angles of the methods, with the angle based rule applied where twilight
+// lasts all night.
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name     string
+		zone     string
+		date     string
+		lat, lon float64
+		method   int
+		// want are Fajr, Sunrise, Dhuhr, Asr, Maghrib and Isha.
+		want [6]string
+	}{
+		{
+			name: "Mecca", zone: "Asia/Riyadh", date: "2024-01-15", lat: 21.4225, lon: 39.8262, method: 4,
+			want: [6]string{"2024-01-15 05:41", "2024-01-15 07:01", "2024-01-15 12:30", "2024-01-15 15:37", "2024-01-15 17:59", "2024-01-15 19:29"},
+		},
+		{
+			name: "Jakarta", zone: "Asia/Jakarta", date: "2024-07-01", lat: -6.2088, lon: 106.8456, method: 20,
+			want: [6]string{"2024-07-01 04:40", "2024-07-01 06:03", "2024-07-01 11:57", "2024-07-01 15:19", "2024-07-01 17:50", "2024-07-01 19:04"},
+		},
+		{
+			name: "London at the equinox", zone: "Europe/London", date: "2024-03-20", lat: 51.5074, lon: -0.1278, method: 3,
+			want: [6]string{"2024-03-20 04:09", "2024-03-20 06:02", "2024-03-20 12:08", "2024-03-20 15:26", "2024-03-20 18:14", "2024-03-20 20:01"},
+		},
+		{
+			// Twilight lasts all night, Fajr is 18/60 and Isha 17/60 of the
+			// night from sunrise and sunset.
+			name: "London at the solstice", zone: "Europe/London", date: "2024-06-21", lat: 51.5074, lon: -0.1278, method: 3,
+			want: [6]string{"2024-06-21 02:31", "2024-06-21 04:43", "2024-06-21 13:02", "2024-06-21 17:25", "2024-06-21 21:22", "2024-06-21 23:27"},
+		},
+		{
+			name: "Oslo at the solstice", zone: "Europe/Oslo", date: "2024-06-21", lat: 59.9139, lon: 10.7522, method: 3,
+			want: [6]string{"2024-06-21 02:21", "2024-06-21 03:54", "2024-06-21 13:19", "2024-06-21 18:01", "2024-06-21 22:44", "2024-06-22 00:12"},
+		},
+	}
+	for _, tt := range tests {
+		loc, err := time.LoadLocation(tt.zone)
+		if err != nil {
+			t.Skip("no time zone database:", err)
+		}
+		date, _ := time.ParseInLocation("2006-01-02 15:04", tt.date+" 12:00", loc)
+		times, err := Compute(date, Params{Latitude: tt.lat, Longitude: tt.lon, Method: Methods[tt.method]})
+		if err != nil {
+			t.Errorf("%s: Compute failed: %v", tt.name, err)
+			continue
+		}
+
+		got := [6]time.Time{times.Fajr, times.Sunrise, times.Dhuhr, times.Asr, times.Maghrib, times.Isha}
+		for i, name := range []string{"Fajr", "Sunrise", "Dhuhr", "Asr", "Maghrib", "Isha"} {
+			want, _ := time.ParseInLocation("2006-01-02 15:04", tt.want[i], loc)
+			// Rounding may differ by a minute.
+			if d := got[i].Sub(want); d < -time.Minute || d > time.Minute {
+				t.Errorf("%s: %s = %s, want %s", tt.name, name, got[i].Format("2006-01-02 15:04"), tt.want[i])
+			}
+		}
+	}
+}
+
+func TestComputePolar(t *testing.T) {
+	// The sun doesn't set in Tromsø in June, nor rise in December.
+	for _, date := range []time.Time{
+		time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC),
+		time.Date(2024, 12, 21, 12, 0, 0, 0, time.UTC),
+	} {
+		if _, err := Compute(date, Params{Latitude: 69.6492, Longitude: 18.9553, Method: Methods[3]}); !errors.Is(err, ErrNoTimes) {
+			t.Errorf("Compute(%s) in Tromsø = %v, want ErrNoTimes", date.Format("2006-01-02"), err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package calc
+
+// Method holds the twilight parameters of a calculation method. Isha and
+// Maghrib are either a sun angle below the horizon or, when the matching
+// Minutes field is set, a fixed delay after Maghrib and sunset respectively.
+type Method struct {
+	Name           string
+	Fajr           float64
+	Isha           float64
+	IshaMinutes    int
+	Maghrib        float64
+	MaghribMinutes int
+}
+
+// Methods maps the aladhan method ids to their parameters, so that local
+// calculation matches what the API would have returned.
+var Methods = map[int]Method{
+	0:  {Name: "Shia Ithna-Ansari", Fajr: 16, Isha: 14, Maghrib: 4},
+	1:  {Name: "University of Islamic Sciences, Karachi", Fajr: 18, Isha: 18},
+	2:  {Name: "Islamic Society of North America", Fajr: 15, Isha: 15},
+	3:  {Name: "Muslim World League", Fajr: 18, Isha: 17},
+	4:  {Name: "Umm Al-Qura University, Makkah", Fajr: 18.5, IshaMinutes: 90},
+	5:  {Name: "Egyptian General Authority of Survey", Fajr: 19.5, Isha: 17.5},
+	7:  {Name: "Institute of Geophysics, University of Tehran", Fajr: 17.7, Isha: 14, Maghrib: 4.5},
+	8:  {Name: "Gulf Region", Fajr: 19.5, IshaMinutes: 90},
+	9:  {Name: "Kuwait", Fajr: 18, Isha: 17.5},
+	10: {Name: "Qatar", Fajr: 18, IshaMinutes: 90},
+	11: {Name: "Majlis Ugama Islam Singapura, Singapore", Fajr: 20, Isha: 18},
+	12: {Name: "Union Organization islamic de France", Fajr: 12, Isha: 12},
+	13: {Name: "Diyanet İşleri Başkanlığı, Turkey", Fajr: 18, Isha: 17},
+	14: {Name: "Spiritual Administration of Muslims of Russia", Fajr: 16, Isha: 15},
+	15: {Name: "Moonsighting Committee Worldwide", Fajr: 18, Isha: 18},
+	16: {Name: "Dubai", Fajr: 18.2, Isha: 18.2},
+	17: {Name: "Jabatan Kemajuan Islam Malaysia (JAKIM)", Fajr: 20, Isha: 18},
+	18: {Name: "Tunisia", Fajr: 18, Isha: 18},
+	19: {Name: "Algeria", Fajr: 18, Isha: 17},
+	20: {Name: "Kementerian Agama Republik Indonesia", Fajr: 20, Isha: 18},
+	21: {Name: "Morocco", Fajr: 19, Isha: 17},
+	22: {Name: "Comunidade Islamica de Lisboa", Fajr: 18, IshaMinutes: 77},
+	23: {Name: "Ministry of Awqaf, Islamic Affairs and Holy Places, Jordan", Fajr: 18, Isha: 18},
+}
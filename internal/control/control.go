@@ -0,0 +1,210 @@
+// Package control is how commands reach the running adhan: JSON requests
+// and responses over a Unix socket. A lock on the pid file makes sure a
+// single instance runs. Windows supports Unix sockets too.
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Timeout bounds how long a command sent to the running instance may take,
+// fetching the timings included.
+const Timeout = 30 * time.Second
+
+var (
+	ErrAlreadyRunning = errors.New("adhan is already running")
+	ErrNotRunning     = errors.New("adhan is not running")
+
+	// errLocked is returned by lockFile when another process holds the lock.
+	errLocked = errors.New("file locked")
+)
+
+// Request is a command sent to the running instance.
+type Request struct {
+	Command string `json:"command"`
+}
+
+// Response is what the running instance answers, the text to print or an
+// error.
+type Response struct {
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Handler runs a command and returns what to print.
+type Handler func(ctx context.Context, command string) (string, error)
+
+// Dir returns where the control socket and the pid file live:
+// $XDG_RUNTIME_DIR/adhan when set, the cache directory otherwise.
+func Dir() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "adhan"), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "adhan"), nil
+}
+
+// Listener is held by the running instance: the socket other invocations
+// send commands to, along with the pid file it keeps locked.
+type Listener struct {
+	ln  net.Listener
+	pid *os.File
+}
+
+// Listen locks the pid file and listens on the control socket, or returns
+// ErrAlreadyRunning when another instance holds the lock.
+func Listen() (*Listener, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "adhan.sock")
+	pidPath := filepath.Join(dir, "adhan.pid")
+
+	// The pid file is never removed, so that instances starting together
+	// lock the same file. The lock goes with the instance holding it.
+	pid, err := os.OpenFile(pidPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pid file: %w", err)
+	}
+	if err := lockFile(pid); err != nil {
+		pid.Close()
+		if !errors.Is(err, errLocked) {
+			return nil, fmt.Errorf("failed to lock pid file: %w", err)
+		}
+		if raw, err := os.ReadFile(pidPath); err == nil && len(bytes.TrimSpace(raw)) > 0 {
+			return nil, fmt.Errorf("%w, pid %s", ErrAlreadyRunning, bytes.TrimSpace(raw))
+		}
+		return nil, ErrAlreadyRunning
+	}
+
+	// With the lock held, a socket left over is from an instance that
+	// didn't exit cleanly.
+	os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		pid.Close()
+		return nil, fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+	if err := pid.Truncate(0); err == nil {
+		_, err = pid.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	if err != nil {
+		slog.Error("Failed to write pid file", "err", err)
+	}
+	return &Listener{ln: ln, pid: pid}, nil
+}
+
+// Serve answers the commands sent to the control socket with handle, until
+// l is closed, or ctx is done while backing off from failures to accept a
+// connection. It returns right away if l is nil.
+func (l *Listener) Serve(ctx context.Context, handle Handler) {
+	if l == nil {
+		return
+	}
+	var delay time.Duration
+	for {
+		conn, err := l.ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			// Back off like net/http does, so that an error that persists,
+			// running out of file descriptors say, doesn't spin.
+			if delay == 0 {
+				delay = 5 * time.Millisecond
+			} else if delay *= 2; delay > time.Second {
+				delay = time.Second
+			}
+			slog.Error("Failed to accept control connection", "err", err, "retry", delay)
+			select {
+			case <-time.After(delay):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		delay = 0
+		go answer(ctx, conn, handle)
+	}
+}
+
+func answer(ctx context.Context, conn net.Conn, handle Handler) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(Timeout))
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		slog.Error("Failed to read control command", "err", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	var resp Response
+	out, err := handle(ctx, req.Command)
+	resp.Output = out
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		slog.Error("Failed to answer control command", "err", err)
+	}
+}
+
+// Close removes the socket, empties the pid file and releases the lock. It
+// does nothing if l is nil.
+func (l *Listener) Close() {
+	if l == nil {
+		return
+	}
+	l.ln.Close()
+	l.pid.Truncate(0)
+	l.pid.Close()
+}
+
+// Send sends command to the running instance and returns its answer, or
+// ErrNotRunning.
+func Send(ctx context.Context, command string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", filepath.Join(dir, "adhan.sock"))
+	if err != nil {
+		return "", ErrNotRunning
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(Timeout))
+
+	if err := json.NewEncoder(conn).Encode(Request{Command: command}); err != nil {
+		return "", err
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return "", fmt.Errorf("failed to read answer: %w", err)
+	}
+	if resp.Error != "" {
+		return resp.Output, errors.New(resp.Error)
+	}
+	return resp.Output, nil
+}
@@ -0,0 +1,21 @@
+package control
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile takes an exclusive lock on f, held until f is closed, or returns
+// errLocked when another process holds it.
+func lockFile(f *os.File) error {
+	// Locks on Windows keep others from reading the bytes locked, so the
+	// byte locked is one far past the pid.
+	ol := &windows.Overlapped{OffsetHigh: 0x7fffffff}
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errLocked
+	}
+	return err
+}
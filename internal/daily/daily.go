@@ -0,0 +1,82 @@
+// Package daily picks a short Quran verse or dua for each day, from a
+// collection built in or from a file of the user's in the same format.
+package daily
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Entry is a verse or dua.
+type Entry struct {
+	Text string
+	// Source is where it comes from, e.g. "Quran 94:6".
+	Source string
+}
+
+// String formats e as the text followed by its source.
+func (e Entry) String() string {
+	if e.Source == "" {
+		return e.Text
+	}
+	return e.Text + " (" + e.Source + ")"
+}
+
+// builtin lists the built-in entries, one per line as text and source
+// separated by a tab. Empty lines and lines starting with # are skipped.
+//
+//go:embed verses.tsv
+var builtin string
+
+// Load reads the entries of the file at path, in the format of the built-in
+// list, or the built-in ones when path is empty.
+func Load(path string) ([]Entry, error) {
+	if path == "" {
+		return parse(strings.NewReader(builtin))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s has no verses", path)
+	}
+	return entries, nil
+}
+
+func parse(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		text, source, _ := strings.Cut(line, "\t")
+		entries = append(entries, Entry{Text: strings.TrimSpace(text), Source: strings.TrimSpace(source)})
+	}
+	return entries, scanner.Err()
+}
+
+// Pick returns the entry of day, going through entries in order, one a
+// day, so that everyone sees the same one on a given date.
+func Pick(entries []Entry, day time.Time) (Entry, error) {
+	if len(entries) == 0 {
+		return Entry{}, errors.New("no verses to pick from")
+	}
+	y, m, d := day.Date()
+	days := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60)
+	return entries[days%int64(len(entries))], nil
+}
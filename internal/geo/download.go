@@ -0,0 +1,140 @@
+package geo
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DumpURL is where the GeoNames database is downloaded from.
+var DumpURL = "https://download.geonames.org/export/dump"
+
+// Download fetches the GeoNames database of cities of over 15000 inhabitants
+// and writes it at path in the compact format Lookup reads, about 2 MB. It
+// returns the number of cities written.
+func Download(ctx context.Context, path string) (int, error) {
+	countries, err := fetchCountries(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	raw, err := fetch(ctx, DumpURL+"/cities15000.zip")
+	if err != nil {
+		return 0, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to open cities archive: %w", err)
+	}
+	dump, err := archive.Open("cities15000.txt")
+	if err != nil {
+		return 0, fmt.Errorf("failed to open cities archive: %w", err)
+	}
+	defer dump.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+	tmp := path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+
+	n, err := convert(out, dump, countries)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, os.Rename(tmp, path)
+}
+
+// convert writes the cities of a GeoNames dump to w in the compact format,
+// naming their country with countries.
+func convert(w io.Writer, dump io.Reader, countries map[string]string) (int, error) {
+	bw := bufio.NewWriter(w)
+	scanner := bufio.NewScanner(dump)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	n := 0
+	for scanner.Scan() {
+		// See the readme of the dump for the columns.
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 18 {
+			continue
+		}
+		lat, err1 := strconv.ParseFloat(fields[4], 64)
+		long, err2 := strconv.ParseFloat(fields[5], 64)
+		population, _ := strconv.Atoi(fields[14])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		c := City{
+			Name:        fields[1],
+			ASCIIName:   fields[2],
+			Country:     countries[fields[8]],
+			CountryCode: fields[8],
+			Latitude:    lat,
+			Longitude:   long,
+			Timezone:    fields[17],
+			Population:  population,
+		}
+		if _, err := bw.WriteString(c.format() + "\n"); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := scanner.Err(); err != nil {
+		return n, err
+	}
+	return n, bw.Flush()
+}
+
+// fetchCountries returns the names of the countries by ISO code.
+func fetchCountries(ctx context.Context) (map[string]string, error) {
+	raw, err := fetch(ctx, DumpURL+"/countryInfo.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	countries := make(map[string]string)
+	for _, line := range strings.Split(string(raw), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) > 4 {
+			countries[fields[0]] = fields[4]
+		}
+	}
+	return countries, nil
+}
+
+func fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request to %s failed: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,120 @@
+// Package geo finds the coordinates and time zone of cities without network
+// access, so that prayer times can be calculated locally for a city. It has a
+// list of major cities built in, and reads the GeoNames database of every
+// city of over 15000 inhabitants once downloaded with Download.
+package geo
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// City is a city with its position and time zone.
+type City struct {
+	Name string
+	// ASCIIName is the name without diacritics, e.g. "Sao Paulo".
+	ASCIIName   string
+	Country     string
+	CountryCode string
+	Latitude    float64
+	Longitude   float64
+	// Timezone is the IANA time zone of the city.
+	Timezone   string
+	Population int
+}
+
+// builtin lists major cities, in the format written by Download.
+//
+//go:embed cities.tsv
+var builtin string
+
+// Lookup returns the most populous city called name, ignoring case, in
+// country if it is not empty. The country is matched by name or ISO code.
+// Cities come from the database at path when it exists, and from the
+// built-in list otherwise.
+func Lookup(path, name, country string) (City, bool, error) {
+	var found City
+	ok := false
+	err := each(path, func(c City) {
+		if !strings.EqualFold(c.Name, name) && !strings.EqualFold(c.ASCIIName, name) {
+			return
+		}
+		if country != "" && !strings.EqualFold(c.Country, country) && !strings.EqualFold(c.CountryCode, country) {
+			return
+		}
+		if !ok || c.Population > found.Population {
+			found, ok = c, true
+		}
+	})
+	return found, ok, err
+}
+
+// each calls f with every city of the database at path, or of the built-in
+// list when there is none.
+func each(path string, f func(City)) error {
+	var r io.Reader = strings.NewReader(builtin)
+	if path != "" {
+		file, err := os.Open(path)
+		switch {
+		case err == nil:
+			defer file.Close()
+			r = file
+		case !errors.Is(err, fs.ErrNotExist):
+			return err
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if c, ok := parseCity(scanner.Text()); ok {
+			f(c)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseCity parses a line of the database, skipping malformed ones.
+func parseCity(line string) (City, bool) {
+	fields := strings.Split(line, "\t")
+	if len(fields) != 8 {
+		return City{}, false
+	}
+
+	lat, err1 := strconv.ParseFloat(fields[4], 64)
+	long, err2 := strconv.ParseFloat(fields[5], 64)
+	population, err3 := strconv.Atoi(fields[7])
+	if err1 != nil || err2 != nil || err3 != nil {
+		return City{}, false
+	}
+
+	return City{
+		Name:        fields[0],
+		ASCIIName:   fields[1],
+		Country:     fields[2],
+		CountryCode: fields[3],
+		Latitude:    lat,
+		Longitude:   long,
+		Timezone:    fields[6],
+		Population:  population,
+	}, true
+}
+
+// format formats c as a line of the database.
+func (c City) format() string {
+	return strings.Join([]string{
+		c.Name,
+		c.ASCIIName,
+		c.Country,
+		c.CountryCode,
+		strconv.FormatFloat(c.Latitude, 'f', -1, 64),
+		strconv.FormatFloat(c.Longitude, 'f', -1, 64),
+		c.Timezone,
+		strconv.Itoa(c.Population),
+	}, "\t")
+}
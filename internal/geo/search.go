@@ -0,0 +1,98 @@
+package geo
+
+import (
+	"sort"
+	"strings"
+)
+
+// Search returns up to limit cities whose name is close to query, allowing
+// for typos, best matches first and the most populous first among equal
+// matches.
+func Search(path, query string, limit int) ([]City, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil, nil
+	}
+
+	type match struct {
+		city  City
+		score int
+	}
+	var matches []match
+	err := each(path, func(c City) {
+		score, ok := matchScore(query, strings.ToLower(c.Name))
+		if ascii, asciiOK := matchScore(query, strings.ToLower(c.ASCIIName)); asciiOK && (!ok || ascii < score) {
+			score, ok = ascii, true
+		}
+		if ok {
+			matches = append(matches, match{c, score})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].city.Population > matches[j].city.Population
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	cities := make([]City, len(matches))
+	for i, m := range matches {
+		cities[i] = m.city
+	}
+	return cities, nil
+}
+
+// matchScore scores how well query matches name, lower being better: 0 when
+// they are equal, 1 when name starts with query, and else 2 plus the number
+// of typos, compared with both the whole name and its start. It reports
+// false when there are too many typos for the length of query.
+func matchScore(query, name string) (int, bool) {
+	switch {
+	case name == query:
+		return 0, true
+	case strings.HasPrefix(name, query):
+		return 1, true
+	}
+
+	// A letter left out or doubled shifts where the start ends.
+	d := distance(query, name)
+	r, n := []rune(name), len([]rune(query))
+	for end := n - 1; end <= n+1 && end < len(r); end++ {
+		d = min(d, distance(query, string(r[:end])))
+	}
+	if d > max(1, n/3) {
+		return 0, false
+	}
+	return 2 + d, true
+}
+
+// distance returns the Levenshtein distance between a and b, the number of
+// runes to insert, delete or replace to turn one into the other.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
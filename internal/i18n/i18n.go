@@ -0,0 +1,109 @@
+// Package i18n translates the text adhan shows into the languages it has a
+// message catalog for. Messages are looked up by their English text, as
+// with gettext, so that they read naturally where they are used and are
+// shown in English when there is no translation.
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// catalogs holds a TOML file per language, named after its ISO 639-1 code,
+// mapping English messages to their translation. Translations may reorder
+// the arguments with explicit indexes, e.g. %[2]s.
+//
+//go:embed catalogs/*.toml
+var catalogs embed.FS
+
+// Translator translates messages into a language. A nil Translator leaves
+// them in English.
+type Translator struct {
+	lang     string
+	messages map[string]string
+}
+
+// New returns the translator for lang, an ISO 639-1 code like "ar", or for
+// the language of the environment when lang is empty. English, and languages
+// of the environment that have no catalog, need no translation and give a
+// nil Translator.
+func New(lang string) (*Translator, error) {
+	explicit := lang != ""
+	if !explicit {
+		lang = envLanguage()
+	}
+	lang = strings.ToLower(lang)
+	if lang == "" || lang == "en" {
+		return nil, nil
+	}
+
+	raw, err := catalogs.ReadFile(path.Join("catalogs", lang+".toml"))
+	if err != nil {
+		if !explicit {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unsupported language %q, expected one of %s", lang, strings.Join(Languages(), ", "))
+	}
+
+	t := &Translator{lang: lang}
+	if err := toml.Unmarshal(raw, &t.messages); err != nil {
+		return nil, fmt.Errorf("invalid catalog for %s: %w", lang, err)
+	}
+	return t, nil
+}
+
+// Languages returns the codes of the supported languages, English included.
+func Languages() []string {
+	langs := []string{"en"}
+	entries, _ := catalogs.ReadDir("catalogs")
+	for _, e := range entries {
+		langs = append(langs, strings.TrimSuffix(e.Name(), ".toml"))
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// envLanguage returns the language of the environment from LANGUAGE,
+// LC_ALL, LC_MESSAGES or LANG, e.g. "fr" for fr_FR.UTF-8.
+func envLanguage() string {
+	for _, env := range []string{"LANGUAGE", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(env)
+		// LANGUAGE lists languages by preference.
+		v, _, _ = strings.Cut(v, ":")
+		if v == "" || v == "C" || v == "POSIX" {
+			continue
+		}
+		lang, _, _ := strings.Cut(v, "_")
+		lang, _, _ = strings.Cut(lang, ".")
+		return lang
+	}
+	return ""
+}
+
+// Lang returns the code of the language t translates into.
+func (t *Translator) Lang() string {
+	if t == nil {
+		return "en"
+	}
+	return t.lang
+}
+
+// T translates msg, formatting it with args as fmt.Sprintf does when there
+// are any.
+func (t *Translator) T(msg string, args ...any) string {
+	if t != nil {
+		if translated, ok := t.messages[msg]; ok {
+			msg = translated
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
@@ -0,0 +1,108 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// Event is a single event, as adhan writes them.
+type Event struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time
+}
+
+// Events reads the events of an iCalendar object. Recurring events are
+// returned once, at their first occurrence, and dates and times without a
+// time zone are in UTC.
+func Events(r io.Reader) ([]Event, error) {
+	components, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	for _, c := range components {
+		if c.kind != "VEVENT" {
+			continue
+		}
+		var e Event
+		if p, ok := c.get("UID"); ok {
+			e.UID = p.value
+		}
+		if p, ok := c.get("SUMMARY"); ok {
+			e.Summary = unescape(p.value)
+		}
+		if p, ok := c.get("DTSTART"); ok {
+			if e.Start, _, err = parseTime(p, time.UTC); err != nil {
+				return nil, err
+			}
+		}
+		if p, ok := c.get("DTEND"); ok {
+			if e.End, _, err = parseTime(p, time.UTC); err != nil {
+				return nil, err
+			}
+		} else if p, ok := c.get("DURATION"); ok {
+			d, err := parseDuration(p.value)
+			if err != nil {
+				return nil, err
+			}
+			e.End = e.Start.Add(d)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// Write writes events as an iCalendar object made by prodID, with their
+// times in UTC.
+func Write(w io.Writer, prodID string, events ...Event) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		// Lines are folded at 75 octets, the space starting the next
+		// included, without splitting a character.
+		for limit := 75; len(s) > limit; limit = 74 {
+			i := limit
+			for i > 0 && s[i]&0xC0 == 0x80 {
+				i--
+			}
+			bw.WriteString(s[:i] + "\r\n ")
+			s = s[i:]
+		}
+		bw.WriteString(s + "\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:" + prodID)
+	stamp := time.Now().UTC().Format(utcLayout)
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + stamp)
+		line("DTSTART:" + e.Start.UTC().Format(utcLayout))
+		line("DTEND:" + e.End.UTC().Format(utcLayout))
+		line("SUMMARY:" + escape(e.Summary))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// utcLayout is that of DATE-TIME values in UTC.
+const utcLayout = "20060102T150405Z"
+
+var (
+	escaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+// escape escapes a TEXT value.
+func escape(s string) string { return escaper.Replace(s) }
+
+// unescape unescapes a TEXT value.
+func unescape(s string) string { return unescaper.Replace(s) }
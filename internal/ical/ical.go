@@ -0,0 +1,472 @@
+// Package ical reads and writes the parts of iCalendar (RFC 5545) adhan
+// needs: when a calendar is busy, from its events or free/busy periods, and
+// the events of synced prayer times.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxOccurrences bounds how many times a recurring event is repeated in the
+// period asked for, for rules without an end.
+const maxOccurrences = 5000
+
+// Period is a stretch of time in which a calendar is busy.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// property is a content line, e.g. DTSTART;TZID=Europe/Paris:20240105T090000.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// component is a VEVENT or VFREEBUSY, with its properties by name.
+type component struct {
+	kind  string
+	props map[string][]property
+}
+
+func (c component) get(name string) (property, bool) {
+	p, ok := c.props[name]
+	if !ok {
+		return property{}, false
+	}
+	return p[0], true
+}
+
+// Busy reads an iCalendar feed and returns the periods between from and to
+// in which it is busy, sorted by start: those of its events, but the ones
+// marked free or cancelled, and its free/busy periods. Events recurring
+// daily, weekly, monthly on the same day, or yearly are repeated, other
+// recurring events only count once. Dates and times without a time zone are
+// in loc.
+func Busy(r io.Reader, from, to time.Time, loc *time.Location) ([]Period, error) {
+	components, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Occurrences moved or changed on their own replace those of the rule.
+	overridden := make(map[string]bool)
+	for _, c := range components {
+		id, ok := c.get("RECURRENCE-ID")
+		if !ok {
+			continue
+		}
+		uid, _ := c.get("UID")
+		if t, _, err := parseTime(id, loc); err == nil {
+			overridden[uid.value+"@"+t.UTC().Format(time.RFC3339)] = true
+		}
+	}
+
+	var periods []Period
+	for _, c := range components {
+		var ps []Period
+		switch c.kind {
+		case "VEVENT":
+			ps, err = eventPeriods(c, from, to, loc, overridden)
+		case "VFREEBUSY":
+			ps, err = freeBusyPeriods(c)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range ps {
+			if p.End.After(from) && p.Start.Before(to) {
+				periods = append(periods, p)
+			}
+		}
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+	return periods, nil
+}
+
+// parse returns the events and free/busy components of the feed read from
+// r, unfolding long lines.
+func parse(r io.Reader) ([]component, error) {
+	var (
+		components []component
+		current    *component
+		lines      []string
+		// depth is how deep in the sub-components of current, as alarms,
+		// the line is. Their properties are not those of current.
+		depth int
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if len(lines) > 0 {
+				lines[len(lines)-1] += line[1:]
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		p, err := parseProperty(line)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case p.name == "BEGIN" && current != nil:
+			depth++
+		case p.name == "END" && current != nil && depth > 0:
+			depth--
+		case depth > 0:
+		case p.name == "BEGIN" && (p.value == "VEVENT" || p.value == "VFREEBUSY"):
+			current = &component{kind: p.value, props: make(map[string][]property)}
+		case p.name == "END" && current != nil && p.value == current.kind:
+			components = append(components, *current)
+			current = nil
+		case current != nil:
+			current.props[p.name] = append(current.props[p.name], p)
+		}
+	}
+	return components, nil
+}
+
+// parseProperty splits a content line into its name, parameters and value.
+// The value starts at the first colon outside of quotes.
+func parseProperty(line string) (property, error) {
+	quoted := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, fmt.Errorf("invalid iCalendar line %q", line)
+	}
+
+	p := property{value: line[colon+1:], params: make(map[string]string)}
+	fields := strings.Split(line[:colon], ";")
+	p.name = strings.ToUpper(fields[0])
+	for _, f := range fields[1:] {
+		k, v, _ := strings.Cut(f, "=")
+		p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p, nil
+}
+
+// parseTime parses a DATE or DATE-TIME value, in UTC, in the time zone of
+// its TZID, or else in loc. It reports whether it is a date.
+func parseTime(p property, loc *time.Location) (time.Time, bool, error) {
+	value := strings.TrimSpace(p.value)
+	if p.params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		return t, false, err
+	}
+	if tzid := p.params["TZID"]; tzid != "" {
+		// Zones unknown to the system, as Windows names, fall back to loc.
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// parseDuration parses a duration such as PT1H30M, P1D or P2W.
+func parseDuration(s string) (time.Duration, error) {
+	invalid := fmt.Errorf("invalid iCalendar duration %q", s)
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") {
+		return 0, invalid
+	}
+
+	var d time.Duration
+	inTime := false
+	n := ""
+	for _, c := range s[1:] {
+		if c >= '0' && c <= '9' {
+			n += string(c)
+			continue
+		}
+		if c == 'T' {
+			inTime = true
+			continue
+		}
+		v, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, invalid
+		}
+		n = ""
+		switch {
+		case c == 'W' && !inTime:
+			d += time.Duration(v) * 7 * 24 * time.Hour
+		case c == 'D' && !inTime:
+			d += time.Duration(v) * 24 * time.Hour
+		case c == 'H' && inTime:
+			d += time.Duration(v) * time.Hour
+		case c == 'M' && inTime:
+			d += time.Duration(v) * time.Minute
+		case c == 'S' && inTime:
+			d += time.Duration(v) * time.Second
+		default:
+			return 0, invalid
+		}
+	}
+	if n != "" {
+		return 0, invalid
+	}
+	return sign * d, nil
+}
+
+// eventPeriods returns the periods of the occurrences of an event that may
+// overlap from to to, or none if it doesn't make the calendar busy.
+func eventPeriods(c component, from, to time.Time, loc *time.Location, overridden map[string]bool) ([]Period, error) {
+	if p, ok := c.get("TRANSP"); ok && strings.EqualFold(p.value, "TRANSPARENT") {
+		return nil, nil
+	}
+	if p, ok := c.get("STATUS"); ok && strings.EqualFold(p.value, "CANCELLED") {
+		return nil, nil
+	}
+	dtstart, ok := c.get("DTSTART")
+	if !ok {
+		return nil, nil
+	}
+	start, allDay, err := parseTime(dtstart, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	var length time.Duration
+	if p, ok := c.get("DTEND"); ok {
+		end, _, err := parseTime(p, loc)
+		if err != nil {
+			return nil, err
+		}
+		length = end.Sub(start)
+	} else if p, ok := c.get("DURATION"); ok {
+		if length, err = parseDuration(p.value); err != nil {
+			return nil, err
+		}
+	} else if allDay {
+		length = 24 * time.Hour
+	}
+
+	starts := []time.Time{start}
+	// Overrides have their own RECURRENCE-ID, rather than a rule.
+	if p, ok := c.get("RRULE"); ok {
+		if _, override := c.get("RECURRENCE-ID"); !override {
+			rule, err := parseRule(p.value, loc)
+			if err != nil {
+				return nil, err
+			}
+			// Occurrences starting before from may still be going on.
+			starts = rule.occurrences(start, from.Add(-length), to)
+		}
+	}
+
+	excluded := make(map[string]bool)
+	for _, p := range c.props["EXDATE"] {
+		for _, v := range strings.Split(p.value, ",") {
+			t, _, err := parseTime(property{params: p.params, value: v}, loc)
+			if err == nil {
+				excluded[t.UTC().Format(time.RFC3339)] = true
+			}
+		}
+	}
+	uid, _ := c.get("UID")
+	_, override := c.get("RECURRENCE-ID")
+
+	periods := make([]Period, 0, len(starts))
+	for _, s := range starts {
+		key := s.UTC().Format(time.RFC3339)
+		if excluded[key] || (!override && overridden[uid.value+"@"+key]) {
+			continue
+		}
+		periods = append(periods, Period{Start: s, End: s.Add(length)})
+	}
+	return periods, nil
+}
+
+// freeBusyPeriods returns the busy periods of a VFREEBUSY component.
+func freeBusyPeriods(c component) ([]Period, error) {
+	var periods []Period
+	for _, p := range c.props["FREEBUSY"] {
+		if t := p.params["FBTYPE"]; t != "" && !strings.HasPrefix(strings.ToUpper(t), "BUSY") {
+			continue
+		}
+		for _, v := range strings.Split(p.value, ",") {
+			from, until, ok := strings.Cut(v, "/")
+			if !ok {
+				return nil, fmt.Errorf("invalid iCalendar period %q", v)
+			}
+			start, err := time.Parse("20060102T150405Z", from)
+			if err != nil {
+				return nil, err
+			}
+			end, err := time.Parse("20060102T150405Z", until)
+			if err != nil {
+				d, derr := parseDuration(until)
+				if derr != nil {
+					return nil, err
+				}
+				end = start.Add(d)
+			}
+			periods = append(periods, Period{Start: start, End: end})
+		}
+	}
+	return periods, nil
+}
+
+// rule is a recurrence rule, RRULE.
+type rule struct {
+	freq     string
+	interval int
+	count    int
+	until    time.Time
+	byDay    []time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+func parseRule(s string, loc *time.Location) (rule, error) {
+	r := rule{interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		k, v, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			r.freq = strings.ToUpper(v)
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(v)
+		case "COUNT":
+			r.count, err = strconv.Atoi(v)
+		case "UNTIL":
+			r.until, _, err = parseTime(property{value: v}, loc)
+		case "BYDAY":
+			for _, d := range strings.Split(v, ",") {
+				day, ok := weekdays[strings.ToUpper(d)]
+				if !ok {
+					// Such as 2TU, the second Tuesday, not supported.
+					r.freq = ""
+					break
+				}
+				r.byDay = append(r.byDay, day)
+			}
+		}
+		if err != nil {
+			return rule{}, fmt.Errorf("invalid iCalendar rule %q: %w", s, err)
+		}
+	}
+	if r.interval < 1 {
+		return rule{}, errors.New("invalid iCalendar rule " + strconv.Quote(s) + ": interval below 1")
+	}
+	return r, nil
+}
+
+// occurrences returns the starts from from to to of the occurrences of an
+// event starting at start, only start for unsupported rules. COUNT counts
+// the occurrences from start.
+func (r rule) occurrences(start, from, to time.Time) []time.Time {
+	var starts []time.Time
+	n := 0
+	add := func(t time.Time) bool {
+		if t.Before(start) {
+			return true
+		}
+		if t.After(to) || (!r.until.IsZero() && t.After(r.until)) || (r.count > 0 && n >= r.count) || len(starts) >= maxOccurrences {
+			return false
+		}
+		n++
+		if !t.Before(from) {
+			starts = append(starts, t)
+		}
+		return true
+	}
+
+	switch {
+	case r.freq == "DAILY":
+		for i := 0; ; i += r.interval {
+			t := start.AddDate(0, 0, i)
+			if len(r.byDay) > 0 && !slices.Contains(r.byDay, t.Weekday()) {
+				if t.After(to) {
+					break
+				}
+				continue
+			}
+			if !add(t) {
+				break
+			}
+		}
+	case r.freq == "WEEKLY":
+		days := r.byDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+		// Weeks start on Monday.
+		monday := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		for week := 0; ; week += r.interval {
+			more := true
+			for offset := 0; offset < 7 && more; offset++ {
+				t := monday.AddDate(0, 0, 7*week+offset)
+				if slices.Contains(days, t.Weekday()) {
+					more = add(t)
+				}
+			}
+			if !more {
+				break
+			}
+		}
+	case r.freq == "MONTHLY" && len(r.byDay) == 0:
+		for i := 0; ; i += r.interval {
+			t := start.AddDate(0, i, 0)
+			// Months without the day, as the 31st, are skipped.
+			if t.Day() != start.Day() {
+				continue
+			}
+			if !add(t) {
+				break
+			}
+		}
+	case r.freq == "YEARLY" && len(r.byDay) == 0:
+		for i := 0; ; i += r.interval {
+			if !add(start.AddDate(i, 0, 0)) {
+				break
+			}
+		}
+	default:
+		return []time.Time{start}
+	}
+	return starts
+}
@@ -0,0 +1,278 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// feed returns a calendar of lines, CRLF terminated as in the wild.
+func feed(lines ...string) string {
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VCALENDAR\r\n"
+}
+
+func utc(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+}
+
+func TestEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  Event
+	}{
+		{
+			name: "folded lines",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:folded", "DTSTART:20240105T090000Z", "DTEND:20240105T100000Z",
+				"SUMMARY:Team mee", " ting", "END:VEVENT",
+			},
+			want: Event{UID: "folded", Summary: "Team meeting", Start: utc(2024, 1, 5, 9, 0), End: utc(2024, 1, 5, 10, 0)},
+		},
+		{
+			name: "escaped text",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:escaped", "DTSTART:20240105T090000Z", "DTEND:20240105T100000Z",
+				`SUMMARY:Fajr\, then Quran\; at home\nC:\\adhan`, "END:VEVENT",
+			},
+			want: Event{UID: "escaped", Summary: "Fajr, then Quran; at home\nC:\\adhan", Start: utc(2024, 1, 5, 9, 0), End: utc(2024, 1, 5, 10, 0)},
+		},
+		{
+			name: "duration",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:duration", "DTSTART:20240105T090000Z", "DURATION:PT1H30M", "SUMMARY:Lesson", "END:VEVENT",
+			},
+			want: Event{UID: "duration", Summary: "Lesson", Start: utc(2024, 1, 5, 9, 0), End: utc(2024, 1, 5, 10, 30)},
+		},
+		{
+			name: "alarm",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:alarm", "DTSTART:20240105T090000Z", "SUMMARY:Jumu'ah",
+				"BEGIN:VALARM", "TRIGGER:-PT15M", "DURATION:PT5M", "REPEAT:2", "SUMMARY:Reminder", "ACTION:DISPLAY", "END:VALARM",
+				"DURATION:PT1H", "END:VEVENT",
+			},
+			want: Event{UID: "alarm", Summary: "Jumu'ah", Start: utc(2024, 1, 5, 9, 0), End: utc(2024, 1, 5, 10, 0)},
+		},
+	}
+	for _, tt := range tests {
+		events, err := Events(strings.NewReader(feed(tt.lines...)))
+		if err != nil {
+			t.Errorf("%s: Events failed: %v", tt.name, err)
+			continue
+		}
+		if len(events) != 1 {
+			t.Errorf("%s: Events returned %d events, want 1", tt.name, len(events))
+			continue
+		}
+		e := events[0]
+		if e.UID != tt.want.UID || e.Summary != tt.want.Summary || !e.Start.Equal(tt.want.Start) || !e.End.Equal(tt.want.End) {
+			t.Errorf("%s: Events = %+v, want %+v", tt.name, e, tt.want)
+		}
+	}
+}
+
+func TestWriteEvents(t *testing.T) {
+	events := []Event{
+		{UID: "fajr-20240105@adhan", Summary: "Fajr", Start: utc(2024, 1, 5, 5, 12), End: utc(2024, 1, 5, 5, 42)},
+		{
+			UID: "isha-20240105@adhan",
+			// Long enough to be folded, with characters on the fold.
+			Summary: strings.Repeat("العشاء, ", 12) + "; done\nthen sleep",
+			Start:   time.Date(2024, 1, 5, 20, 40, 0, 0, time.FixedZone("EET", 2*60*60)),
+			End:     time.Date(2024, 1, 5, 21, 10, 0, 0, time.FixedZone("EET", 2*60*60)),
+		},
+	}
+	var b bytes.Buffer
+	if err := Write(&b, "-//adhan//EN", events...); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Write wrote a line of %d octets, want at most 75: %q", len(line), line)
+		}
+	}
+
+	got, err := Events(&b)
+	if err != nil {
+		t.Fatalf("Events failed: %v", err)
+	}
+	if len(got) != len(events) {
+		t.Fatalf("Events returned %d events, want %d", len(got), len(events))
+	}
+	for i, e := range got {
+		want := events[i]
+		if e.UID != want.UID || e.Summary != want.Summary || !e.Start.Equal(want.Start) || !e.End.Equal(want.End) {
+			t.Errorf("Events = %+v, want %+v", e, want)
+		}
+	}
+}
+
+func TestBusy(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+
+	tests := []struct {
+		name     string
+		lines    []string
+		from, to time.Time
+		want     []Period
+	}{
+		{
+			name:  "utc",
+			lines: []string{"BEGIN:VEVENT", "UID:a", "DTSTART:20240105T090000Z", "DTEND:20240105T100000Z", "END:VEVENT"},
+			from:  utc(2024, 1, 5, 0, 0), to: utc(2024, 1, 6, 0, 0),
+			want: []Period{{utc(2024, 1, 5, 9, 0), utc(2024, 1, 5, 10, 0)}},
+		},
+		{
+			name:  "floating",
+			lines: []string{"BEGIN:VEVENT", "UID:a", "DTSTART:20240105T090000", "DTEND:20240105T100000", "END:VEVENT"},
+			from:  utc(2024, 1, 5, 0, 0), to: utc(2024, 1, 6, 0, 0),
+			want: []Period{{utc(2024, 1, 5, 8, 0), utc(2024, 1, 5, 9, 0)}},
+		},
+		{
+			name: "tzid",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a", "DTSTART;TZID=America/New_York:20240105T090000",
+				`DTEND;TZID="America/New_York":20240105T100000`, "END:VEVENT",
+			},
+			from: utc(2024, 1, 5, 0, 0), to: utc(2024, 1, 6, 0, 0),
+			want: []Period{{time.Date(2024, 1, 5, 9, 0, 0, 0, newYork), time.Date(2024, 1, 5, 10, 0, 0, 0, newYork)}},
+		},
+		{
+			name:  "unknown tzid",
+			lines: []string{"BEGIN:VEVENT", "UID:a", "DTSTART;TZID=Romance Standard Time:20240105T090000", "DURATION:PT1H", "END:VEVENT"},
+			from:  utc(2024, 1, 5, 0, 0), to: utc(2024, 1, 6, 0, 0),
+			want: []Period{{utc(2024, 1, 5, 8, 0), utc(2024, 1, 5, 9, 0)}},
+		},
+		{
+			name:  "all day",
+			lines: []string{"BEGIN:VEVENT", "UID:a", "DTSTART;VALUE=DATE:20240105", "END:VEVENT"},
+			from:  utc(2024, 1, 5, 0, 0), to: utc(2024, 1, 7, 0, 0),
+			want: []Period{{time.Date(2024, 1, 5, 0, 0, 0, 0, paris), time.Date(2024, 1, 6, 0, 0, 0, 0, paris)}},
+		},
+		{
+			name:  "transparent",
+			lines: []string{"BEGIN:VEVENT", "UID:a", "DTSTART:20240105T090000Z", "DTEND:20240105T100000Z", "TRANSP:TRANSPARENT", "END:VEVENT"},
+			from:  utc(2024, 1, 5, 0, 0), to: utc(2024, 1, 6, 0, 0),
+		},
+		{
+			name: "alarm",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a", "DTSTART:20240105T090000Z",
+				"BEGIN:VALARM", "TRIGGER:-PT15M", "DURATION:PT5H", "ACTION:DISPLAY", "END:VALARM",
+				"DURATION:PT1H", "END:VEVENT",
+			},
+			from: utc(2024, 1, 5, 0, 0), to: utc(2024, 1, 6, 0, 0),
+			want: []Period{{utc(2024, 1, 5, 9, 0), utc(2024, 1, 5, 10, 0)}},
+		},
+		{
+			name:  "count",
+			lines: []string{"BEGIN:VEVENT", "UID:a", "DTSTART:20240105T090000Z", "DURATION:PT1H", "RRULE:FREQ=DAILY;COUNT=2", "END:VEVENT"},
+			from:  utc(2024, 1, 1, 0, 0), to: utc(2024, 2, 1, 0, 0),
+			want: []Period{
+				{utc(2024, 1, 5, 9, 0), utc(2024, 1, 5, 10, 0)},
+				{utc(2024, 1, 6, 9, 0), utc(2024, 1, 6, 10, 0)},
+			},
+		},
+		{
+			name:  "count before the period",
+			lines: []string{"BEGIN:VEVENT", "UID:a", "DTSTART:20240105T090000Z", "DURATION:PT1H", "RRULE:FREQ=DAILY;COUNT=3", "END:VEVENT"},
+			from:  utc(2024, 1, 6, 12, 0), to: utc(2024, 2, 1, 0, 0),
+			want: []Period{{utc(2024, 1, 7, 9, 0), utc(2024, 1, 7, 10, 0)}},
+		},
+		{
+			name:  "until",
+			lines: []string{"BEGIN:VEVENT", "UID:a", "DTSTART:20240105T090000Z", "DURATION:PT1H", "RRULE:FREQ=WEEKLY;INTERVAL=2;UNTIL=20240120T000000Z", "END:VEVENT"},
+			from:  utc(2024, 1, 1, 0, 0), to: utc(2024, 2, 1, 0, 0),
+			want: []Period{
+				{utc(2024, 1, 5, 9, 0), utc(2024, 1, 5, 10, 0)},
+				{utc(2024, 1, 19, 9, 0), utc(2024, 1, 19, 10, 0)},
+			},
+		},
+		{
+			name:  "by day",
+			lines: []string{"BEGIN:VEVENT", "UID:a", "DTSTART:20240101T090000Z", "DURATION:PT1H", "RRULE:FREQ=WEEKLY;BYDAY=MO,WE", "EXDATE:20240103T090000Z", "END:VEVENT"},
+			from:  utc(2024, 1, 1, 0, 0), to: utc(2024, 1, 11, 0, 0),
+			want: []Period{
+				{utc(2024, 1, 1, 9, 0), utc(2024, 1, 1, 10, 0)},
+				{utc(2024, 1, 8, 9, 0), utc(2024, 1, 8, 10, 0)},
+				{utc(2024, 1, 10, 9, 0), utc(2024, 1, 10, 10, 0)},
+			},
+		},
+		{
+			name:  "long running",
+			lines: []string{"BEGIN:VEVENT", "UID:a", "DTSTART:20000105T090000Z", "DTEND:20000105T100000Z", "RRULE:FREQ=DAILY", "END:VEVENT"},
+			from:  utc(2024, 1, 1, 9, 30), to: utc(2024, 1, 2, 12, 0),
+			want: []Period{
+				{utc(2024, 1, 1, 9, 0), utc(2024, 1, 1, 10, 0)},
+				{utc(2024, 1, 2, 9, 0), utc(2024, 1, 2, 10, 0)},
+			},
+		},
+		{
+			name: "free busy",
+			lines: []string{
+				"BEGIN:VFREEBUSY", "FREEBUSY;FBTYPE=BUSY:20240105T090000Z/20240105T100000Z,20240105T140000Z/PT30M",
+				"FREEBUSY;FBTYPE=FREE:20240105T120000Z/20240105T130000Z", "END:VFREEBUSY",
+			},
+			from: utc(2024, 1, 5, 0, 0), to: utc(2024, 1, 6, 0, 0),
+			want: []Period{
+				{utc(2024, 1, 5, 9, 0), utc(2024, 1, 5, 10, 0)},
+				{utc(2024, 1, 5, 14, 0), utc(2024, 1, 5, 14, 30)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := Busy(strings.NewReader(feed(tt.lines...)), tt.from, tt.to, paris)
+		if err != nil {
+			t.Errorf("%s: Busy failed: %v", tt.name, err)
+			continue
+		}
+		if !equalPeriods(got, tt.want) {
+			t.Errorf("%s: Busy = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func equalPeriods(a, b []Period) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Start.Equal(b[i].Start) || !a[i].End.Equal(b[i].End) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"PT1H30M", 90 * time.Minute},
+		{"P1D", 24 * time.Hour},
+		{"P2W", 14 * 24 * time.Hour},
+		{"P1DT2H3M4S", 26*time.Hour + 3*time.Minute + 4*time.Second},
+		{"-PT15M", -15 * time.Minute},
+		{"+PT5S", 5 * time.Second},
+	}
+	for _, tt := range tests {
+		got, err := parseDuration(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseDuration(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "1H", "PT1", "P1H", "PTD", "PT1X"} {
+		if d, err := parseDuration(in); err == nil {
+			t.Errorf("parseDuration(%q) = %v, want an error", in, d)
+		}
+	}
+}
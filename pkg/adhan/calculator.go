@@ -0,0 +1,116 @@
+package adhan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"iustusae/adhan/internal/calc"
+)
+
+// Calculator computes timings locally from the position of the sun. It needs
+// the coordinates of the location, and supports the methods aladhan does
+// except for the seasonal adjustments of the Moonsighting Committee.
+type Calculator struct{}
+
+var _ PrayerTimesProvider = Calculator{}
+
+func (Calculator) Calendar(ctx context.Context, q Query, year int, month time.Month) ([]Day, error) {
+	if !q.hasCoordinates() {
+		return nil, errors.New("local calculation needs coordinates")
+	}
+
+	method, ok := calc.Methods[q.Method]
+	if q.MethodSettings != "" {
+		var err error
+		if method, err = parseMethodSettings(q.MethodSettings); err != nil {
+			return nil, err
+		}
+		ok = true
+	}
+	if !ok {
+		return nil, fmt.Errorf("method %d can't be calculated locally", q.Method)
+	}
+
+	loc := time.Local
+	if q.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(q.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	params := calc.Params{
+		Latitude:  q.Latitude,
+		Longitude: q.Longitude,
+		Method:    method,
+		AsrFactor: float64(q.School + 1),
+	}
+
+	var days []Day
+	for day := time.Date(year, month, 1, 12, 0, 0, 0, loc); day.Month() == month; day = day.AddDate(0, 0, 1) {
+		times, err := calc.Compute(day, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate the prayer times of %s: %w", day.Format("2006-01-02"), err)
+		}
+		days = append(days, Day{
+			Timings: Timings{
+				Fajr:     times.Fajr.Format("15:04"),
+				Sunrise:  times.Sunrise.Format("15:04"),
+				Dhuhr:    times.Dhuhr.Format("15:04"),
+				Asr:      times.Asr.Format("15:04"),
+				Sunset:   times.Sunset.Format("15:04"),
+				Maghrib:  times.Maghrib.Format("15:04"),
+				Isha:     times.Isha.Format("15:04"),
+				Imsak:    times.Imsak.Format("15:04"),
+				Midnight: times.Midnight.Format("15:04"),
+			},
+			Date: Date{Gregorian: CalendarDate{Date: day.Format("02-01-2006")}},
+			Meta: Meta{Latitude: q.Latitude, Longitude: q.Longitude, Timezone: loc.String()},
+		})
+	}
+
+	return days, nil
+}
+
+// parseMethodSettings parses the "Fajr,Maghrib,Isha" methodSettings format
+// of the API, e.g. "18.5,null,90 min".
+func parseMethodSettings(s string) (calc.Method, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return calc.Method{}, fmt.Errorf("invalid method settings %q", s)
+	}
+
+	var angles [3]float64
+	var minutes [3]int
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "null" || part == "":
+		case strings.HasSuffix(part, "min"):
+			n, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(part, "min")))
+			if err != nil {
+				return calc.Method{}, fmt.Errorf("invalid method settings %q: %w", s, err)
+			}
+			minutes[i] = n
+		default:
+			angle, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return calc.Method{}, fmt.Errorf("invalid method settings %q: %w", s, err)
+			}
+			angles[i] = angle
+		}
+	}
+
+	return calc.Method{
+		Name:           "Custom",
+		Fajr:           angles[0],
+		Maghrib:        angles[1],
+		MaghribMinutes: minutes[1],
+		Isha:           angles[2],
+		IshaMinutes:    minutes[2],
+	}, nil
+}
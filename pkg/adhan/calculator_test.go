@@ -0,0 +1,41 @@
+package adhan
+
+import (
+	"testing"
+
+	"iustusae/adhan/internal/calc"
+)
+
+func TestParseMethodSettings(t *testing.T) {
+	tests := []struct {
+		in   string
+		want calc.Method
+	}{
+		{"18.5,null,90 min", calc.Method{Fajr: 18.5, IshaMinutes: 90}},
+		{"16,4,14", calc.Method{Fajr: 16, Maghrib: 4, Isha: 14}},
+		{"19.5, 3 min , 17.5", calc.Method{Fajr: 19.5, MaghribMinutes: 3, Isha: 17.5}},
+		{"18,5min,17", calc.Method{Fajr: 18, MaghribMinutes: 5, Isha: 17}},
+		// Empty fields are left unset, like null.
+		{"18,,", calc.Method{Fajr: 18}},
+		{",,", calc.Method{}},
+	}
+	for _, tt := range tests {
+		got, err := parseMethodSettings(tt.in)
+		if err != nil {
+			t.Errorf("parseMethodSettings(%q) failed: %v", tt.in, err)
+			continue
+		}
+		tt.want.Name = "Custom"
+		if got != tt.want {
+			t.Errorf("parseMethodSettings(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseMethodSettingsInvalid(t *testing.T) {
+	for _, in := range []string{"", "18", "18,17", "18,null,17,1", "18,null,abc", "18,null,x min", "18,null,1.5 min", "north,null,17"} {
+		if got, err := parseMethodSettings(in); err == nil {
+			t.Errorf("parseMethodSettings(%q) = %+v, want an error", in, got)
+		}
+	}
+}
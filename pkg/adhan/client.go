@@ -0,0 +1,204 @@
+package adhan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://api.aladhan.com/v1"
+
+const (
+	// DefaultMaxRetries is how many times a failed request is retried.
+	DefaultMaxRetries = 3
+
+	// backoffBase is the delay before the first retry, doubled on every
+	// following one.
+	backoffBase = time.Second
+)
+
+// defaultHTTPClient is shared by all clients, so that connections are
+// reused, and has a timeout so that a stalled request can't hang forever.
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// PrayerTimesProvider returns the timings of a whole month. Client is the
+// aladhan implementation.
+type PrayerTimesProvider interface {
+	Calendar(ctx context.Context, q Query, year int, month time.Month) ([]Day, error)
+}
+
+// Client talks to the aladhan API, or any mirror of it. Failed requests are
+// retried with exponential backoff.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	MaxRetries int
+}
+
+var _ PrayerTimesProvider = (*Client)(nil)
+
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{BaseURL: baseURL, HTTPClient: defaultHTTPClient, MaxRetries: DefaultMaxRetries}
+}
+
+// Query describes the location and calculation settings to fetch timings
+// for. Coordinates take precedence over the city when set.
+type Query struct {
+	City      string
+	Country   string
+	Latitude  float64
+	Longitude float64
+	Method    int
+	// School is 0 for Shafi'i and 1 for Hanafi Asr.
+	School int
+	// MethodSettings holds custom angles for method 99, e.g. "18.5,null,17.5".
+	MethodSettings string
+	// Timezone is an IANA time zone name, empty to let the API pick one.
+	Timezone string
+}
+
+func (q Query) hasCoordinates() bool {
+	return q.Latitude != 0 || q.Longitude != 0
+}
+
+// Calendar returns the timings of every day of the month, one entry per day.
+func (c *Client) Calendar(ctx context.Context, q Query, year int, month time.Month) ([]Day, error) {
+	query := url.Values{}
+	query.Set("method", strconv.Itoa(q.Method))
+	query.Set("school", strconv.Itoa(q.School))
+	if q.MethodSettings != "" {
+		query.Set("methodSettings", q.MethodSettings)
+	}
+	if q.Timezone != "" {
+		query.Set("timezonestring", q.Timezone)
+	}
+
+	// Coordinates are more precise than a city name, and work for places the
+	// API doesn't know about.
+	path := "calendarByCity"
+	if q.hasCoordinates() {
+		path = "calendar"
+		query.Set("latitude", strconv.FormatFloat(q.Latitude, 'f', -1, 64))
+		query.Set("longitude", strconv.FormatFloat(q.Longitude, 'f', -1, 64))
+	} else {
+		query.Set("city", q.City)
+		query.Set("country", q.Country)
+	}
+
+	var days []Day
+	if err := c.get(ctx, fmt.Sprintf("%s/%d/%d", path, year, month), query, &days); err != nil {
+		return nil, err
+	}
+
+	for i := range days {
+		days[i].Timings.stripZones()
+	}
+
+	return days, nil
+}
+
+// MethodInfo describes a calculation method as listed by the API. Params
+// hold angles as numbers and fixed delays as strings like "90 min".
+type MethodInfo struct {
+	ID     int            `json:"id"`
+	Name   string         `json:"name"`
+	Params map[string]any `json:"params"`
+}
+
+// Methods returns the calculation methods the API supports, ordered by id.
+func (c *Client) Methods(ctx context.Context) ([]MethodInfo, error) {
+	var byName map[string]MethodInfo
+	if err := c.get(ctx, "methods", nil, &byName); err != nil {
+		return nil, err
+	}
+
+	methods := make([]MethodInfo, 0, len(byName))
+	for _, m := range byName {
+		methods = append(methods, m)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].ID < methods[j].ID })
+
+	return methods, nil
+}
+
+// get fetches path and decodes the data field of the response into v,
+// retrying on network errors and server errors.
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	endpoint := strings.TrimSuffix(c.BaseURL, "/") + "/" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.do(ctx, endpoint, v)
+
+		var temporary *temporaryError
+		if err == nil || !errors.As(err, &temporary) || attempt >= c.MaxRetries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// temporaryError marks failures that are worth retrying.
+type temporaryError struct {
+	err error
+}
+
+func (e *temporaryError) Error() string { return e.err.Error() }
+func (e *temporaryError) Unwrap() error { return e.err }
+
+func (c *Client) do(ctx context.Context, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &temporaryError{err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("request to %s failed: %s", req.URL.Path, resp.Status)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return &temporaryError{err}
+		}
+		return err
+	}
+
+	response := struct {
+		Code   int    `json:"code"`
+		Status string `json:"status"`
+		Data   any    `json:"data"`
+	}{Data: v}
+	return json.NewDecoder(resp.Body).Decode(&response)
+}
+
+// backoff returns how long to wait before retrying after the given attempt:
+// an exponentially growing delay of which a random half is dropped, so that
+// clients failing together don't retry together.
+func backoff(attempt int) time.Duration {
+	d := backoffBase << attempt
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,74 @@
+package adhan
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is where the Scheduler gets the time from, so that it can run
+// against a simulated one.
+type Clock interface {
+	Now() time.Time
+	// Sleep waits for d to pass on the clock, or until ctx is done.
+	Sleep(ctx context.Context, d time.Duration) error
+	// AfterFunc calls f in its own goroutine once d passed on the clock.
+	AfterFunc(d time.Duration, f func()) *time.Timer
+}
+
+// SystemClock is the real clock.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+func (SystemClock) Sleep(ctx context.Context, d time.Duration) error { return sleep(ctx, d) }
+
+func (SystemClock) AfterFunc(d time.Duration, f func()) *time.Timer { return time.AfterFunc(d, f) }
+
+// SimulatedClock starts at a given time and runs a number of times faster
+// than the real one, to see what the Scheduler does at a given time without
+// waiting for it.
+type SimulatedClock struct {
+	mu     sync.Mutex
+	start  time.Time
+	origin time.Time
+	speed  float64
+}
+
+// NewSimulatedClock returns a clock starting at start and running speed
+// times faster than the real one.
+func NewSimulatedClock(start time.Time, speed float64) *SimulatedClock {
+	if speed <= 0 {
+		speed = 1
+	}
+	return &SimulatedClock{start: start, origin: time.Now(), speed: speed}
+}
+
+func (c *SimulatedClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.start.Add(time.Duration(float64(time.Since(c.origin)) * c.speed))
+}
+
+// Jump moves the clock by d at once, as when the machine wakes up from sleep
+// or the time is set.
+func (c *SimulatedClock) Jump(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.start = c.start.Add(d)
+}
+
+func (c *SimulatedClock) Sleep(ctx context.Context, d time.Duration) error {
+	return sleep(ctx, c.real(d))
+}
+
+func (c *SimulatedClock) AfterFunc(d time.Duration, f func()) *time.Timer {
+	return time.AfterFunc(c.real(d), f)
+}
+
+// real returns how long d on the clock lasts in real time.
+func (c *SimulatedClock) real(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / c.speed)
+}
@@ -0,0 +1,48 @@
+package adhan
+
+import "strconv"
+
+// Event is an Islamic occasion falling on a fixed day of the Hijri calendar.
+type Event struct {
+	Name string
+	// Month is the Hijri month, or 0 for an event that occurs every month.
+	Month int
+	Day   int
+}
+
+// Events lists the occasions adhan knows about, in calendar order.
+var Events = []Event{
+	{Name: "Islamic New Year", Month: 1, Day: 1},
+	{Name: "Ashura", Month: 1, Day: 10},
+	{Name: "White day", Day: 13},
+	{Name: "White day", Day: 14},
+	{Name: "White day", Day: 15},
+	{Name: "Start of Ramadan", Month: 9, Day: 1},
+	{Name: "Eid al-Fitr", Month: 10, Day: 1},
+	{Name: "Day of Arafah", Month: 12, Day: 9},
+	{Name: "Eid al-Adha", Month: 12, Day: 10},
+}
+
+// Events returns the events falling on the Hijri date of d, none when it is
+// unknown.
+func (d Date) Events() []Event {
+	day, err := strconv.Atoi(d.Hijri.Day)
+	if err != nil {
+		return nil
+	}
+	month := d.Hijri.Month.Number
+
+	var events []Event
+	for _, e := range Events {
+		if e.Day != day || (e.Month != 0 && e.Month != month) {
+			continue
+		}
+		// 13 Dhu al-Hijjah is one of the days of Tashreeq, on which fasting
+		// is forbidden.
+		if e.Month == 0 && month == 12 && day == 13 {
+			continue
+		}
+		events = append(events, e)
+	}
+	return events
+}
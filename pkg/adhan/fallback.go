@@ -0,0 +1,31 @@
+package adhan
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Fallback tries each provider in turn and returns the first calendar one of
+// them manages to provide, so that a single outage doesn't leave the caller
+// without timings.
+type Fallback []PrayerTimesProvider
+
+func (f Fallback) Calendar(ctx context.Context, q Query, year int, month time.Month) ([]Day, error) {
+	errs := make([]error, 0, len(f))
+	for _, p := range f {
+		days, err := p.Calendar(ctx, q, year, month)
+		if err == nil {
+			return days, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		return nil, errors.New("no prayer times provider configured")
+	}
+	return nil, errors.Join(errs...)
+}
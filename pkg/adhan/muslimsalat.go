@@ -0,0 +1,130 @@
+package adhan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const muslimSalatURL = "https://muslimsalat.com"
+
+// MuslimSalat fetches timings from muslimsalat.com, which needs an API key.
+// Only the methods it shares with aladhan are supported.
+type MuslimSalat struct {
+	Key        string
+	HTTPClient *http.Client
+}
+
+var _ PrayerTimesProvider = MuslimSalat{}
+
+// muslimSalatMethods maps aladhan method ids to muslimsalat ones.
+var muslimSalatMethods = map[int]int{
+	5: 1, // Egyptian General Authority of Survey
+	1: 2, // University of Islamic Sciences, Karachi
+	2: 4, // Islamic Society of North America
+	3: 5, // Muslim World League
+	4: 6, // Umm Al-Qura
+}
+
+func (m MuslimSalat) Calendar(ctx context.Context, q Query, year int, month time.Month) ([]Day, error) {
+	method, ok := muslimSalatMethods[q.Method]
+	if !ok || q.MethodSettings != "" {
+		return nil, fmt.Errorf("method %d is not supported by muslimsalat.com", q.Method)
+	}
+	if method == 2 && q.School == 1 {
+		method = 3 // Karachi with Hanafi Asr
+	}
+
+	location := strings.Join([]string{q.City, q.Country}, ", ")
+	if q.hasCoordinates() {
+		location = fmt.Sprintf("%f,%f", q.Latitude, q.Longitude)
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/monthly/01-%02d-%d/false/%d.json?key=%s",
+		muslimSalatURL, url.PathEscape(location), month, year, method, url.QueryEscape(m.Key))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := m.HTTPClient
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("muslimsalat request failed: %s", resp.Status)
+	}
+
+	var response struct {
+		StatusValid int `json:"status_valid"`
+		StatusError any `json:"status_error"`
+		Items       []struct {
+			Date    string `json:"date_for"`
+			Fajr    string `json:"fajr"`
+			Shurooq string `json:"shurooq"`
+			Dhuhr   string `json:"dhuhr"`
+			Asr     string `json:"asr"`
+			Maghrib string `json:"maghrib"`
+			Isha    string `json:"isha"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	if response.StatusValid != 1 {
+		return nil, fmt.Errorf("muslimsalat request failed: %v", response.StatusError)
+	}
+
+	var days []Day
+	for _, item := range response.Items {
+		date, err := time.Parse("2006-1-2", item.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid muslimsalat date %q: %w", item.Date, err)
+		}
+		if date.Year() != year || date.Month() != month {
+			continue
+		}
+
+		var t Timings
+		for _, f := range []struct {
+			dst *string
+			src string
+		}{
+			{&t.Fajr, item.Fajr},
+			{&t.Sunrise, item.Shurooq},
+			{&t.Dhuhr, item.Dhuhr},
+			{&t.Asr, item.Asr},
+			{&t.Maghrib, item.Maghrib},
+			{&t.Isha, item.Isha},
+		} {
+			hour, minute, _, err := ParseClock(f.src)
+			if err != nil {
+				return nil, fmt.Errorf("invalid muslimsalat time: %w", err)
+			}
+			*f.dst = fmt.Sprintf("%02d:%02d", hour, minute)
+		}
+		t.Sunset = t.Maghrib
+
+		days = append(days, Day{
+			Timings: t,
+			Date:    Date{Gregorian: CalendarDate{Date: date.Format("02-01-2006")}},
+		})
+	}
+
+	if len(days) == 0 {
+		return nil, errors.New("muslimsalat returned no timings for the month")
+	}
+	return days, nil
+}
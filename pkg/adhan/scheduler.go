@@ -0,0 +1,222 @@
+package adhan
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// retryDelay is how long the scheduler waits before trying again when
+	// the timings can't be loaded.
+	retryDelay = time.Minute
+	// checkInterval bounds how long the scheduler sleeps at once, so that it
+	// notices when the machine was asleep or the clock was changed.
+	checkInterval = 30 * time.Second
+	// clockJump is how far the wall clock has to drift from the monotonic
+	// clock over one sleep to be considered a jump.
+	clockJump = 5 * time.Second
+	// maxLateness is how late a prayer time may be noticed and still be
+	// announced as usual rather than as missed.
+	maxLateness = 2 * time.Minute
+)
+
+// Reminder is a notification scheduled around the prayers, such as a
+// warning that Suhoor is about to end.
+type Reminder struct {
+	Title   string
+	Message string
+	Time    time.Time
+}
+
+// Scheduler sleeps until the next prayer or reminder and calls OnPrayer or
+// OnReminder when it arrives, instead of polling the clock.
+type Scheduler struct {
+	// Today returns the timings of the current day.
+	Today func(ctx context.Context) (Day, error)
+	// Day returns the timings of the given date, used for the next Fajr once
+	// Isha has passed. Without it, Fajr is assumed to be at the same time as
+	// today's.
+	Day      func(ctx context.Context, date time.Time) (Day, error)
+	Location *time.Location
+	// Clock is the real one when nil.
+	Clock Clock
+
+	// Reminders returns the reminders to schedule around prayers, which are
+	// today's followed by the next one when it falls on the following day.
+	Reminders func(ctx context.Context, today Day, prayers []Prayer) []Reminder
+
+	// OnNext is called whenever the next prayer is scheduled.
+	OnNext func(today Day, next Prayer)
+	// OnPrayer is called when the time of a prayer arrives.
+	OnPrayer func(today Day, prayer Prayer)
+	// OnMissed is called instead of OnPrayer when the time of a prayer
+	// passed while the machine was asleep, or the clock was moved past it.
+	// Only the last prayer passed is reported. OnPrayer is called if it is
+	// nil.
+	OnMissed func(today Day, prayer Prayer)
+	// OnReminder is called when the time of a reminder arrives.
+	OnReminder func(today Day, reminder Reminder)
+	// OnError is called when the timings can't be loaded, before retrying.
+	OnError func(err error)
+}
+
+// Run schedules prayers until ctx is done, and returns its error.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		today, err := s.Today(ctx)
+		if err != nil {
+			s.fail(ctx, fmt.Errorf("failed to fetch prayer times: %w", err))
+			continue
+		}
+
+		now := s.now()
+		prayers, err := today.Prayers(now)
+		if err != nil {
+			s.fail(ctx, fmt.Errorf("failed to find next prayer: %w", err))
+			continue
+		}
+		var next Prayer
+		if s.Day != nil {
+			next, err = NextPrayerAcross(today, now, func(date time.Time) (Day, error) {
+				return s.Day(ctx, date)
+			})
+		} else {
+			next, err = today.NextPrayer(now)
+		}
+		if err != nil {
+			s.fail(ctx, fmt.Errorf("failed to find next prayer: %w", err))
+			continue
+		}
+		if next.Time.After(prayers[len(prayers)-1].Time) {
+			prayers = append(prayers, next)
+		}
+
+		if s.OnNext != nil {
+			s.OnNext(today, next)
+		}
+
+		// Wait for whatever comes first, the next prayer or a reminder.
+		at := next.Time
+		var reminders []Reminder
+		if s.Reminders != nil {
+			for _, r := range s.Reminders(ctx, today, prayers) {
+				if r.Time.After(now) && !r.Time.After(next.Time) {
+					reminders = append(reminders, r)
+					if r.Time.Before(at) {
+						at = r.Time
+					}
+				}
+			}
+		}
+
+		if err := s.sleepUntil(ctx, at); err != nil {
+			return err
+		}
+
+		// The clock may have been moved back, or forward without reaching
+		// anything, in which case the schedule is worked out again.
+		now = s.now()
+		for _, r := range reminders {
+			if !r.Time.After(now) && now.Sub(r.Time) <= maxLateness && s.OnReminder != nil {
+				s.OnReminder(today, r)
+			}
+		}
+		if !next.Time.After(now) {
+			s.arrive(today, prayers, next, now)
+		}
+	}
+}
+
+// arrive reports the prayer whose time came, or the last one passed when
+// several were slept through.
+func (s *Scheduler) arrive(today Day, prayers []Prayer, next Prayer, now time.Time) {
+	prayer := next
+	for _, p := range prayers {
+		if p.Time.After(next.Time) && !p.Time.After(now) {
+			prayer = p
+		}
+	}
+
+	switch {
+	case now.Sub(prayer.Time) > maxLateness && s.OnMissed != nil:
+		s.OnMissed(today, prayer)
+	case s.OnPrayer != nil:
+		s.OnPrayer(today, prayer)
+	}
+}
+
+func (s *Scheduler) now() time.Time {
+	if s.Clock != nil {
+		return s.Clock.Now().In(s.Location)
+	}
+	return time.Now().In(s.Location)
+}
+
+// sleepUntil sleeps until the wall clock reaches at. Timers follow the
+// monotonic clock, which ignores clock changes and on some systems stops
+// while the machine is asleep, so it wakes up every checkInterval to look at
+// the wall clock, and returns early when it jumped. It returns ctx's error if
+// ctx is done first.
+func (s *Scheduler) sleepUntil(ctx context.Context, at time.Time) error {
+	if _, real := s.Clock.(SystemClock); s.Clock != nil && !real {
+		// Simulated clocks are read after every sleep, which is enough to
+		// notice their jumps.
+		for {
+			d := at.Sub(s.Clock.Now())
+			if d <= 0 {
+				return nil
+			}
+			if d > checkInterval {
+				d = checkInterval
+			}
+			if err := s.Clock.Sleep(ctx, d); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		start := time.Now()
+		d := at.Sub(start.Round(0))
+		if d <= 0 {
+			return nil
+		}
+		if d > checkInterval {
+			d = checkInterval
+		}
+		if err := sleep(ctx, d); err != nil {
+			return err
+		}
+
+		// Round(0) strips the monotonic reading, leaving the wall clock.
+		drift := time.Now().Round(0).Sub(start.Round(0)) - time.Since(start)
+		if drift > clockJump || drift < -clockJump {
+			return nil
+		}
+	}
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) fail(ctx context.Context, err error) {
+	if s.OnError != nil && ctx.Err() == nil {
+		s.OnError(err)
+	}
+	sleep(ctx, retryDelay)
+}
@@ -0,0 +1,129 @@
+package adhan
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// testSpeed runs the clocks of the tests a minute a second, so that the two
+// minutes of maxLateness last two seconds.
+const testSpeed = 60
+
+// arrival is a prayer reported by the scheduler, on time or missed.
+type arrival struct {
+	name   string
+	missed bool
+}
+
+// runScheduler runs a scheduler of timings in loc on clock until the test
+// ends, and returns the prayers it reports.
+func runScheduler(t *testing.T, clock Clock, loc *time.Location, timings Timings) <-chan arrival {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	arrivals := make(chan arrival, 10)
+	s := &Scheduler{
+		Today: func(context.Context) (Day, error) {
+			return Day{Timings: timings, Meta: Meta{Timezone: loc.String()}}, nil
+		},
+		Location: loc,
+		Clock:    clock,
+		OnPrayer: func(_ Day, p Prayer) { arrivals <- arrival{name: p.Name} },
+		OnMissed: func(_ Day, p Prayer) { arrivals <- arrival{name: p.Name, missed: true} },
+		OnError:  func(err error) { t.Error(err) },
+	}
+	go s.Run(ctx)
+	return arrivals
+}
+
+// expect waits for the scheduler to report want.
+func expect(t *testing.T, arrivals <-chan arrival, want arrival) {
+	t.Helper()
+	select {
+	case got := <-arrivals:
+		if got != want {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("got nothing, want %+v", want)
+	}
+}
+
+// expectNone checks that the scheduler reports nothing for d.
+func expectNone(t *testing.T, arrivals <-chan arrival, d time.Duration) {
+	t.Helper()
+	select {
+	case got := <-arrivals:
+		t.Fatalf("got %+v, want nothing", got)
+	case <-time.After(d):
+	}
+}
+
+var testTimings = Timings{
+	Fajr: "05:00", Sunrise: "06:30", Dhuhr: "12:00", Asr: "15:30",
+	Sunset: "18:00", Maghrib: "18:00", Isha: "19:30",
+}
+
+func TestSchedulerPrayer(t *testing.T) {
+	clock := NewSimulatedClock(time.Date(2024, 3, 1, 11, 59, 0, 0, time.UTC), testSpeed)
+	arrivals := runScheduler(t, clock, time.UTC, testTimings)
+
+	expect(t, arrivals, arrival{name: "Dhuhr"})
+}
+
+func TestSchedulerSuspend(t *testing.T) {
+	clock := NewSimulatedClock(time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC), testSpeed)
+	arrivals := runScheduler(t, clock, time.UTC, testTimings)
+	expectNone(t, arrivals, time.Second)
+
+	// Waking up at 16:01, only the last prayer slept through is missed.
+	clock.Jump(5 * time.Hour)
+	expect(t, arrivals, arrival{name: "Asr", missed: true})
+
+	// The next one comes on time.
+	clock.Jump(time.Hour + 58*time.Minute)
+	expect(t, arrivals, arrival{name: "Maghrib"})
+}
+
+func TestSchedulerBackwardJump(t *testing.T) {
+	clock := NewSimulatedClock(time.Date(2024, 3, 1, 11, 59, 0, 0, time.UTC), testSpeed)
+	arrivals := runScheduler(t, clock, time.UTC, testTimings)
+
+	// Set back to 10:59, Dhuhr is an hour away again.
+	clock.Jump(-time.Hour)
+	expectNone(t, arrivals, 2*time.Second)
+
+	clock.Jump(58 * time.Minute)
+	expect(t, arrivals, arrival{name: "Dhuhr"})
+}
+
+func TestSchedulerDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	timings := testTimings
+	timings.Fajr = "03:01"
+
+	t.Run("forward", func(t *testing.T) {
+		// At 02:00 CET, clocks go forward to 03:00 CEST, so Fajr is two
+		// minutes after 01:59.
+		clock := NewSimulatedClock(time.Date(2024, 3, 31, 1, 59, 0, 0, berlin), testSpeed)
+		arrivals := runScheduler(t, clock, berlin, timings)
+
+		expect(t, arrivals, arrival{name: "Fajr"})
+	})
+
+	t.Run("back", func(t *testing.T) {
+		// At 03:00 CEST, clocks go back to 02:00 CET, so Fajr is an hour
+		// and two minutes after 02:59 CEST, 00:59 UTC.
+		clock := NewSimulatedClock(time.Date(2024, 10, 27, 0, 59, 0, 0, time.UTC), testSpeed)
+		arrivals := runScheduler(t, clock, berlin, timings)
+		expectNone(t, arrivals, 3*time.Second)
+
+		clock.Jump(57 * time.Minute)
+		expect(t, arrivals, arrival{name: "Fajr"})
+	})
+}
@@ -0,0 +1,255 @@
+// Package adhan fetches prayer times from the aladhan.com API and works out
+// when the next prayer is.
+package adhan
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timings are the prayer times of a day as "15:04" clock times.
+type Timings struct {
+	Fajr     string `json:"Fajr"`
+	Sunrise  string `json:"Sunrise"`
+	Dhuhr    string `json:"Dhuhr"`
+	Asr      string `json:"Asr"`
+	Sunset   string `json:"Sunset"`
+	Maghrib  string `json:"Maghrib"`
+	Isha     string `json:"Isha"`
+	Imsak    string `json:"Imsak"`
+	Midnight string `json:"Midnight"`
+}
+
+// Weekday is the name of a day of the week in English and Arabic.
+type Weekday struct {
+	En string `json:"en"`
+	Ar string `json:"ar"`
+}
+
+// Month is a month of the Gregorian or Hijri calendar, by number and name.
+type Month struct {
+	Number int    `json:"number"`
+	En     string `json:"en"`
+	Ar     string `json:"ar"`
+}
+
+// CalendarDate is a date in the Gregorian or Hijri calendar, with the
+// holidays falling on it.
+type CalendarDate struct {
+	Date     string   `json:"date"`
+	Day      string   `json:"day"`
+	Weekday  Weekday  `json:"weekday"`
+	Month    Month    `json:"month"`
+	Year     string   `json:"year"`
+	Holidays []string `json:"holidays"`
+}
+
+// Date is a day in both the Gregorian and Hijri calendars.
+type Date struct {
+	Readable  string       `json:"readable"`
+	Gregorian CalendarDate `json:"gregorian"`
+	Hijri     CalendarDate `json:"hijri"`
+}
+
+// HijriString formats the Hijri date, e.g. "3 Rabīʿ al-thānī 1448 AH". It is
+// empty when the date is unknown, as with locally calculated timings.
+func (d Date) HijriString() string {
+	if d.Hijri.Date == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s %s %s AH", d.Hijri.Day, d.Hijri.Month.En, d.Hijri.Year)
+}
+
+// Meta describes where the timings of a day are for.
+type Meta struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// Timezone is the IANA time zone the timings are expressed in.
+	Timezone string `json:"timezone"`
+}
+
+// Day holds the timings and date of a single day.
+type Day struct {
+	Timings Timings `json:"timings"`
+	Date    Date    `json:"date"`
+	Meta    Meta    `json:"meta"`
+}
+
+// Location returns the time zone the timings are expressed in, or nil when
+// it is unknown, as with timings cached by older versions.
+func (d Day) Location() *time.Location {
+	if d.Meta.Timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(d.Meta.Timezone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// in returns t in the time zone of the timings, when it is known.
+func (d Day) in(t time.Time) time.Time {
+	if loc := d.Location(); loc != nil {
+		return t.In(loc)
+	}
+	return t
+}
+
+// Prayers returns the prayers of the day, see Timings.Prayers. The times are
+// set in the time zone of the timings when it is known, so that they are
+// right whatever the zone of now.
+func (d Day) Prayers(now time.Time) ([]Prayer, error) {
+	return d.Timings.Prayers(d.in(now))
+}
+
+// NextPrayer returns the first prayer of the day after now, see NextPrayer.
+func (d Day) NextPrayer(now time.Time) (Prayer, error) {
+	return NextPrayer(d.Timings, d.in(now))
+}
+
+// Prayer is a prayer and the time it is due.
+type Prayer struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+}
+
+// Prayers returns the prayers of the day the timings are for, with their
+// times set on day in the location of day.
+func (t Timings) Prayers(day time.Time) ([]Prayer, error) {
+	names := []struct {
+		Name  string
+		Clock string
+	}{
+		{"Fajr", t.Fajr},
+		{"Sunrise", t.Sunrise},
+		{"Dhuhr", t.Dhuhr},
+		{"Asr", t.Asr},
+		{"Maghrib", t.Maghrib},
+		{"Isha", t.Isha},
+	}
+
+	year, month, date := day.Date()
+	prayers := make([]Prayer, 0, len(names))
+	for _, n := range names {
+		hour, minute, _, err := ParseClock(n.Clock)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s time: %w", n.Name, err)
+		}
+		at := time.Date(year, month, date, hour, minute, 0, 0, day.Location())
+		prayers = append(prayers, Prayer{Name: n.Name, Time: at})
+	}
+
+	return prayers, nil
+}
+
+// NextPrayer returns the first prayer after now. Once Isha has passed it
+// returns Fajr of the following day, at the same time as today's, see
+// NextPrayerAcross for the exact time.
+func NextPrayer(timings Timings, now time.Time) (Prayer, error) {
+	prayers, err := timings.Prayers(now)
+	if err != nil {
+		return Prayer{}, err
+	}
+
+	for _, prayer := range prayers {
+		if prayer.Time.After(now) {
+			return prayer, nil
+		}
+	}
+
+	fajr := prayers[0]
+	fajr.Time = fajr.Time.AddDate(0, 0, 1)
+	return fajr, nil
+}
+
+// NextPrayerAcross returns the first prayer after now like Day.NextPrayer,
+// except that once Isha has passed it returns Fajr from the timings of the
+// following day, given by day, rather than assuming it is at the same time as
+// today's. That assumption is only made when day fails.
+func NextPrayerAcross(today Day, now time.Time, day func(date time.Time) (Day, error)) (Prayer, error) {
+	next, err := today.NextPrayer(now)
+	if err != nil {
+		return next, err
+	}
+	prayers, err := today.Prayers(now)
+	if err != nil || !next.Time.After(prayers[len(prayers)-1].Time) {
+		return next, err
+	}
+
+	tomorrow, err := day(next.Time)
+	if err != nil {
+		return next, nil
+	}
+	fajr, err := tomorrow.Prayers(next.Time)
+	if err != nil {
+		return next, nil
+	}
+	return fajr[0], nil
+}
+
+// stripZones normalizes every time to "15:04", removing the zone
+// abbreviation the calendar endpoint appends, as in "05:12 (EET)". Times that
+// can't be parsed are left as they are, for Prayers to report.
+func (t *Timings) stripZones() {
+	for _, field := range []*string{&t.Fajr, &t.Sunrise, &t.Dhuhr, &t.Asr, &t.Sunset, &t.Maghrib, &t.Isha, &t.Imsak, &t.Midnight} {
+		if hour, minute, _, err := ParseClock(*field); err == nil {
+			*field = fmt.Sprintf("%02d:%02d", hour, minute)
+		}
+	}
+}
+
+// ParseClock parses a time of day in the forms providers emit: "05:12", with
+// a zone suffix as in "05:12 (EET)" or "05:12 (+03)", or on the 12-hour clock
+// as in "5:12 am". The zone suffix is returned without its parentheses, empty
+// when there is none.
+func ParseClock(s string) (hour, minute int, zone string, err error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, 0, "", errors.New("empty time")
+	}
+	invalid := func() (int, int, string, error) {
+		return 0, 0, "", fmt.Errorf("invalid time %q", s)
+	}
+
+	h, m, ok := strings.Cut(fields[0], ":")
+	if !ok {
+		return invalid()
+	}
+	if hour, err = strconv.Atoi(h); err != nil {
+		return invalid()
+	}
+	if minute, err = strconv.Atoi(m); err != nil || len(m) != 2 || minute < 0 || minute > 59 {
+		return invalid()
+	}
+
+	rest := fields[1:]
+	if len(rest) > 0 {
+		switch strings.ToLower(strings.ReplaceAll(rest[0], ".", "")) {
+		case "am", "pm":
+			if hour < 1 || hour > 12 {
+				return invalid()
+			}
+			hour %= 12
+			if strings.EqualFold(rest[0][:1], "p") {
+				hour += 12
+			}
+			rest = rest[1:]
+		}
+	}
+	if hour < 0 || hour > 23 {
+		return invalid()
+	}
+
+	switch {
+	case len(rest) == 0:
+	case len(rest) == 1 && strings.HasPrefix(rest[0], "(") && strings.HasSuffix(rest[0], ")"):
+		zone = strings.Trim(rest[0], "()")
+	default:
+		return invalid()
+	}
+	return hour, minute, zone, nil
+}
@@ -0,0 +1,52 @@
+package adhan
+
+import "testing"
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		in           string
+		hour, minute int
+		zone         string
+	}{
+		{"05:12", 5, 12, ""},
+		{"05:12 (EET)", 5, 12, "EET"},
+		{"05:12 (+03)", 5, 12, "+03"},
+		{"5:12 am", 5, 12, ""},
+		{"5:12 pm", 17, 12, ""},
+		{"5:12 PM (EET)", 17, 12, "EET"},
+		{"12:00 am", 0, 0, ""},
+		{"12:00 pm", 12, 0, ""},
+		{"23:59", 23, 59, ""},
+	}
+	for _, tt := range tests {
+		hour, minute, zone, err := ParseClock(tt.in)
+		if err != nil {
+			t.Errorf("ParseClock(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if hour != tt.hour || minute != tt.minute || zone != tt.zone {
+			t.Errorf("ParseClock(%q) = %d, %d, %q, want %d, %d, %q", tt.in, hour, minute, zone, tt.hour, tt.minute, tt.zone)
+		}
+	}
+}
+
+func TestParseClockInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"05",
+		"05:1",
+		"05:60",
+		"05:-1",
+		"24:00",
+		"-1:00",
+		"ab:cd",
+		"13:00 pm",
+		"0:30 am",
+		"05:12 EET",
+		"05:12 (EET) extra",
+	} {
+		if hour, minute, zone, err := ParseClock(in); err == nil {
+			t.Errorf("ParseClock(%q) = %d, %d, %q, want an error", in, hour, minute, zone)
+		}
+	}
+}